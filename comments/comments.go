@@ -0,0 +1,243 @@
+// Package comments keeps review comments on lines of a diff, anchored by
+// path, side and line numbers, in the git directory or in git notes, and
+// exports them as Markdown
+package comments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is where the comments are kept, relative to the git directory
+const FileName = "diff-tui/comments.json"
+
+// Side is the side of the diff a comment's line numbers refer to
+type Side string
+
+const (
+	Old Side = "old" // The original version
+	New Side = "new" // The modified version
+)
+
+// String names the side the way the diff panels are titled
+func (s Side) String() string {
+	if s == Old {
+		return "original"
+	}
+	return "modified"
+}
+
+// Comment is a review comment on one line or a range of lines
+type Comment struct {
+	Path    string    `json:"path"`
+	Side    Side      `json:"side"`
+	Start   int       `json:"start"` // First line, numbered as on its side
+	End     int       `json:"end"`   // Last line; the same as Start for one line
+	Body    string    `json:"body"`
+	Code    []string  `json:"code,omitempty"` // The lines commented on, as they were
+	Author  string    `json:"author,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Covers reports whether the comment is on line of side
+func (c Comment) Covers(side Side, line int) bool {
+	return c.Side == side && line >= c.Start && line <= c.End
+}
+
+// Lines describes the commented lines, as "line 12" or "lines 12-14"
+func (c Comment) Lines() string {
+	if c.End > c.Start {
+		return fmt.Sprintf("lines %d-%d", c.Start, c.End)
+	}
+	return fmt.Sprintf("line %d", c.Start)
+}
+
+// Store holds the comments of a repository
+type Store struct {
+	path     string // Empty for comments that are not saved
+	comments []Comment
+}
+
+// NewMemory returns a store that keeps its comments for the session only
+func NewMemory() *Store {
+	return &Store{}
+}
+
+// Load reads the comments kept in gitDir; a missing file means no comments
+func Load(gitDir string) (*Store, error) {
+	s := &Store{path: filepath.Join(gitDir, filepath.FromSlash(FileName))}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.comments); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	sortComments(s.comments)
+	return s, nil
+}
+
+// Dir returns the directory the store is saved in, or "" if it is not saved
+func (s *Store) Dir() string {
+	if s.path == "" {
+		return ""
+	}
+	return filepath.Dir(s.path)
+}
+
+// All returns the comments ordered by path, side and line
+func (s *Store) All() []Comment {
+	return s.comments
+}
+
+// ForFile returns the comments on path
+func (s *Store) ForFile(path string) []Comment {
+	var found []Comment
+	for _, c := range s.comments {
+		if c.Path == path {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// Add adds a comment
+func (s *Store) Add(c Comment) {
+	s.comments = append(s.comments, c)
+	sortComments(s.comments)
+}
+
+// Remove removes the i-th comment of All
+func (s *Store) Remove(i int) {
+	if i >= 0 && i < len(s.comments) {
+		s.comments = append(s.comments[:i], s.comments[i+1:]...)
+	}
+}
+
+// Delete removes the comments that are the same as c, wherever they are
+func (s *Store) Delete(c Comment) {
+	kept := s.comments[:0]
+	for _, other := range s.comments {
+		if !other.same(c) {
+			kept = append(kept, other)
+		}
+	}
+	s.comments = kept
+}
+
+// same reports whether two comments were written as one, ignoring the
+// commented lines they quote
+func (c Comment) same(o Comment) bool {
+	return c.Path == o.Path && c.Side == o.Side && c.Start == o.Start && c.End == o.End &&
+		c.Body == o.Body && c.Author == o.Author && c.Created.Equal(o.Created)
+}
+
+// Save writes the comments back, creating the directory they live in
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.comments, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0o644)
+}
+
+func sortComments(comments []Comment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Side != b.Side {
+			return a.Side == Old
+		}
+		return a.Start < b.Start
+	})
+}
+
+// ParseNote reads the comments of a git note written by FormatNote, skipping
+// the blank lines git notes merge can leave
+func ParseNote(text string) ([]Comment, error) {
+	var found []Comment
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var c Comment
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			return nil, fmt.Errorf("note line %d: %w", i+1, err)
+		}
+		found = append(found, c)
+	}
+	sortComments(found)
+	return found, nil
+}
+
+// FormatNote writes comments as a git note, one JSON object per line, so that
+// git notes merge -s cat_sort_uniq can merge them
+func FormatNote(comments []Comment) string {
+	var sb strings.Builder
+	for _, c := range comments {
+		data, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Markdown formats comments for a pull request or an email review: one
+// section per file, each comment quoting the lines it is on
+func Markdown(comments []Comment) string {
+	var sb strings.Builder
+	sb.WriteString("# Review comments\n")
+	path := ""
+	for _, c := range comments {
+		if c.Path != path {
+			path = c.Path
+			fmt.Fprintf(&sb, "\n## `%s`\n", path)
+		}
+		fmt.Fprintf(&sb, "\n**%s%s** (%s)", strings.ToUpper(c.Lines()[:1]), c.Lines()[1:], c.Side)
+		if c.Author != "" {
+			fmt.Fprintf(&sb, ", %s", c.Author)
+		}
+		sb.WriteString("\n\n")
+		if len(c.Code) > 0 {
+			fence := codeFence(c.Code)
+			sb.WriteString(fence + "\n")
+			for _, line := range c.Code {
+				sb.WriteString(line + "\n")
+			}
+			sb.WriteString(fence + "\n\n")
+		}
+		sb.WriteString(strings.TrimSpace(c.Body) + "\n")
+	}
+	return sb.String()
+}
+
+// codeFence returns a fence longer than any run of backticks in code
+func codeFence(code []string) string {
+	fence := "```"
+	for _, line := range code {
+		for strings.Contains(line, fence) {
+			fence += "`"
+		}
+	}
+	return fence
+}
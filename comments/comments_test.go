@@ -0,0 +1,96 @@
+package comments
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	gitDir := t.TempDir()
+	s, err := Load(gitDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	s.Add(Comment{Path: "b.go", Side: New, Start: 3, End: 3, Body: "third"})
+	s.Add(Comment{Path: "a.go", Side: New, Start: 10, End: 12, Body: "second"})
+	s.Add(Comment{Path: "a.go", Side: Old, Start: 20, End: 20, Body: "first"})
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	s, err = Load(gitDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var bodies []string
+	for _, c := range s.All() {
+		bodies = append(bodies, c.Body)
+	}
+	if got := strings.Join(bodies, ","); got != "first,second,third" {
+		t.Errorf("expected comments ordered by path, side and line, got %s", got)
+	}
+	if got := s.ForFile("a.go"); len(got) != 2 {
+		t.Errorf("expected 2 comments on a.go, got %d", len(got))
+	}
+
+	c := s.All()[1]
+	if !c.Covers(New, 11) || c.Covers(Old, 11) || c.Covers(New, 13) {
+		t.Error("expected the comment to cover lines 10-12 of the new side only")
+	}
+
+	s.Remove(0)
+	if len(s.All()) != 2 || s.All()[0].Body != "second" {
+		t.Error("expected the first comment to be removed")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got := Markdown([]Comment{
+		{Path: "a.go", Side: New, Start: 10, End: 11, Body: "Handle the error.\n", Code: []string{"x, _ := f()", "use(x)"}},
+		{Path: "a.go", Side: Old, Start: 3, End: 3, Body: "Why was this removed?", Author: "Ana"},
+		{Path: "doc.md", Side: New, Start: 1, End: 1, Body: "Typo", Code: []string{"```go"}},
+	})
+	want := "# Review comments\n" +
+		"\n## `a.go`\n" +
+		"\n**Lines 10-11** (modified)\n\n```\nx, _ := f()\nuse(x)\n```\n\nHandle the error.\n" +
+		"\n**Line 3** (original), Ana\n\nWhy was this removed?\n" +
+		"\n## `doc.md`\n" +
+		"\n**Line 1** (modified)\n\n````\n```go\n````\n\nTypo\n"
+	if got != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNote(t *testing.T) {
+	written := []Comment{
+		{Path: "b.go", Side: New, Start: 3, End: 4, Body: "Split\nthis", Code: []string{"a", "b"}, Author: "Ana"},
+		{Path: "a.go", Side: Old, Start: 1, End: 1, Body: "Why?"},
+	}
+	note := FormatNote(written)
+	if strings.Count(note, "\n") != 2 {
+		t.Fatalf("expected one line per comment, got %q", note)
+	}
+
+	// Notes merged with cat_sort_uniq are the lines of both, sorted
+	read, err := ParseNote("\n" + note + FormatNote(written[1:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 3 || read[0].Path != "a.go" || read[2].Body != "Split\nthis" || read[2].Code[1] != "b" {
+		t.Errorf("unexpected comments %+v", read)
+	}
+
+	s := NewMemory()
+	for _, c := range read {
+		s.Add(c)
+	}
+	s.Delete(written[1])
+	if len(s.All()) != 1 || s.All()[0].Path != "b.go" {
+		t.Errorf("expected both copies of the a.go comment to be deleted, got %+v", s.All())
+	}
+
+	if _, err := ParseNote("not json\n"); err == nil {
+		t.Error("expected an error for a note that is not comments")
+	}
+}
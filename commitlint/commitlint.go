@@ -0,0 +1,199 @@
+// Package commitlint checks commit messages against configurable rules such
+// as a subject length limit or the Conventional Commits grammar
+package commitlint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Severity decides whether an issue blocks the commit
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue is one problem found in a message
+type Issue struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Rule, i.Message)
+}
+
+// Message is a commit message split into lines
+type Message struct {
+	Subject string
+	Lines   []string // All lines, including the subject
+}
+
+// Body returns the lines after the subject and its separating blank line
+func (m Message) Body() []string {
+	if len(m.Lines) <= 1 {
+		return nil
+	}
+	body := m.Lines[1:]
+	if body[0] == "" {
+		body = body[1:]
+	}
+	return body
+}
+
+// ParseMessage splits a message into lines
+func ParseMessage(message string) Message {
+	message = strings.TrimRight(message, "\n")
+	lines := strings.Split(message, "\n")
+	return Message{Subject: lines[0], Lines: lines}
+}
+
+// Rule checks one aspect of a message
+type Rule interface {
+	Name() string
+	Check(msg Message) []string
+}
+
+// Linter runs a set of rules with a severity each
+type Linter struct {
+	rules    []Rule
+	severity map[string]Severity
+}
+
+// New creates a linter; rules report errors unless downgraded with Warn
+func New(rules ...Rule) *Linter {
+	return &Linter{rules: rules, severity: make(map[string]Severity)}
+}
+
+// Warn makes the named rule report warnings instead of errors
+func (l *Linter) Warn(rule string) {
+	l.severity[rule] = SeverityWarning
+}
+
+// Empty reports whether the linter has no rules
+func (l *Linter) Empty() bool {
+	return l == nil || len(l.rules) == 0
+}
+
+// Lint checks message against every rule
+func (l *Linter) Lint(message string) []Issue {
+	if l.Empty() {
+		return nil
+	}
+	msg := ParseMessage(message)
+
+	var issues []Issue
+	for _, r := range l.rules {
+		for _, problem := range r.Check(msg) {
+			issues = append(issues, Issue{Rule: r.Name(), Severity: l.severity[r.Name()], Message: problem})
+		}
+	}
+	return issues
+}
+
+// HasErrors reports whether any issue blocks the commit
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// SubjectMaxLength limits the length of the first line
+type SubjectMaxLength int
+
+func (r SubjectMaxLength) Name() string { return "subject-max-length" }
+
+func (r SubjectMaxLength) Check(msg Message) []string {
+	if n := len([]rune(msg.Subject)); n > int(r) {
+		return []string{fmt.Sprintf("subject is %d characters, limit is %d", n, int(r))}
+	}
+	return nil
+}
+
+// BlankLineAfterSubject requires a blank line between the subject and the body
+type BlankLineAfterSubject struct{}
+
+func (BlankLineAfterSubject) Name() string { return "blank-line-after-subject" }
+
+func (BlankLineAfterSubject) Check(msg Message) []string {
+	if len(msg.Lines) > 1 && msg.Lines[1] != "" {
+		return []string{"subject must be followed by a blank line"}
+	}
+	return nil
+}
+
+// BodyMaxLineLength requires the body to be wrapped; lines without spaces,
+// like long URLs, are allowed
+type BodyMaxLineLength int
+
+func (r BodyMaxLineLength) Name() string { return "body-max-line-length" }
+
+func (r BodyMaxLineLength) Check(msg Message) []string {
+	var problems []string
+	for i, line := range msg.Body() {
+		if len([]rune(line)) > int(r) && strings.ContainsRune(line, ' ') {
+			problems = append(problems, fmt.Sprintf("body line %d is longer than %d characters", i+1, int(r)))
+		}
+	}
+	return problems
+}
+
+// DefaultConventionalTypes are the commit types allowed when none are configured
+var DefaultConventionalTypes = []string{
+	"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test",
+}
+
+// conventionalRE matches "type(scope)!: description"
+var conventionalRE = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.*)$`)
+
+// Conventional enforces the Conventional Commits subject grammar
+type Conventional struct {
+	Types        []string // Allowed types; DefaultConventionalTypes when empty
+	Scopes       []string // Allowed scopes; any scope when empty
+	RequireScope bool
+}
+
+func (Conventional) Name() string { return "conventional-commits" }
+
+func (r Conventional) Check(msg Message) []string {
+	m := conventionalRE.FindStringSubmatch(msg.Subject)
+	if m == nil {
+		return []string{`subject must look like "type(scope): description"`}
+	}
+	typ, scope, description := m[1], m[2], m[4]
+
+	types := r.Types
+	if len(types) == 0 {
+		types = DefaultConventionalTypes
+	}
+
+	var problems []string
+	if !slices.Contains(types, typ) {
+		problems = append(problems, fmt.Sprintf("type %q is not one of %s", typ, strings.Join(types, ", ")))
+	}
+	switch {
+	case scope == "" && r.RequireScope:
+		problems = append(problems, "a scope is required")
+	case scope != "" && len(r.Scopes) > 0 && !slices.Contains(r.Scopes, scope):
+		problems = append(problems, fmt.Sprintf("scope %q is not one of %s", scope, strings.Join(r.Scopes, ", ")))
+	}
+	if strings.TrimSpace(description) == "" {
+		problems = append(problems, "description is empty")
+	}
+	return problems
+}
@@ -0,0 +1,61 @@
+package commitlint
+
+import "testing"
+
+func TestSubjectMaxLength(t *testing.T) {
+	l := New(SubjectMaxLength(10))
+	if issues := l.Lint("short"); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+	issues := l.Lint("this subject is too long")
+	if len(issues) != 1 || issues[0].Rule != "subject-max-length" || issues[0].Severity != SeverityError {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
+
+func TestConventional(t *testing.T) {
+	r := Conventional{Scopes: []string{"tui", "parser"}, RequireScope: true}
+
+	tests := []struct {
+		subject  string
+		problems int
+	}{
+		{"feat(tui): add commit modal", 0},
+		{"fix(parser)!: handle renames", 0},
+		{"add commit modal", 1},
+		{"feature(tui): add commit modal", 1},
+		{"feat: add commit modal", 1},
+		{"feat(cli): add flag", 1},
+		{"feat(tui): ", 1},
+	}
+	for _, tt := range tests {
+		if got := r.Check(ParseMessage(tt.subject)); len(got) != tt.problems {
+			t.Errorf("%q: got %v, want %d problems", tt.subject, got, tt.problems)
+		}
+	}
+}
+
+func TestBlankLineAndBodyWrap(t *testing.T) {
+	l := New(BlankLineAfterSubject{}, BodyMaxLineLength(20))
+	l.Warn("body-max-line-length")
+
+	issues := l.Lint("subject\nbody right away")
+	if len(issues) != 1 || issues[0].Rule != "blank-line-after-subject" {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+
+	issues = l.Lint("subject\n\nthis body line is definitely too long\nhttps://example.com/a/very/long/url/without/spaces")
+	if len(issues) != 1 || issues[0].Severity != SeverityWarning {
+		t.Fatalf("expected one wrap warning, got %v", issues)
+	}
+	if HasErrors(issues) {
+		t.Error("warnings must not count as errors")
+	}
+}
+
+func TestEmptyLinter(t *testing.T) {
+	var l *Linter
+	if issues := l.Lint("anything"); issues != nil {
+		t.Errorf("nil linter reported %v", issues)
+	}
+}
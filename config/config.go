@@ -0,0 +1,96 @@
+// Package config loads the optional .diff-tui.json file from the repository root
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"diff-tui/commitlint"
+)
+
+// FileName is the name of the config file looked up in the repository root
+const FileName = ".diff-tui.json"
+
+// Config is the contents of the config file; every section is optional
+type Config struct {
+	Commit CommitConfig `json:"commit"`
+	Viewed ViewedConfig `json:"viewed"`
+}
+
+// ViewedConfig configures the viewed marks of the file tree
+type ViewedConfig struct {
+	Collapse bool `json:"collapse"` // Collapse a directory once all its files are viewed
+}
+
+// CommitConfig configures the commit modal
+type CommitConfig struct {
+	Lint LintConfig `json:"lint"`
+}
+
+// LintConfig selects the commit message rules; unset rules are off
+type LintConfig struct {
+	SubjectMaxLength      int                 `json:"subjectMaxLength"`
+	BlankLineAfterSubject bool                `json:"blankLineAfterSubject"`
+	BodyMaxLineLength     int                 `json:"bodyMaxLineLength"`
+	Conventional          *ConventionalConfig `json:"conventional"`
+	Warnings              []string            `json:"warnings"` // Rule names reported as warnings instead of errors
+}
+
+// ConventionalConfig enables the Conventional Commits grammar
+type ConventionalConfig struct {
+	Types        []string `json:"types"`
+	Scopes       []string `json:"scopes"`
+	RequireScope bool     `json:"requireScope"`
+}
+
+// Load reads the config file in root; a missing file is an empty config
+func Load(root string) (*Config, error) {
+	cfg := &Config{}
+	if root == "" {
+		return cfg, nil
+	}
+
+	path := filepath.Join(root, FileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Linter builds the commit message linter for the configured rules
+func (c LintConfig) Linter() *commitlint.Linter {
+	var rules []commitlint.Rule
+	if c.SubjectMaxLength > 0 {
+		rules = append(rules, commitlint.SubjectMaxLength(c.SubjectMaxLength))
+	}
+	if c.Conventional != nil {
+		rules = append(rules, commitlint.Conventional{
+			Types:        c.Conventional.Types,
+			Scopes:       c.Conventional.Scopes,
+			RequireScope: c.Conventional.RequireScope,
+		})
+	}
+	if c.BlankLineAfterSubject {
+		rules = append(rules, commitlint.BlankLineAfterSubject{})
+	}
+	if c.BodyMaxLineLength > 0 {
+		rules = append(rules, commitlint.BodyMaxLineLength(c.BodyMaxLineLength))
+	}
+
+	l := commitlint.New(rules...)
+	for _, name := range c.Warnings {
+		l.Warn(name)
+	}
+	return l
+}
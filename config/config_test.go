@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Commit.Lint.Linter().Empty() {
+		t.Error("expected no lint rules without a config file")
+	}
+}
+
+func TestLoad_CommitLint(t *testing.T) {
+	dir := t.TempDir()
+	data := `{
+		"commit": {
+			"lint": {
+				"subjectMaxLength": 50,
+				"conventional": {"types": ["feat", "fix"]},
+				"blankLineAfterSubject": true,
+				"bodyMaxLineLength": 72,
+				"warnings": ["subject-max-length"]
+			}
+		}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := cfg.Commit.Lint.Linter().Lint("chore: this subject line is much longer than fifty characters")
+	rules := map[string]bool{}
+	for _, i := range issues {
+		rules[i.Rule] = true
+	}
+	if !rules["subject-max-length"] || !rules["conventional-commits"] || len(issues) != 2 {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,92 @@
+package diff
+
+// MergeChunk is a run of lines of a three-way merge: either lines both sides
+// agree on, or a conflict where ours and theirs changed the base differently
+type MergeChunk struct {
+	Conflict bool
+	Base     []string
+	Ours     []string
+	Theirs   []string
+}
+
+// Result returns the merged lines of a clean chunk, the side that changed the
+// base, or nil for a conflict
+func (c MergeChunk) Result() []string {
+	switch {
+	case c.Conflict:
+		return nil
+	case equalLines(c.Ours, c.Base):
+		return c.Theirs
+	}
+	return c.Ours
+}
+
+// Merge3 merges the changes ours and theirs made to base, the way diff3 does:
+// lines unchanged on both sides anchor the merge, and each stretch between
+// anchors is clean if at most one side changed it (or both made the same
+// change) and a conflict otherwise
+func Merge3(base, ours, theirs []string) []MergeChunk {
+	inOurs := unchangedIn(base, ours)
+	inTheirs := unchangedIn(base, theirs)
+
+	var chunks []MergeChunk
+	b, o, t := 0, 0, 0
+	for {
+		// Lines kept by both sides at the current positions
+		if b < len(base) && inOurs[b] == o && inTheirs[b] == t {
+			start := b
+			for b < len(base) && inOurs[b] == o && inTheirs[b] == t {
+				b, o, t = b+1, o+1, t+1
+			}
+			lines := base[start:b]
+			chunks = append(chunks, MergeChunk{Base: lines, Ours: lines, Theirs: lines})
+			continue
+		}
+
+		// Everything up to the next line kept by both is one changed stretch
+		next := b
+		for next < len(base) && (inOurs[next] < 0 || inTheirs[next] < 0) {
+			next++
+		}
+		nextOurs, nextTheirs := len(ours), len(theirs)
+		if next < len(base) {
+			nextOurs, nextTheirs = inOurs[next], inTheirs[next]
+		}
+		if next == b && nextOurs == o && nextTheirs == t {
+			return chunks
+		}
+
+		chunk := MergeChunk{Base: base[b:next], Ours: ours[o:nextOurs], Theirs: theirs[t:nextTheirs]}
+		chunk.Conflict = !equalLines(chunk.Ours, chunk.Base) && !equalLines(chunk.Theirs, chunk.Base) &&
+			!equalLines(chunk.Ours, chunk.Theirs)
+		chunks = append(chunks, chunk)
+		b, o, t = next, nextOurs, nextTheirs
+	}
+}
+
+// unchangedIn maps each line of base to its index in other, or -1 if the
+// line was changed or deleted
+func unchangedIn(base, other []string) []int {
+	index := make([]int, len(base))
+	for i := range index {
+		index[i] = -1
+	}
+	for _, e := range LineEdits(base, other) {
+		if e.Op == EditEqual {
+			index[e.OldIndex] = e.NewIndex
+		}
+	}
+	return index
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
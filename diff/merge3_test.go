@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// merged joins a merge's clean results and marks conflicts like git does
+func merged(chunks []MergeChunk) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		if c.Conflict {
+			sb.WriteString("<" + strings.Join(c.Ours, ",") + "|" + strings.Join(c.Theirs, ",") + ">")
+			continue
+		}
+		for _, line := range c.Result() {
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
+
+func TestMerge3(t *testing.T) {
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "")
+	}
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+	}{
+		{"unchanged", "abc", "abc", "abc", "abc"},
+		{"ours only", "abc", "aXc", "abc", "aXc"},
+		{"theirs only", "abc", "abc", "abYc", "abYc"},
+		{"both sides apart", "abcdef", "aXcdef", "abcdeY", "aXcdeY"},
+		{"same change", "abc", "aXc", "aXc", "aXc"},
+		{"conflict", "abc", "aXc", "aYc", "a<X|Y>c"},
+		{"conflict at end", "ab", "aX", "aY", "a<X|Y>"},
+		{"both added", "", "X", "Y", "<X|Y>"},
+		{"deleted by us", "abc", "", "aYc", "<|a,Y,c>"},
+		{"insert and delete apart", "abcde", "abXcde", "abcd", "abXcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := merged(Merge3(split(tt.base), split(tt.ours), split(tt.theirs)))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package diff
+
+// EditOp is the kind of a single edit in a line-level edit script
+type EditOp int
+
+const (
+	EditEqual EditOp = iota
+	EditDelete
+	EditInsert
+)
+
+// Edit is one step of an edit script turning a into b; the index of the side
+// it doesn't touch is -1
+type Edit struct {
+	Op       EditOp
+	OldIndex int
+	NewIndex int
+}
+
+// LineEdits computes a shortest edit script from a to b with linear-space
+// Myers, deletes before inserts within a change like git's unified diffs
+func LineEdits(a, b []string) []Edit {
+	// Common prefix and suffix never take part in the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, Edit{Op: EditEqual, OldIndex: i, NewIndex: i})
+	}
+
+	middle := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, e := range middle {
+		if e.OldIndex >= 0 {
+			e.OldIndex += prefix
+		}
+		if e.NewIndex >= 0 {
+			e.NewIndex += prefix
+		}
+		edits = append(edits, e)
+	}
+
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, Edit{
+			Op:       EditEqual,
+			OldIndex: len(a) - suffix + i,
+			NewIndex: len(b) - suffix + i,
+		})
+	}
+
+	return edits
+}
+
+// myers finds a shortest edit script in linear space: the middle snake of a
+// forward and a reverse search splits the problem in two, which are solved
+// the same way
+func myers(a, b []string) []Edit {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	edits := make([]Edit, 0, len(a)+len(b))
+	edits = myersSplit(a, b, 0, len(a), 0, len(b), edits)
+	return groupChanges(edits)
+}
+
+// myersSplit appends the edit script turning a[aLo:aHi] into b[bLo:bHi]
+func myersSplit(a, b []string, aLo, aHi, bLo, bHi int, edits []Edit) []Edit {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		edits = append(edits, Edit{Op: EditEqual, OldIndex: aLo, NewIndex: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-1-suffix] == b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			edits = append(edits, Edit{Op: EditInsert, OldIndex: -1, NewIndex: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			edits = append(edits, Edit{Op: EditDelete, OldIndex: x, NewIndex: -1})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b, aLo, aHi, bLo, bHi)
+		edits = myersSplit(a, b, aLo, x, bLo, y, edits)
+		for ; x < u; x, y = x+1, y+1 {
+			edits = append(edits, Edit{Op: EditEqual, OldIndex: x, NewIndex: y})
+		}
+		edits = myersSplit(a, b, u, aHi, v, bHi, edits)
+	}
+
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, Edit{Op: EditEqual, OldIndex: aHi + i, NewIndex: bHi + i})
+	}
+	return edits
+}
+
+// middleSnake searches non-empty a[aLo:aHi] and b[bLo:bHi] from both ends
+// and returns the middle snake (x, y)-(u, v) of a shortest edit script
+func middleSnake(a, b []string, aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// forward[k] is the furthest x reached on diagonal k = x - y from the
+	// start; backward[k] the same from the end, counting back
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var fx int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				fx = forward[offset+k+1]
+			} else {
+				fx = forward[offset+k-1] + 1
+			}
+			fy := fx - k
+			startX, startY := fx, fy
+			for fx < n && fy < m && a[aLo+fx] == b[bLo+fy] {
+				fx++
+				fy++
+			}
+			forward[offset+k] = fx
+
+			// The reverse search reached diagonal k at step d-1
+			if r := delta - k; odd && r >= -(d-1) && r <= d-1 && fx+backward[offset+r] >= n {
+				return aLo + startX, bLo + startY, aLo + fx, bLo + fy
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var bx int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				bx = backward[offset+k+1]
+			} else {
+				bx = backward[offset+k-1] + 1
+			}
+			by := bx - k
+			startX, startY := bx, by
+			for bx < n && by < m && a[aHi-1-bx] == b[bHi-1-by] {
+				bx++
+				by++
+			}
+			backward[offset+k] = bx
+
+			// The forward search reached diagonal k at step d
+			if f := delta - k; !odd && f >= -d && f <= d && bx+forward[offset+f] >= n {
+				return aHi - bx, bHi - by, aHi - startX, bHi - startY
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
+
+// groupChanges reorders each run of non-equal edits so all deletes come
+// before all inserts
+func groupChanges(edits []Edit) []Edit {
+	result := make([]Edit, 0, len(edits))
+	i := 0
+	for i < len(edits) {
+		if edits[i].Op == EditEqual {
+			result = append(result, edits[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(edits) && edits[j].Op != EditEqual {
+			j++
+		}
+		for _, e := range edits[i:j] {
+			if e.Op == EditDelete {
+				result = append(result, e)
+			}
+		}
+		for _, e := range edits[i:j] {
+			if e.Op == EditInsert {
+				result = append(result, e)
+			}
+		}
+		i = j
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// applyEdits rebuilds both inputs from an edit script to check its validity
+func applyEdits(t *testing.T, a, b []string, edits []Edit) {
+	t.Helper()
+
+	var gotA, gotB []string
+	for _, e := range edits {
+		switch e.Op {
+		case EditEqual:
+			if a[e.OldIndex] != b[e.NewIndex] {
+				t.Fatalf("equal edit pairs different lines %q and %q", a[e.OldIndex], b[e.NewIndex])
+			}
+			gotA = append(gotA, a[e.OldIndex])
+			gotB = append(gotB, b[e.NewIndex])
+		case EditDelete:
+			gotA = append(gotA, a[e.OldIndex])
+		case EditInsert:
+			gotB = append(gotB, b[e.NewIndex])
+		}
+	}
+
+	if strings.Join(gotA, "\n") != strings.Join(a, "\n") {
+		t.Errorf("edit script does not reproduce a: %q", gotA)
+	}
+	if strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+		t.Errorf("edit script does not reproduce b: %q", gotB)
+	}
+}
+
+func countOps(edits []Edit) (equal, del, ins int) {
+	for _, e := range edits {
+		switch e.Op {
+		case EditEqual:
+			equal++
+		case EditDelete:
+			del++
+		case EditInsert:
+			ins++
+		}
+	}
+	return
+}
+
+func TestLineEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		del, ins int
+	}{
+		{name: "identical", a: "a b c", b: "a b c", del: 0, ins: 0},
+		{name: "both empty", a: "", b: "", del: 0, ins: 0},
+		{name: "all inserted", a: "", b: "a b", del: 0, ins: 2},
+		{name: "all deleted", a: "a b", b: "", del: 2, ins: 0},
+		{name: "single change", a: "a b c", b: "a x c", del: 1, ins: 1},
+		{name: "insert in middle", a: "a c", b: "a b c", del: 0, ins: 1},
+		{name: "classic", a: "a b c a b b a", b: "c b a b a c", del: 3, ins: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := strings.Fields(tt.a)
+			b := strings.Fields(tt.b)
+
+			edits := LineEdits(a, b)
+			applyEdits(t, a, b, edits)
+
+			_, del, ins := countOps(edits)
+			if del != tt.del || ins != tt.ins {
+				t.Errorf("expected %d deletes/%d inserts, got %d/%d", tt.del, tt.ins, del, ins)
+			}
+		})
+	}
+}
+
+func TestLineEdits_DeletesBeforeInserts(t *testing.T) {
+	a := []string{"keep", "old1", "old2", "keep2"}
+	b := []string{"keep", "new1", "new2", "keep2"}
+
+	edits := LineEdits(a, b)
+
+	ops := make([]EditOp, len(edits))
+	for i, e := range edits {
+		ops[i] = e.Op
+	}
+	want := []EditOp{EditEqual, EditDelete, EditDelete, EditInsert, EditInsert, EditEqual}
+	if len(ops) != len(want) {
+		t.Fatalf("expected %d edits, got %d", len(want), len(ops))
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf("edit %d: expected op %d, got %d", i, want[i], ops[i])
+		}
+	}
+}
+
+func TestLineEdits_LargeRewrite(t *testing.T) {
+	a := make([]string, 4000)
+	b := make([]string, 4000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d", i)
+		b[i] = fmt.Sprintf("new %d", i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits := LineEdits(a, b)
+	runtime.ReadMemStats(&after)
+
+	applyEdits(t, a, b, edits)
+	if _, del, ins := countOps(edits); del != 4000 || ins != 4000 {
+		t.Errorf("expected 4000 deletes/4000 inserts, got %d/%d", del, ins)
+	}
+	// Linear space: the search must not keep a frontier per edit
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("allocated %d MB", allocated>>20)
+	}
+}
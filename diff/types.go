@@ -18,20 +18,42 @@ type Segment struct {
 type Line struct {
 	Type     LineType  // what type of line it is
 	Content  string    // actual content of that line
+	Number   int       // line number in its side's version of the file; 0 for placeholders
 	Segments []Segment // nil for non-modified lines; populated for word-level diff
 }
 
 type FileDiff struct {
-	Name       string
-	OldPath    string
-	NewPath    string
-	IsNew      bool
-	IsDeleted  bool
-	IsBinary   bool
-	AddCount   int
-	DelCount   int
-	LeftLines  []Line
-	RightLines []Line
+	Name        string
+	OldPath     string
+	NewPath     string
+	IsNew       bool
+	IsDeleted   bool
+	IsBinary    bool
+	IsUntracked bool   // not tracked by git yet; synthesised rather than parsed
+	OldHash     string // blob hash of the original side, possibly abbreviated; empty if there is none
+	NewHash     string // blob hash of the new side, likewise
+	AddCount    int
+	DelCount    int
+	LeftLines   []Line
+	RightLines  []Line
+	Submodule   *Submodule // non-nil for a gitlink, a change of submodule commit
+}
+
+// Submodule is the change a gitlink diff records: the submodule commit before
+// and after, plus the submodule's own history and changes between them when
+// they are known
+type Submodule struct {
+	OldCommit string // empty for a new submodule
+	NewCommit string // empty for a deleted submodule
+	Dirty     bool   // the submodule's worktree has changes of its own
+	Commits   []SubmoduleCommit
+	Files     []FileDiff // paths include the submodule path
+}
+
+// SubmoduleCommit is one commit between the old and new submodule commits
+type SubmoduleCommit struct {
+	Subject string
+	Removed bool // only reachable from the old commit, as after a rewind
 }
 
 type Result struct {
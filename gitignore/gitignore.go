@@ -0,0 +1,301 @@
+// Package gitignore matches worktree paths against .gitignore rules and walks
+// a worktree the way git sees it, skipping .git and ignored entries
+package gitignore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pattern is one compiled line of an ignore file
+type pattern struct {
+	base    string // Directory of the ignore file, relative to the root ("" for the root)
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher holds ignore patterns in increasing order of precedence
+type Matcher struct {
+	root     string
+	patterns []pattern
+	loaded   map[string]bool
+}
+
+// New creates a matcher for the worktree at root with core.excludesFile,
+// info/exclude in commonDir and the root .gitignore; Walk and LoadDir add
+// nested ones
+func New(root, commonDir string) *Matcher {
+	m := &Matcher{root: root, loaded: make(map[string]bool)}
+	if file := excludesFile(commonDir); file != "" {
+		m.addFile("", file)
+	}
+	m.addFile("", filepath.Join(commonDir, "info", "exclude"))
+	m.LoadDir("")
+	return m
+}
+
+// excludesFile returns core.excludesFile from the system, global and
+// repository config, or git's default of $XDG_CONFIG_HOME/git/ignore
+func excludesFile(commonDir string) string {
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+
+	var configs []string
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		configs = append(configs, "/etc/gitconfig")
+	}
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		configs = append(configs, global)
+	} else if home != "" {
+		configs = append(configs, filepath.Join(xdg, "git", "config"), filepath.Join(home, ".gitconfig"))
+	}
+	configs = append(configs, filepath.Join(commonDir, "config"))
+
+	file := ""
+	if xdg != "" {
+		file = filepath.Join(xdg, "git", "ignore")
+	}
+	for _, config := range configs {
+		if value, ok := configValue(config, "core", "excludesfile"); ok {
+			file = value
+		}
+	}
+	if rest, ok := strings.CutPrefix(file, "~/"); ok && home != "" {
+		file = filepath.Join(home, rest)
+	}
+	return file
+}
+
+// configValue does a minimal lookup of section.key in a git config file; the
+// last value wins, as in git
+func configValue(path, section, key string) (value string, found bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	current := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		name, v, ok := strings.Cut(line, "=")
+		if !ok || current != section || !strings.EqualFold(strings.TrimSpace(name), key) {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if unquoted, ok := strings.CutPrefix(v, `"`); ok {
+			v, _, _ = strings.Cut(unquoted, `"`)
+		} else if i := strings.IndexAny(v, "#;"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		value, found = v, true
+	}
+	return value, found
+}
+
+// LoadDir loads the .gitignore in dir (relative to the root) once
+func (m *Matcher) LoadDir(dir string) {
+	if m.loaded[dir] {
+		return
+	}
+	m.loaded[dir] = true
+	m.addFile(dir, filepath.Join(m.root, filepath.FromSlash(dir), ".gitignore"))
+}
+
+// AddPatterns adds the lines of an ignore file whose patterns are relative to dir
+func (m *Matcher) AddPatterns(dir, content string) {
+	for _, line := range strings.Split(content, "\n") {
+		if p, ok := compile(dir, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+func (m *Matcher) addFile(dir, file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	m.AddPatterns(dir, string(data))
+}
+
+// Match reports whether rel (slash-separated, relative to the root) is ignored
+// by its own patterns; the last matching pattern wins
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if p.base != "" {
+			var ok bool
+			target, ok = strings.CutPrefix(rel, p.base+"/")
+			if !ok {
+				continue
+			}
+		}
+		if p.re.MatchString(target) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Ignored reports whether rel or one of its parent directories is ignored,
+// loading the .gitignore files along the way
+func (m *Matcher) Ignored(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	if parts[0] == ".git" {
+		return true
+	}
+	for i := 1; i <= len(parts); i++ {
+		m.LoadDir(strings.Join(parts[:i-1], "/"))
+		if m.Match(strings.Join(parts[:i], "/"), i < len(parts) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn with the root-relative path of every file and directory that
+// is not ignored, never visiting .git
+func (m *Matcher) Walk(fn func(rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(m.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Entries can disappear while walking; that's not fatal
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if p == m.root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(m.root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" || m.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			m.LoadDir(rel)
+			return fn(rel, d)
+		}
+		if m.Match(rel, false) {
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
+
+// compile turns one ignore-file line into a pattern
+func compile(base, line string) (pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the ignore file's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+
+	re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// globToRegexp converts gitignore glob syntax, including **, to a regexp
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				switch {
+				case atStart && i+2 < len(glob) && glob[i+2] == '/':
+					// "**/" matches zero or more directories
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				case atStart && i+2 == len(glob):
+					// trailing "/**" matches everything inside
+					sb.WriteString(".*")
+					i++
+				default:
+					sb.WriteString("[^/]*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
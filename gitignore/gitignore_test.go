@@ -0,0 +1,152 @@
+package gitignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	m := &Matcher{loaded: map[string]bool{}}
+	m.AddPatterns("", `
+# comment
+*.log
+!keep.log
+/build
+node_modules/
+docs/**/*.tmp
+**/cache
+generated/**
+\#hash
+`)
+	m.AddPatterns("sub", "local.txt\n/rooted.txt\n")
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"deep/dir/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"node_modules", true, true},
+		{"node_modules", false, false},
+		{"a/node_modules", true, true},
+		{"docs/x.tmp", false, true},
+		{"docs/a/b/x.tmp", false, true},
+		{"other/x.tmp", false, false},
+		{"cache", true, true},
+		{"a/b/cache", false, true},
+		{"generated/x/y.go", false, true},
+		{"#hash", false, true},
+		{"main.go", false, false},
+		{"sub/local.txt", false, true},
+		{"sub/deeper/local.txt", false, true},
+		{"local.txt", false, false},
+		{"sub/rooted.txt", false, true},
+		{"sub/deeper/rooted.txt", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnored_ParentDirectory(t *testing.T) {
+	m := &Matcher{loaded: map[string]bool{}}
+	m.AddPatterns("", "out/\n!out/keep.txt\n")
+
+	if !m.Ignored("out/keep.txt", false) {
+		t.Error("expected file in ignored directory to stay ignored")
+	}
+	if !m.Ignored(".git/index", false) {
+		t.Error("expected .git contents to be ignored")
+	}
+	if m.Ignored("src/main.go", false) {
+		t.Error("expected src/main.go not to be ignored")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(".gitignore", "*.log\nvendor/\n")
+	write(".git/HEAD", "ref: refs/heads/main\n")
+	write("main.go", "")
+	write("debug.log", "")
+	write("vendor/lib.go", "")
+	write("pkg/.gitignore", "secret.txt\n")
+	write("pkg/secret.txt", "")
+	write("pkg/util.go", "")
+
+	var files []string
+	err := New(root, filepath.Join(root, ".git")).Walk(func(rel string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	sort.Strings(files)
+	want := []string{".gitignore", "main.go", "pkg/.gitignore", "pkg/util.go"}
+	if len(files) != len(want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d: expected %s, got %s", i, want[i], files[i])
+		}
+	}
+}
+
+func TestNew_ExcludesFiles(t *testing.T) {
+	root, common, home := t.TempDir(), t.TempDir(), t.TempDir()
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A linked worktree's info/exclude is in the common directory
+	write(filepath.Join(common, "info", "exclude"), "*.exclude\n")
+	write(filepath.Join(home, "git", "ignore"), "*.xdg\n")
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "none"))
+
+	m := New(root, common)
+	if !m.Match("a.exclude", false) || !m.Match("a.xdg", false) || m.Match("a.go", false) {
+		t.Error("expected info/exclude and $XDG_CONFIG_HOME/git/ignore to apply")
+	}
+
+	// core.excludesFile replaces the default
+	write(filepath.Join(home, "gitconfig"), "[core]\n\texcludesFile = "+filepath.Join(home, "global-ignore")+"\n")
+	write(filepath.Join(home, "global-ignore"), "*.global\n")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, "gitconfig"))
+
+	m = New(root, common)
+	if !m.Match("a.global", false) || m.Match("a.xdg", false) {
+		t.Error("expected core.excludesFile to replace the default")
+	}
+}
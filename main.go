@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"diff-tui/config"
+	"diff-tui/memrepo"
+	"diff-tui/native"
 	"diff-tui/parser"
 	"diff-tui/tui"
+	"diff-tui/watch"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -17,23 +23,274 @@ func main() {
 	ctx := context.Background()
 	args := os.Args[1:]
 
-	// Parse git diff with provided arguments
+	// Demo mode runs against an in-memory repository
+	if len(args) == 1 && args[0] == "--demo" {
+		runDemo(ctx)
+		return
+	}
+
+	opts, args, err := parseOptions(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Every git command is also appended to the log file, for bug reports
+	var logFile io.Writer
+	if opts.logFile != "" {
+		f, err := os.OpenFile(opts.logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logFile = f
+	}
+
+	if opts.backend == "native" {
+		runNative(ctx, args, logFile, opts.watch)
+		return
+	}
+
 	p := parser.New()
+	if logFile != nil {
+		p.GitRunner().CommandLog().SetOutput(logFile)
+	}
+	if !p.IsGitRepository(ctx) {
+		handleError(parser.ErrNotGitRepo)
+		return
+	}
+
+	// Get the git root folder name for display in the tree
+	rootName, gitRoot := "", ""
+	if root, err := p.GitRunner().FindGitRoot(ctx); err == nil {
+		rootName, gitRoot = filepath.Base(root), root
+	}
+	cfg := loadConfig(gitRoot)
+
+	if isReview(args) {
+		runReview(ctx, p.GitRunner(), args[1:], rootName, cfg)
+		return
+	}
+	if isRangeDiff(args) {
+		runRangeDiff(ctx, p.GitRunner(), args[1:], rootName, cfg)
+		return
+	}
+
+	// Without args show staged and unstaged changes side by side in the tree
+	if parser.IsWorkingTreeDiff(args) {
+		wt, err := p.GitRunner().WorkingTree(ctx)
+		if err == nil && wt.IsEmpty() {
+			err = parser.ErrEmptyDiff
+		}
+		if err != nil {
+			handleError(err)
+			return
+		}
+		runTUI(withWatch(tui.NewWorkingTreeModel(wt, p.GitRunner(), rootName).WithConfig(cfg), gitRoot, opts.watch))
+		return
+	}
+
+	// Parse git diff with provided arguments
 	result, err := p.ParseGitDiff(ctx, args...)
 	if err != nil {
 		handleError(err)
 		return
 	}
 
-	// Get the git root folder name for display in the tree
-	rootName := ""
-	if gitRoot, err := p.GitRunner().FindGitRoot(ctx); err == nil {
-		rootName = filepath.Base(gitRoot)
+	// Pass GitRunner, args, and rootName to enable staging/commit features
+	model := tui.NewModel(result.Files, p.GitRunner(), args, rootName).WithConfig(cfg)
+	runTUI(withWatch(model, gitRoot, opts.watch))
+}
+
+func runDemo(ctx context.Context) {
+	repo := memrepo.Demo()
+	wt, err := repo.WorkingTree(ctx)
+	if err != nil {
+		handleError(err)
+		return
 	}
+	runTUI(tui.NewWorkingTreeModel(wt, repo, "demo"))
+}
 
-	// Pass GitRunner, args, and rootName to enable staging/commit features
-	model := tui.NewModel(result.Files, p.GitRunner(), args, rootName)
-	runTUI(model)
+// options are diff-tui's own flags; all other args are passed to git diff
+type options struct {
+	backend string // "git" or "native"
+	logFile string
+	watch   bool // Refresh when the worktree, index or HEAD change
+}
+
+// parseOptions extracts --backend=<git|native>, --log-file <path> and --watch
+// from args
+func parseOptions(args []string) (options, []string, error) {
+	opts := options{backend: "git"}
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--backend="):
+			opts.backend = strings.TrimPrefix(arg, "--backend=")
+			if opts.backend != "git" && opts.backend != "native" {
+				return opts, nil, fmt.Errorf("unknown backend %q (want git or native)", opts.backend)
+			}
+		case strings.HasPrefix(arg, "--log-file="):
+			opts.logFile = strings.TrimPrefix(arg, "--log-file=")
+		case arg == "--log-file":
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("--log-file needs a path")
+			}
+			i++
+			opts.logFile = args[i]
+		case arg == "--watch":
+			opts.watch = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if opts.watch && (isReview(rest) || isRangeDiff(rest)) {
+		return opts, nil, fmt.Errorf("--watch does not work with %s", rest[0])
+	}
+	return opts, rest, nil
+}
+
+// runNative reads the repository from .git directly instead of running git
+func runNative(ctx context.Context, args []string, logFile io.Writer, watchChanges bool) {
+	repo, err := native.Open("", "")
+	if err != nil {
+		handleError(err)
+		return
+	}
+	if logFile != nil {
+		repo.CommandLog().SetOutput(logFile)
+	}
+	rootName := filepath.Base(repo.Root())
+	cfg := loadConfig(repo.Root())
+
+	if isReview(args) {
+		runReview(ctx, repo, args[1:], rootName, cfg)
+		return
+	}
+	if isRangeDiff(args) {
+		runRangeDiff(ctx, repo, args[1:], rootName, cfg)
+		return
+	}
+
+	if parser.IsWorkingTreeDiff(args) {
+		wt, err := repo.WorkingTree(ctx)
+		if err == nil && wt.IsEmpty() {
+			err = parser.ErrEmptyDiff
+		}
+		if err != nil {
+			handleError(err)
+			return
+		}
+		runTUI(withWatch(tui.NewWorkingTreeModel(wt, repo, rootName).WithConfig(cfg), repo.Root(), watchChanges))
+		return
+	}
+
+	files, err := repo.Diff(ctx, args...)
+	if err == nil && len(files) == 0 {
+		err = parser.ErrEmptyDiff
+	}
+	if err != nil {
+		handleError(err)
+		return
+	}
+	runTUI(withWatch(tui.NewModel(files, repo, args, rootName).WithConfig(cfg), repo.Root(), watchChanges))
+}
+
+// isReview reports whether args run the review subcommand
+func isReview(args []string) bool {
+	return len(args) > 0 && args[0] == "review"
+}
+
+// reviewRepo is a repository that can load a branch review
+type reviewRepo interface {
+	parser.Repository
+	parser.Reviewer
+}
+
+// runReview shows the commits of HEAD since it forked from the base branch,
+// starting with their combined diff
+func runReview(ctx context.Context, repo reviewRepo, args []string, rootName string, cfg *config.Config) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: diff-tui review <base>")
+		os.Exit(2)
+	}
+
+	review, err := repo.Review(ctx, args[0])
+	if err != nil {
+		handleError(err)
+		return
+	}
+	files, err := repo.Diff(ctx, review.Args()...)
+	if err != nil {
+		handleError(err)
+		return
+	}
+	runTUI(tui.NewReviewModel(review, files, repo, rootName).WithConfig(cfg))
+}
+
+// isRangeDiff reports whether args run the range-diff subcommand
+func isRangeDiff(args []string) bool {
+	return len(args) > 0 && args[0] == "range-diff"
+}
+
+// rangeDiffRepo is a repository that can compare two versions of a series
+type rangeDiffRepo interface {
+	parser.Repository
+	parser.RangeDiffer
+}
+
+// runRangeDiff pairs up the commits of two versions of a series with git
+// range-diff and opens the pairs over the working tree
+func runRangeDiff(ctx context.Context, repo rangeDiffRepo, args []string, rootName string, cfg *config.Config) {
+	if len(args) < 1 || len(args) > 3 {
+		fmt.Fprintln(os.Stderr, "Usage: diff-tui range-diff <base> <old> <new>")
+		fmt.Fprintln(os.Stderr, "       diff-tui range-diff <old-base>..<old> <new-base>..<new>")
+		fmt.Fprintln(os.Stderr, "       diff-tui range-diff <old>...<new>")
+		os.Exit(2)
+	}
+
+	pairs, err := repo.RangeDiff(ctx, args...)
+	if err == nil && len(pairs) == 0 {
+		err = parser.ErrEmptyDiff
+	}
+	if err != nil {
+		handleError(err)
+		return
+	}
+	wt, err := repo.WorkingTree(ctx)
+	if err != nil {
+		handleError(err)
+		return
+	}
+	runTUI(tui.NewWorkingTreeModel(wt, repo, rootName).WithConfig(cfg).WithRangeDiff(args, pairs))
+}
+
+// loadConfig reads .diff-tui.json from the repository root; a broken file is
+// reported and ignored rather than stopping the viewer
+func loadConfig(root string) *config.Config {
+	cfg, err := config.Load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring config: %v\n", err)
+		return &config.Config{}
+	}
+	return cfg
+}
+
+// withWatch makes the model refresh itself on changes to the worktree at root
+// when --watch was given; a watcher that can't start is reported and skipped
+func withWatch(model tui.Model, root string, on bool) tui.Model {
+	if !on {
+		return model
+	}
+	w, err := watch.New(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not watching for changes: %v\n", err)
+		return model
+	}
+	return model.WithWatcher(w)
 }
 
 func runTUI(model tui.Model) {
@@ -43,7 +300,11 @@ func runTUI(model tui.Model) {
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if m, ok := final.(tui.Model); ok {
+		m.Close()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -56,6 +317,10 @@ func handleError(err error) {
 		fmt.Fprintln(os.Stderr, "Run this command from within a git repository")
 		os.Exit(1)
 
+	case errors.Is(err, parser.ErrNoCommits):
+		fmt.Fprintf(os.Stderr, "Nothing to review (%v)\n", err)
+		os.Exit(0)
+
 	case errors.Is(err, parser.ErrEmptyDiff):
 		fmt.Fprintln(os.Stderr, "No changes to display")
 		fmt.Fprintln(os.Stderr, "Try: diff-tui HEAD~1  or  diff-tui --staged")
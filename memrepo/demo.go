@@ -0,0 +1,59 @@
+package memrepo
+
+import "context"
+
+// Demo returns a repository with a few staged, unstaged and untracked
+// changes, used by diff-tui --demo
+func Demo() *Repo {
+	r := New(map[string]string{
+		"README.md": "# demo\n\nA small project used to show off diff-tui.\n",
+		"main.go": `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(greet("world"))
+}
+`,
+		"greet.go": `package main
+
+func greet(name string) string {
+	return "hello " + name
+}
+`,
+	})
+
+	// A staged change
+	r.WriteFile("greet.go", `package main
+
+import "strings"
+
+// greet builds a friendly greeting
+func greet(name string) string {
+	return "Hello, " + strings.TrimSpace(name) + "!"
+}
+`)
+	r.StageFile(context.Background(), "greet.go")
+
+	// An unstaged change on top of the committed version
+	r.WriteFile("main.go", `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	name := "world"
+	if len(os.Args) > 1 {
+		name = os.Args[1]
+	}
+	fmt.Println(greet(name))
+}
+`)
+
+	// An untracked file
+	r.WriteFile("docs/usage.md", "# Usage\n\n    demo [name]\n")
+
+	return r
+}
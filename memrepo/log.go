@@ -0,0 +1,80 @@
+package memrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+var _ parser.LogReader = (*Repo)(nil)
+
+// Log lists the commits made with Commit, newest first; Rev is not supported
+func (r *Repo) Log(ctx context.Context, opts parser.LogOptions) ([]parser.LogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if opts.Rev != "" && opts.Rev != "HEAD" {
+		return nil, fmt.Errorf("memrepo: unknown revision %q", opts.Rev)
+	}
+
+	var entries []parser.LogEntry
+	for i := len(r.commits) - 1; i >= 0; i-- {
+		c := r.commits[i]
+		if opts.Search != "" && !strings.Contains(strings.ToLower(c.Message), strings.ToLower(opts.Search)) {
+			continue
+		}
+		entries = append(entries, r.logEntry(i))
+	}
+
+	entries = entries[min(opts.Skip, len(entries)):]
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+	return entries, nil
+}
+
+// CommitDiff diffs a commit against the one before it
+func (r *Repo) CommitDiff(ctx context.Context, hash string) ([]diff.FileDiff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.commits {
+		if commitHash(i) != hash && commitHash(i)[:7] != hash {
+			continue
+		}
+		parent := r.initial
+		if i > 0 {
+			parent = r.commits[i-1].Files
+		}
+		return diffMaps(parent, r.commits[i].Files, true), nil
+	}
+	return nil, fmt.Errorf("memrepo: unknown commit %q", hash)
+}
+
+func (r *Repo) logEntry(i int) parser.LogEntry {
+	c := r.commits[i]
+	subject, body := parser.SplitCommitMessage(c.Message)
+	name, email, _ := strings.Cut(strings.TrimSuffix(Committer, ">"), " <")
+
+	entry := parser.LogEntry{
+		Hash:      commitHash(i),
+		ShortHash: commitHash(i)[:7],
+		Author:    name,
+		Email:     email,
+		Date:      c.Date,
+		Subject:   subject,
+		Body:      body,
+	}
+	if i > 0 {
+		entry.Parents = []string{commitHash(i - 1)}
+	}
+	return entry
+}
+
+// commitHash is a made-up hash for the i-th commit
+func commitHash(i int) string {
+	return fmt.Sprintf("%07x%033x", i+1, 0)
+}
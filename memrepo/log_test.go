@@ -0,0 +1,47 @@
+package memrepo
+
+import (
+	"context"
+	"testing"
+
+	"diff-tui/parser"
+)
+
+func TestRepo_LogAndCommitDiff(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "one\n"})
+	for _, subject := range []string{"Fix one", "Add b", "fix two"} {
+		r.WriteFile("a.txt", subject+"\n")
+		if subject == "Add b" {
+			r.WriteFile("b.txt", "b\n")
+			if err := r.StageFile(ctx, "b.txt"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := r.StageFile(ctx, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Commit(ctx, parser.CommitOptions{Message: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := r.Log(ctx, parser.LogOptions{Skip: 1, Limit: 1})
+	if err != nil || len(entries) != 1 || entries[0].Subject != "Add b" {
+		t.Fatalf("Log = %+v, %v", entries, err)
+	}
+	if found, _ := r.Log(ctx, parser.LogOptions{Search: "FIX"}); len(found) != 2 {
+		t.Errorf("search found %d commits, want 2", len(found))
+	}
+
+	files, err := r.CommitDiff(ctx, entries[0].ShortHash)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("CommitDiff = %+v, %v", files, err)
+	}
+
+	all, _ := r.Log(ctx, parser.LogOptions{})
+	first, err := r.CommitDiff(ctx, all[2].Hash)
+	if err != nil || len(first) != 1 || first[0].IsNew {
+		t.Errorf("first commit should diff against the initial files, got %+v, %v", first, err)
+	}
+}
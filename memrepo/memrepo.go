@@ -0,0 +1,323 @@
+// Package memrepo provides an in-memory parser.Repository, with HEAD, the
+// index and the worktree as path-to-content maps, for tests and demos
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+// ErrUnsupportedArgs is returned by Diff for args the fake does not understand
+var ErrUnsupportedArgs = errors.New("memrepo: unsupported diff arguments")
+
+// ErrNothingToCommit is returned by Commit when the index matches HEAD
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// ErrEmptyMessage is returned by Commit when the message is empty after cleanup
+var ErrEmptyMessage = errors.New("empty commit message")
+
+// Committer is the identity used for Signed-off-by trailers
+const Committer = "Demo User <demo@example.com>"
+
+// Commit is a commit recorded by Repo.Commit
+type Commit struct {
+	Message string
+	Files   map[string]string
+	Date    time.Time
+}
+
+// Repo is an in-memory repository
+type Repo struct {
+	mu       sync.Mutex
+	initial  map[string]string // HEAD before the first Commit
+	head     map[string]string
+	index    map[string]string
+	worktree map[string]string
+	commits  []Commit
+	template string
+	stashes  []stash // Newest first
+}
+
+var (
+	_ parser.Repository           = (*Repo)(nil)
+	_ parser.CommitTemplateReader = (*Repo)(nil)
+)
+
+// New creates a repository whose HEAD, index and worktree all hold files
+func New(files map[string]string) *Repo {
+	return &Repo{
+		initial:  copyFiles(files),
+		head:     copyFiles(files),
+		index:    copyFiles(files),
+		worktree: copyFiles(files),
+	}
+}
+
+// WriteFile changes path in the worktree, like editing the file on disk
+func (r *Repo) WriteFile(path, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.worktree[path] = content
+}
+
+// RemoveFile deletes path from the worktree
+func (r *Repo) RemoveFile(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.worktree, path)
+}
+
+// SetCommitTemplate sets the content returned by CommitTemplate
+func (r *Repo) SetCommitTemplate(template string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.template = template
+}
+
+// CommitTemplate returns the template set with SetCommitTemplate
+func (r *Repo) CommitTemplate(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.template, nil
+}
+
+// Commits returns the commits made so far, oldest first
+func (r *Repo) Commits() []Commit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Commit(nil), r.commits...)
+}
+
+// Diff supports the working-tree diff (no args), --cached/--staged and HEAD
+func (r *Repo) Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case len(args) == 0:
+		return diffMaps(r.index, r.worktree, false), nil
+	case len(args) == 1 && (args[0] == "--cached" || args[0] == "--staged"):
+		return diffMaps(r.head, r.index, true), nil
+	case len(args) == 1 && args[0] == "HEAD":
+		return diffMaps(r.head, r.worktree, true), nil
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnsupportedArgs, args)
+}
+
+// WorkingTree returns the staged and unstaged changes, including untracked files
+func (r *Repo) WorkingTree(ctx context.Context) (*parser.WorkingTree, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unstaged := diffMaps(r.index, r.worktree, false)
+	for _, path := range sortedPaths(r.worktree) {
+		if _, tracked := r.index[path]; !tracked {
+			unstaged = append(unstaged, parser.NewUntrackedFileDiff(path, []byte(r.worktree[path])))
+		}
+	}
+
+	return &parser.WorkingTree{
+		Staged:   &diff.Result{Files: diffMaps(r.head, r.index, true)},
+		Unstaged: &diff.Result{Files: unstaged},
+	}, nil
+}
+
+// Status reports ordinary entries for tracked changes and untracked files
+func (r *Repo) Status(ctx context.Context) (*parser.Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Build porcelain v2 output so the real parser produces the Status
+	var out []byte
+	for _, path := range unionPaths(r.head, r.index, r.worktree) {
+		headContent, inHead := r.head[path]
+		indexContent, inIndex := r.index[path]
+		wtContent, inWorktree := r.worktree[path]
+
+		if !inHead && !inIndex {
+			out = append(out, "? "+path+"\x00"...)
+			continue
+		}
+
+		x := statusCode(headContent, inHead, indexContent, inIndex)
+		y := statusCode(indexContent, inIndex, wtContent, inWorktree)
+		if x == parser.StatusUnmodified && y == parser.StatusUnmodified {
+			continue
+		}
+
+		entry := fmt.Sprintf("1 %c%c N... 100644 100644 100644 %040x %040x %s\x00", x, y, 0, 0, path)
+		out = append(out, entry...)
+	}
+
+	return parser.ParseStatus(string(out))
+}
+
+// StageFile copies the worktree version of path into the index
+func (r *Repo) StageFile(ctx context.Context, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, ok := r.worktree[path]
+	if !ok {
+		if _, tracked := r.index[path]; !tracked {
+			return fmt.Errorf("pathspec '%s' did not match any files", path)
+		}
+		delete(r.index, path)
+		return nil
+	}
+	r.index[path] = content
+	return nil
+}
+
+// UnstageFile resets the index entry of path to HEAD
+func (r *Repo) UnstageFile(ctx context.Context, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if content, ok := r.head[path]; ok {
+		r.index[path] = content
+	} else {
+		delete(r.index, path)
+	}
+	return nil
+}
+
+// Commit makes the index the new HEAD; an amend replaces the last commit
+func (r *Repo) Commit(ctx context.Context, opts parser.CommitOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !opts.Amend && len(diffMaps(r.head, r.index, true)) == 0 {
+		return ErrNothingToCommit
+	}
+
+	message := opts.Message
+	if !opts.Verbatim {
+		message = parser.CleanupCommitWhitespace(message)
+	}
+	if message == "" {
+		return ErrEmptyMessage
+	}
+	if opts.SignOff {
+		message += "\n\nSigned-off-by: " + Committer
+	}
+
+	commit := Commit{Message: message, Files: copyFiles(r.index), Date: time.Now()}
+	if opts.Amend && len(r.commits) > 0 {
+		r.commits[len(r.commits)-1] = commit
+	} else {
+		r.commits = append(r.commits, commit)
+	}
+	r.head = copyFiles(r.index)
+	return nil
+}
+
+// HeadMessage returns the message of the last commit made
+func (r *Repo) HeadMessage(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.commits) == 0 {
+		return "", nil
+	}
+	return r.commits[len(r.commits)-1].Message, nil
+}
+
+// ReadFile reads path from HEAD, the index or the worktree
+func (r *Repo) ReadFile(ctx context.Context, rev, path string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var files map[string]string
+	switch rev {
+	case parser.WorktreeRev:
+		files = r.worktree
+	case parser.IndexRev:
+		files = r.index
+	case "HEAD":
+		files = r.head
+	default:
+		return nil, fmt.Errorf("memrepo: unknown revision %q", rev)
+	}
+
+	content, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	return []byte(content), nil
+}
+
+// diffMaps diffs every path in from/to; untracked paths only count when
+// includeNew is set (git diff without args ignores them)
+func diffMaps(from, to map[string]string, includeNew bool) []diff.FileDiff {
+	var files []diff.FileDiff
+	for _, path := range unionPaths(from, to) {
+		oldContent, inOld := from[path]
+		newContent, inNew := to[path]
+
+		switch {
+		case inOld && inNew && oldContent == newContent:
+			continue
+		case !inOld && !includeNew:
+			continue
+		}
+
+		var oldBytes, newBytes []byte
+		if inOld {
+			oldBytes = []byte(oldContent)
+		}
+		if inNew {
+			newBytes = []byte(newContent)
+		}
+		files = append(files, parser.DiffContents(path, oldBytes, newBytes, parser.DefaultContextLines))
+	}
+	return files
+}
+
+// statusCode is the porcelain status letter for a change from one side to the other
+func statusCode(oldContent string, inOld bool, newContent string, inNew bool) parser.StatusCode {
+	switch {
+	case !inOld && inNew:
+		return parser.StatusAdded
+	case inOld && !inNew:
+		return parser.StatusDeleted
+	case inOld && oldContent != newContent:
+		return parser.StatusModified
+	}
+	return parser.StatusUnmodified
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	result := make(map[string]string, len(files))
+	for path, content := range files {
+		result[path] = content
+	}
+	return result
+}
+
+func sortedPaths(files map[string]string) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func unionPaths(maps ...map[string]string) []string {
+	union := make(map[string]string)
+	for _, m := range maps {
+		for path := range m {
+			union[path] = ""
+		}
+	}
+	return sortedPaths(union)
+}
@@ -0,0 +1,130 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"diff-tui/parser"
+)
+
+func TestRepo_StageAndCommit(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "one\n"})
+
+	r.WriteFile("a.txt", "one\ntwo\n")
+	r.WriteFile("b.txt", "new\n")
+
+	wt, err := r.WorkingTree(ctx)
+	if err != nil {
+		t.Fatalf("WorkingTree failed: %v", err)
+	}
+	if len(wt.Staged.Files) != 0 || len(wt.Unstaged.Files) != 2 {
+		t.Fatalf("expected 0 staged/2 unstaged, got %d/%d", len(wt.Staged.Files), len(wt.Unstaged.Files))
+	}
+	if !wt.Unstaged.Files[1].IsUntracked {
+		t.Error("expected b.txt to be untracked")
+	}
+
+	if err := r.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatalf("StageFile failed: %v", err)
+	}
+
+	status, err := r.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	a, ok := status.Get("a.txt")
+	if !ok || a.XY() != "M." {
+		t.Errorf("expected a.txt staged as M., got %q", a.XY())
+	}
+	b, ok := status.Get("b.txt")
+	if !ok || !b.IsUntracked() {
+		t.Error("expected b.txt untracked")
+	}
+
+	staged, err := r.Diff(ctx, "--cached")
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(staged) != 1 || staged[0].AddCount != 1 {
+		t.Fatalf("expected one staged file with 1 addition, got %+v", staged)
+	}
+
+	if err := r.Commit(ctx, parser.CommitOptions{Message: "add two"}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if commits := r.Commits(); len(commits) != 1 || commits[0].Message != "add two" {
+		t.Errorf("unexpected commits: %+v", commits)
+	}
+
+	if err := r.Commit(ctx, parser.CommitOptions{Message: "again"}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("expected ErrNothingToCommit, got %v", err)
+	}
+}
+
+func TestRepo_UnstageNewFile(t *testing.T) {
+	ctx := context.Background()
+	r := New(nil)
+	r.WriteFile("new.txt", "hello\n")
+
+	r.StageFile(ctx, "new.txt")
+	r.UnstageFile(ctx, "new.txt")
+
+	status, _ := r.Status(ctx)
+	st, ok := status.Get("new.txt")
+	if !ok || !st.IsUntracked() {
+		t.Errorf("expected new.txt to be untracked again, got %q", st.XY())
+	}
+}
+
+func TestRepo_ReadFile(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "head\n"})
+	r.WriteFile("a.txt", "worktree\n")
+
+	for rev, want := range map[string]string{"HEAD": "head\n", ":": "head\n", "": "worktree\n"} {
+		got, err := r.ReadFile(ctx, rev, "a.txt")
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", rev, err)
+		}
+		if string(got) != want {
+			t.Errorf("ReadFile(%q): expected %q, got %q", rev, want, got)
+		}
+	}
+
+	if _, err := r.ReadFile(ctx, "HEAD", "missing.txt"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestRepo_AmendAndSignOff(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "one\n"})
+	r.WriteFile("a.txt", "two\n")
+	r.StageFile(ctx, "a.txt")
+
+	if err := r.Commit(ctx, parser.CommitOptions{Message: "first"}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// Amending needs no staged changes and replaces the last commit
+	err := r.Commit(ctx, parser.CommitOptions{Message: "reworded\n\n# comment\nbody", Amend: true, SignOff: true})
+	if err != nil {
+		t.Fatalf("amend failed: %v", err)
+	}
+
+	commits := r.Commits()
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit after amend, got %d", len(commits))
+	}
+	want := "reworded\n\n# comment\nbody\n\nSigned-off-by: " + Committer
+	if commits[0].Message != want {
+		t.Errorf("message = %q, want %q", commits[0].Message, want)
+	}
+
+	msg, err := r.HeadMessage(ctx)
+	if err != nil || msg != want {
+		t.Errorf("HeadMessage = %q, %v", msg, err)
+	}
+}
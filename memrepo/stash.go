@@ -0,0 +1,299 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+// ErrNoLocalChanges is returned by StashPush when there is nothing to stash
+var ErrNoLocalChanges = errors.New("no local changes to save")
+
+var _ parser.Stasher = (*Repo)(nil)
+
+// stash is a saved set of changes: the stashed paths as they were in the
+// base they were made against, and as they were stashed
+type stash struct {
+	message string
+	date    time.Time
+	base    map[string]string
+	files   map[string]string
+}
+
+// StashList returns the stash entries, newest first
+func (r *Repo) StashList(ctx context.Context) ([]parser.StashEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]parser.StashEntry, len(r.stashes))
+	for i, s := range r.stashes {
+		entries[i] = parser.StashEntry{
+			Ref:     stashRef(i),
+			Commit:  fmt.Sprintf("%040x", len(r.stashes)-i),
+			Message: s.message,
+			Date:    s.date,
+		}
+	}
+	return entries, nil
+}
+
+// StashDiff returns the changes saved in a stash entry
+func (r *Repo) StashDiff(ctx context.Context, ref string) ([]diff.FileDiff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, _, err := r.findStash(ref)
+	if err != nil {
+		return nil, err
+	}
+	return diffMaps(s.base, s.files, true), nil
+}
+
+// StashPush saves the worktree version of changed paths against HEAD, or the
+// index for WorktreeOnly, and resets them; the staged state isn't kept apart
+func (r *Repo) StashPush(ctx context.Context, opts parser.StashPushOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if opts.Hunks != nil {
+		return r.stashHunks(opts)
+	}
+
+	base := r.head
+	if opts.WorktreeOnly {
+		base = r.index
+	}
+
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = unionPaths(r.head, r.index, r.worktree)
+	}
+
+	s := stash{message: opts.Message, date: time.Now(), base: map[string]string{}, files: map[string]string{}}
+	if s.message == "" {
+		s.message = "WIP on main"
+	}
+	for _, path := range paths {
+		_, tracked := r.index[path]
+		if !tracked && !opts.IncludeUntracked {
+			if _, inHead := r.head[path]; !inHead {
+				continue
+			}
+		}
+		baseContent, inBase := base[path]
+		wtContent, inWorktree := r.worktree[path]
+		if inBase == inWorktree && baseContent == wtContent {
+			continue
+		}
+		if inBase {
+			s.base[path] = baseContent
+		}
+		if inWorktree {
+			s.files[path] = wtContent
+		}
+	}
+	if len(s.base) == 0 && len(s.files) == 0 {
+		return ErrNoLocalChanges
+	}
+
+	for _, path := range unionPaths(s.base, s.files) {
+		if content, ok := base[path]; ok {
+			r.worktree[path] = content
+		} else {
+			delete(r.worktree, path)
+		}
+		if opts.WorktreeOnly {
+			continue
+		}
+		if content, ok := r.head[path]; ok {
+			r.index[path] = content
+		} else {
+			delete(r.index, path)
+		}
+	}
+	r.stashes = append([]stash{s}, r.stashes...)
+	return nil
+}
+
+// stashHunks stashes the hunks of one file's unstaged changes that touch the
+// selected lines
+func (r *Repo) stashHunks(opts parser.StashPushOptions) error {
+	if !opts.WorktreeOnly || len(opts.Paths) != 1 {
+		return errors.New("hunks can only be stashed from the unstaged changes of one file")
+	}
+	path := opts.Paths[0]
+	base, inIndex := r.index[path]
+	current, inWorktree := r.worktree[path]
+	if !inIndex || !inWorktree {
+		return fmt.Errorf("%s has no unstaged hunks", path)
+	}
+	stashed, kept := splitHunks(base, current, *opts.Hunks)
+	if stashed == base {
+		return fmt.Errorf("no unstaged changes in the selected lines of %s", path)
+	}
+
+	s := stash{message: opts.Message, date: time.Now(), base: map[string]string{path: base}, files: map[string]string{path: stashed}}
+	if s.message == "" {
+		s.message = "WIP on main"
+	}
+	r.worktree[path] = kept
+	r.stashes = append([]stash{s}, r.stashes...)
+	return nil
+}
+
+// splitHunks splits the change from base to current between the hunks that
+// touch the selected lines and the rest: stashed is base with the selected
+// hunks applied, kept is base with the others
+func splitHunks(base, current string, sel parser.HunkSelection) (stashed, kept string) {
+	oldLines, newLines := splitAfterLines(base), splitAfterLines(current)
+	edits := diff.LineEdits(oldLines, newLines)
+	selected := selectedEdits(edits, sel)
+
+	var s, k strings.Builder
+	for i, e := range edits {
+		switch {
+		case e.Op == diff.EditEqual:
+			s.WriteString(oldLines[e.OldIndex])
+			k.WriteString(oldLines[e.OldIndex])
+		case e.Op == diff.EditDelete && selected[i]:
+			k.WriteString(oldLines[e.OldIndex])
+		case e.Op == diff.EditDelete:
+			s.WriteString(oldLines[e.OldIndex])
+		case selected[i]:
+			s.WriteString(newLines[e.NewIndex])
+		default:
+			k.WriteString(newLines[e.NewIndex])
+		}
+	}
+	return s.String(), k.String()
+}
+
+// selectedEdits marks the changes of the hunks, grouped the way the diff view
+// shows them, whose lines overlap the selection
+func selectedEdits(edits []diff.Edit, sel parser.HunkSelection) map[int]bool {
+	var changed []int
+	for i, e := range edits {
+		if e.Op != diff.EditEqual {
+			changed = append(changed, i)
+		}
+	}
+
+	contextLines := parser.DefaultContextLines
+	selected := make(map[int]bool)
+	for i := 0; i < len(changed); {
+		first, last := i, i
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*contextLines {
+			last++
+		}
+		i = last + 1
+
+		touches := false
+		for _, e := range edits[max(changed[first]-contextLines, 0) : min(changed[last]+contextLines, len(edits)-1)+1] {
+			line := e.OldIndex + 1
+			if sel.New {
+				line = e.NewIndex + 1
+			}
+			touches = touches || (line > 0 && line >= sel.Start && line <= sel.End)
+		}
+		if touches {
+			for _, c := range changed[first : last+1] {
+				selected[c] = true
+			}
+		}
+	}
+	return selected
+}
+
+// splitAfterLines splits content into lines that keep their newlines
+func splitAfterLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// StashApply writes a stash entry's files to the worktree; a path changed
+// locally since the stash was made is a conflict and nothing is applied
+func (r *Repo) StashApply(ctx context.Context, ref string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, _, err := r.findStash(ref)
+	if err != nil {
+		return err
+	}
+	return r.applyStash(s)
+}
+
+// StashPop applies a stash entry and drops it if that succeeded
+func (r *Repo) StashPop(ctx context.Context, ref string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, i, err := r.findStash(ref)
+	if err != nil {
+		return err
+	}
+	if err := r.applyStash(s); err != nil {
+		return err
+	}
+	r.stashes = append(r.stashes[:i:i], r.stashes[i+1:]...)
+	return nil
+}
+
+// StashDrop deletes a stash entry
+func (r *Repo) StashDrop(ctx context.Context, ref string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, i, err := r.findStash(ref)
+	if err != nil {
+		return err
+	}
+	r.stashes = append(r.stashes[:i:i], r.stashes[i+1:]...)
+	return nil
+}
+
+func (r *Repo) applyStash(s stash) error {
+	paths := unionPaths(s.base, s.files)
+	for _, path := range paths {
+		baseContent, inBase := s.base[path]
+		wtContent, inWorktree := r.worktree[path]
+		if inBase != inWorktree || baseContent != wtContent {
+			return fmt.Errorf("your local changes to %s would be overwritten", path)
+		}
+	}
+
+	for _, path := range paths {
+		content, ok := s.files[path]
+		if !ok {
+			delete(r.worktree, path)
+			continue
+		}
+		r.worktree[path] = content
+		if _, tracked := r.index[path]; !tracked {
+			// Like git, files the stash adds come back staged
+			r.index[path] = content
+		}
+	}
+	return nil
+}
+
+// findStash looks up a stash@{n} ref
+func (r *Repo) findStash(ref string) (stash, int, error) {
+	var i int
+	if _, err := fmt.Sscanf(ref, "stash@{%d}", &i); err != nil || i < 0 || i >= len(r.stashes) {
+		return stash{}, 0, fmt.Errorf("%s is not a valid reference", ref)
+	}
+	return r.stashes[i], i, nil
+}
+
+func stashRef(i int) string {
+	return fmt.Sprintf("stash@{%d}", i)
+}
@@ -0,0 +1,114 @@
+package memrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"diff-tui/parser"
+)
+
+func TestRepo_StashPushAndPop(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	r.WriteFile("a.txt", "a changed\n")
+	r.WriteFile("b.txt", "b changed\n")
+
+	if err := r.StashPush(ctx, parser.StashPushOptions{Message: "only a", Paths: []string{"a.txt"}}); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	if content, _ := r.ReadFile(ctx, parser.WorktreeRev, "a.txt"); string(content) != "a\n" {
+		t.Errorf("a.txt not reverted: %q", content)
+	}
+
+	entries, err := r.StashList(ctx)
+	if err != nil || len(entries) != 1 || entries[0].Ref != "stash@{0}" || entries[0].Message != "only a" {
+		t.Fatalf("StashList = %+v, %v", entries, err)
+	}
+	files, err := r.StashDiff(ctx, "stash@{0}")
+	if err != nil || len(files) != 1 || files[0].Name != "a.txt" {
+		t.Fatalf("StashDiff = %+v, %v", files, err)
+	}
+
+	if err := r.StashPop(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashPop failed: %v", err)
+	}
+	if content, _ := r.ReadFile(ctx, parser.WorktreeRev, "a.txt"); string(content) != "a changed\n" {
+		t.Errorf("a.txt not restored: %q", content)
+	}
+	if entries, _ := r.StashList(ctx); len(entries) != 0 {
+		t.Errorf("expected pop to drop the entry, got %+v", entries)
+	}
+}
+
+func TestRepo_StashWorktreeOnlyKeepsIndex(t *testing.T) {
+	ctx := context.Background()
+	r := New(map[string]string{"a.txt": "a\n"})
+	r.WriteFile("a.txt", "a staged\n")
+	if err := r.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	r.WriteFile("a.txt", "a staged\nand unstaged\n")
+
+	if err := r.StashPush(ctx, parser.StashPushOptions{Paths: []string{"a.txt"}, WorktreeOnly: true}); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	if content, _ := r.ReadFile(ctx, parser.WorktreeRev, "a.txt"); string(content) != "a staged\n" {
+		t.Errorf("worktree should be back at the index version: %q", content)
+	}
+	if content, _ := r.ReadFile(ctx, parser.IndexRev, "a.txt"); string(content) != "a staged\n" {
+		t.Errorf("index should be kept: %q", content)
+	}
+
+	// Applying over a conflicting local change fails without touching anything
+	r.WriteFile("a.txt", "something else\n")
+	if err := r.StashApply(ctx, "stash@{0}"); err == nil {
+		t.Error("expected a conflict")
+	}
+
+	if err := r.StashDrop(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashDrop failed: %v", err)
+	}
+	if err := r.StashDrop(ctx, "stash@{0}"); err == nil {
+		t.Error("expected dropping a missing entry to fail")
+	}
+}
+
+func TestRepo_StashNothing(t *testing.T) {
+	r := New(map[string]string{"a.txt": "a\n"})
+	if err := r.StashPush(context.Background(), parser.StashPushOptions{}); !errors.Is(err, ErrNoLocalChanges) {
+		t.Errorf("expected ErrNoLocalChanges, got %v", err)
+	}
+}
+
+func TestRepo_StashHunks(t *testing.T) {
+	ctx := context.Background()
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	original := strings.Join(lines, "")
+	r := New(map[string]string{"h.txt": original})
+	lines[1], lines[17] = "line two\n", "line eighteen\n"
+	r.WriteFile("h.txt", strings.Join(lines, ""))
+
+	opts := parser.StashPushOptions{Paths: []string{"h.txt"}, WorktreeOnly: true, Hunks: &parser.HunkSelection{New: true, Start: 2, End: 2}}
+	if err := r.StashPush(ctx, opts); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	content, _ := r.ReadFile(ctx, parser.WorktreeRev, "h.txt")
+	if want := strings.Replace(original, "line 18\n", "line eighteen\n", 1); string(content) != want {
+		t.Errorf("only the first hunk should be reverted:\n%s", content)
+	}
+	files, err := r.StashDiff(ctx, "stash@{0}")
+	if err != nil || len(files) != 1 || files[0].AddCount != 1 || files[0].DelCount != 1 {
+		t.Fatalf("StashDiff = %+v, %v", files, err)
+	}
+
+	opts.Hunks = &parser.HunkSelection{Start: 9, End: 11}
+	if err := r.StashPush(ctx, opts); err == nil {
+		t.Error("expected an error for lines without changes")
+	}
+}
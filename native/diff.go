@@ -0,0 +1,488 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"diff-tui/diff"
+	"diff-tui/gitignore"
+	"diff-tui/parser"
+)
+
+// fileVersion is one side of a path in a diff
+type fileVersion struct {
+	mode    uint32
+	hash    Hash
+	content []byte // Set when the version was read from the worktree
+}
+
+// snapshot is a full set of file versions: a tree, the index or the worktree
+type snapshot map[string]fileVersion
+
+// Diff computes git diff natively for no args, --cached [<rev>], <rev>,
+// <rev> <rev>, <rev>..<rev> and <rev>...<rev>, with optional -- <paths>;
+// anything else, or a repository with submodules, falls back to git
+func (r *Repo) Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error) {
+	if r.hasSubmodules() {
+		return r.git.Diff(ctx, args...)
+	}
+	cached := false
+	var revs, paths []string
+
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			paths = args[i+1:]
+		case arg == "--cached" || arg == "--staged":
+			cached = true
+			continue
+		case strings.HasPrefix(arg, "-"):
+			return r.git.Diff(ctx, args...)
+		default:
+			revs = append(revs, arg)
+			continue
+		}
+		break
+	}
+
+	// Expand ranges into two revisions
+	if len(revs) == 1 {
+		if a, b, ok := strings.Cut(revs[0], "..."); ok {
+			base, err := r.mergeBase(orHead(a), orHead(b))
+			if err != nil {
+				return nil, err
+			}
+			revs = []string{base.String(), orHead(b)}
+		} else if a, b, ok := strings.Cut(revs[0], ".."); ok {
+			revs = []string{orHead(a), orHead(b)}
+		}
+	}
+
+	var oldSide, newSide snapshot
+	var err error
+
+	switch {
+	case cached && len(revs) <= 1:
+		rev := "HEAD"
+		if len(revs) == 1 {
+			rev = revs[0]
+		}
+		if oldSide, err = r.revisionSnapshot(rev); err != nil {
+			return nil, err
+		}
+		newSide, err = r.indexSnapshot()
+
+	case !cached && len(revs) == 0:
+		if oldSide, err = r.indexSnapshot(); err != nil {
+			return nil, err
+		}
+		newSide, err = r.worktreeSnapshot(oldSide)
+
+	case !cached && len(revs) == 1:
+		if oldSide, err = r.revisionSnapshot(revs[0]); err != nil {
+			return nil, err
+		}
+		var index snapshot
+		if index, err = r.indexSnapshot(); err != nil {
+			return nil, err
+		}
+		newSide, err = r.worktreeSnapshot(mergeSnapshots(oldSide, index))
+
+	case !cached && len(revs) == 2:
+		if oldSide, err = r.revisionSnapshot(revs[0]); err != nil {
+			return nil, err
+		}
+		newSide, err = r.revisionSnapshot(revs[1])
+
+	default:
+		return r.git.Diff(ctx, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.diffSnapshots(filterPaths(oldSide, paths), filterPaths(newSide, paths))
+}
+
+// WorkingTree returns the staged and unstaged diffs plus untracked files
+func (r *Repo) WorkingTree(ctx context.Context) (*parser.WorkingTree, error) {
+	if r.hasSubmodules() {
+		return r.git.WorkingTree(ctx)
+	}
+	head, err := r.revisionSnapshot("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	index, err := r.indexSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := r.worktreeSnapshot(index)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := r.diffSnapshots(head, index)
+	if err != nil {
+		return nil, err
+	}
+	unstaged, err := r.diffSnapshots(index, worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := r.untrackedFiles(index)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		content, err := readWorktreeFile(r.root, path)
+		if err != nil {
+			continue
+		}
+		unstaged = append(unstaged, parser.NewUntrackedFileDiff(path, content))
+	}
+
+	return &parser.WorkingTree{
+		Staged:   &diff.Result{Files: staged},
+		Unstaged: &diff.Result{Files: unstaged},
+	}, nil
+}
+
+// Status reports the same per-path states as git status --porcelain=v2
+func (r *Repo) Status(ctx context.Context) (*parser.Status, error) {
+	head, err := r.revisionSnapshot("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := readIndex(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	index := indexEntriesSnapshot(idx)
+	worktree, err := r.worktreeSnapshot(index)
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+
+	// Branch headers
+	if h, err := r.resolveRef("HEAD"); err == nil {
+		fmt.Fprintf(&out, "# branch.oid %s\x00", h)
+	} else {
+		out.WriteString("# branch.oid (initial)\x00")
+	}
+	if branch := r.headBranch(); branch != "" {
+		fmt.Fprintf(&out, "# branch.head %s\x00", branch)
+	} else {
+		out.WriteString("# branch.head (detached)\x00")
+	}
+
+	// Unmerged entries, grouped by path
+	unmerged := make(map[string]*[3]IndexEntry)
+	for _, e := range idx.Entries {
+		if e.Stage > 0 {
+			if unmerged[e.Path] == nil {
+				unmerged[e.Path] = &[3]IndexEntry{}
+			}
+			unmerged[e.Path][e.Stage-1] = e
+		}
+	}
+
+	for _, path := range unionPaths(head, index, snapshotOf(unmerged)) {
+		if stages, ok := unmerged[path]; ok {
+			fmt.Fprintf(&out, "u %s N... %06o %06o %06o %06o %s %s %s %s\x00",
+				unmergedXY(stages), stages[0].Mode, stages[1].Mode, stages[2].Mode,
+				worktree[path].mode, stages[0].Hash, stages[1].Hash, stages[2].Hash, path)
+			continue
+		}
+
+		h, inHead := head[path]
+		i, inIndex := index[path]
+		w, inWorktree := worktree[path]
+
+		x := changeCode(h, inHead, i, inIndex)
+		y := changeCode(i, inIndex, w, inWorktree)
+		if x == parser.StatusUnmodified && y == parser.StatusUnmodified {
+			continue
+		}
+		fmt.Fprintf(&out, "1 %c%c N... %06o %06o %06o %s %s %s\x00",
+			x, y, h.mode, i.mode, w.mode, h.hash, i.hash, path)
+	}
+
+	untracked, err := r.untrackedFiles(mergeSnapshots(index, snapshotOf(unmerged)))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		fmt.Fprintf(&out, "? %s\x00", path)
+	}
+
+	return parser.ParseStatus(out.String())
+}
+
+// revisionSnapshot flattens the tree of rev; an unborn HEAD is empty
+func (r *Repo) revisionSnapshot(rev string) (snapshot, error) {
+	h, err := r.ResolveRevision(rev)
+	if err != nil {
+		if rev == "HEAD" {
+			return snapshot{}, nil
+		}
+		return nil, err
+	}
+
+	files, err := r.commitFiles(h)
+	if err != nil {
+		return nil, err
+	}
+
+	s := make(snapshot, len(files))
+	for path, e := range files {
+		if e.Mode == modeGitlink {
+			continue
+		}
+		s[path] = fileVersion{mode: e.Mode, hash: e.Hash}
+	}
+	return s, nil
+}
+
+// indexSnapshot returns the stage-0 entries of the index
+func (r *Repo) indexSnapshot() (snapshot, error) {
+	idx, err := readIndex(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	return indexEntriesSnapshot(idx), nil
+}
+
+func indexEntriesSnapshot(idx *Index) snapshot {
+	s := make(snapshot, len(idx.Entries))
+	for _, e := range idx.Entries {
+		if e.Stage == 0 && e.Mode != modeGitlink {
+			s[e.Path] = fileVersion{mode: e.Mode, hash: e.Hash}
+		}
+	}
+	return s
+}
+
+// worktreeSnapshot reads the worktree versions of the tracked paths, taking
+// files whose size and mtime match the index as unchanged, like git
+func (r *Repo) worktreeSnapshot(tracked snapshot) (snapshot, error) {
+	idx, err := readIndex(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return nil, err
+	}
+	stat := make(map[string]IndexEntry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		if e.Stage == 0 {
+			stat[e.Path] = e
+		}
+	}
+
+	s := make(snapshot, len(tracked))
+	for path := range tracked {
+		full := filepath.Join(r.root, filepath.FromSlash(path))
+		info, err := os.Lstat(full)
+		if err != nil {
+			continue // Deleted in the worktree
+		}
+		if info.IsDir() {
+			continue // Replaced by a directory (or an unregistered submodule)
+		}
+
+		mode := uint32(0o100644)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			mode = 0o120000
+		case info.Mode()&0o111 != 0:
+			mode = 0o100755
+		}
+
+		if e, ok := stat[path]; ok && e.Size == uint32(info.Size()) &&
+			e.MtimeSec == uint32(info.ModTime().Unix()) && e.MtimeNsec == uint32(info.ModTime().Nanosecond()) {
+			s[path] = fileVersion{mode: mode, hash: e.Hash}
+			continue
+		}
+
+		content, err := readWorktreeFile(r.root, path)
+		if err != nil {
+			continue
+		}
+		s[path] = fileVersion{mode: mode, hash: HashObject(ObjectBlob, content), content: content}
+	}
+	return s, nil
+}
+
+// untrackedFiles walks the worktree for files that are neither tracked nor ignored
+func (r *Repo) untrackedFiles(tracked snapshot) ([]string, error) {
+	var untracked []string
+	err := gitignore.New(r.root, r.commonDir).Walk(func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			// Nested repositories are not ours to list
+			if _, err := os.Stat(filepath.Join(r.root, filepath.FromSlash(rel), ".git")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := tracked[rel]; !ok {
+			untracked = append(untracked, rel)
+		}
+		return nil
+	})
+	sort.Strings(untracked)
+	return untracked, err
+}
+
+// hasSubmodules reports whether the worktree has a .gitmodules file, whose
+// gitlinks the snapshots skip
+func (r *Repo) hasSubmodules() bool {
+	_, err := os.Stat(filepath.Join(r.root, ".gitmodules"))
+	return err == nil
+}
+
+// diffSnapshots diffs every path whose content differs between the two sides
+func (r *Repo) diffSnapshots(oldSide, newSide snapshot) ([]diff.FileDiff, error) {
+	var files []diff.FileDiff
+	for _, path := range unionPaths(oldSide, newSide) {
+		o, inOld := oldSide[path]
+		n, inNew := newSide[path]
+		if inOld && inNew && o.hash == n.hash {
+			continue
+		}
+
+		var oldContent, newContent []byte
+		var err error
+		if inOld {
+			if oldContent, err = r.versionContent(o); err != nil {
+				return nil, err
+			}
+			oldContent = nonNil(oldContent)
+		}
+		if inNew {
+			if newContent, err = r.versionContent(n); err != nil {
+				return nil, err
+			}
+			newContent = nonNil(newContent)
+		}
+
+		files = append(files, parser.DiffContents(path, oldContent, newContent, parser.DefaultContextLines))
+	}
+	return files, nil
+}
+
+func (r *Repo) versionContent(v fileVersion) ([]byte, error) {
+	if v.content != nil {
+		return v.content, nil
+	}
+	return r.readBlob(v.hash)
+}
+
+// changeCode is the porcelain status letter for one column
+func changeCode(o fileVersion, inOld bool, n fileVersion, inNew bool) parser.StatusCode {
+	switch {
+	case !inOld && inNew:
+		return parser.StatusAdded
+	case inOld && !inNew:
+		return parser.StatusDeleted
+	case inOld && o.mode&0o170000 != n.mode&0o170000:
+		return parser.StatusTypeChanged
+	case inOld && o.hash != n.hash:
+		return parser.StatusModified
+	}
+	return parser.StatusUnmodified
+}
+
+// unmergedXY derives the two-letter conflict code from the stages present
+func unmergedXY(stages *[3]IndexEntry) string {
+	base := !stages[0].Hash.IsZero()
+	ours := !stages[1].Hash.IsZero()
+	theirs := !stages[2].Hash.IsZero()
+
+	switch {
+	case base && !ours && !theirs:
+		return "DD"
+	case !base && ours && !theirs:
+		return "AU"
+	case base && ours && !theirs:
+		return "UD"
+	case !base && !ours && theirs:
+		return "UA"
+	case base && !ours && theirs:
+		return "DU"
+	case !base && ours && theirs:
+		return "AA"
+	}
+	return "UU"
+}
+
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+func nonNil(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
+
+func mergeSnapshots(a, b snapshot) snapshot {
+	s := make(snapshot, len(a)+len(b))
+	for path, v := range a {
+		s[path] = v
+	}
+	for path, v := range b {
+		s[path] = v
+	}
+	return s
+}
+
+func snapshotOf[V any](m map[string]V) snapshot {
+	s := make(snapshot, len(m))
+	for path := range m {
+		s[path] = fileVersion{}
+	}
+	return s
+}
+
+// filterPaths keeps the paths equal to or below one of the given pathspecs
+func filterPaths(s snapshot, paths []string) snapshot {
+	if len(paths) == 0 {
+		return s
+	}
+	filtered := make(snapshot)
+	for path, v := range s {
+		for _, p := range paths {
+			p = strings.TrimSuffix(p, "/")
+			if path == p || strings.HasPrefix(path, p+"/") {
+				filtered[path] = v
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func unionPaths[V any](maps ...map[string]V) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range maps {
+		for path := range m {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,148 @@
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+var errCorruptIndex = errors.New("corrupt index")
+
+// IndexEntry is one entry of .git/index
+type IndexEntry struct {
+	Path  string
+	Mode  uint32
+	Hash  Hash
+	Stage int // 0 for normal entries, 1-3 for conflict stages
+
+	// Stat data used to skip hashing unchanged worktree files
+	MtimeSec  uint32
+	MtimeNsec uint32
+	Size      uint32
+}
+
+// Index is the parsed staging area
+type Index struct {
+	Version int
+	Entries []IndexEntry
+}
+
+// Entry returns the stage-0 entry for path
+func (idx *Index) Entry(path string) (IndexEntry, bool) {
+	return idx.StageEntry(path, 0)
+}
+
+// StageEntry binary-searches the entries, sorted by path then stage, for
+// path at the given stage
+func (idx *Index) StageEntry(path string, stage int) (IndexEntry, bool) {
+	i := sort.Search(len(idx.Entries), func(i int) bool {
+		e := idx.Entries[i]
+		return e.Path > path || (e.Path == path && e.Stage >= stage)
+	})
+	if i < len(idx.Entries) && idx.Entries[i].Path == path && idx.Entries[i].Stage == stage {
+		return idx.Entries[i], true
+	}
+	return IndexEntry{}, false
+}
+
+// readIndex reads an index file; a missing index is an empty one
+func readIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Index{Version: 2}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(data)
+}
+
+// parseIndex parses index versions 2, 3 and 4, skipping extensions
+func parseIndex(data []byte) (*Index, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], []byte("DIRC")) {
+		return nil, errCorruptIndex
+	}
+
+	idx := &Index{Version: int(binary.BigEndian.Uint32(data[4:8]))}
+	if idx.Version < 2 || idx.Version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", idx.Version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	pos := 12
+	var prevPath []byte
+	for i := 0; i < count; i++ {
+		if len(data) < pos+62 {
+			return nil, errCorruptIndex
+		}
+		start := pos
+		e := IndexEntry{
+			MtimeSec:  binary.BigEndian.Uint32(data[pos+8:]),
+			MtimeNsec: binary.BigEndian.Uint32(data[pos+12:]),
+			Mode:      binary.BigEndian.Uint32(data[pos+24:]),
+			Size:      binary.BigEndian.Uint32(data[pos+36:]),
+		}
+		copy(e.Hash[:], data[pos+40:pos+60])
+		flags := binary.BigEndian.Uint16(data[pos+60:])
+		e.Stage = int(flags>>12) & 3
+		pos += 62
+
+		// Extended flags (skip-worktree, intent-to-add) only exist in v3+
+		if flags&0x4000 != 0 && idx.Version >= 3 {
+			pos += 2
+		}
+
+		if idx.Version == 4 {
+			// Prefix compression: drop n bytes of the previous path, then append
+			n, used := readIndexVarint(data[pos:])
+			if used == 0 || n > uint64(len(prevPath)) {
+				return nil, errCorruptIndex
+			}
+			pos += used
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errCorruptIndex
+			}
+			path := append(append([]byte(nil), prevPath[:len(prevPath)-int(n)]...), data[pos:pos+end]...)
+			pos += end + 1
+			prevPath = path
+			e.Path = string(path)
+		} else {
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errCorruptIndex
+			}
+			e.Path = string(data[pos : pos+end])
+			pos += end
+			// Entries are NUL-padded to a multiple of 8 bytes
+			entryLen := pos - start
+			pos = start + (entryLen+8)&^7
+		}
+
+		idx.Entries = append(idx.Entries, e)
+	}
+
+	return idx, nil
+}
+
+// readIndexVarint decodes the offset-style varint used by index v4
+func readIndexVarint(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	c := data[0]
+	value := uint64(c & 0x7f)
+	i := 1
+	for c&0x80 != 0 {
+		if i >= len(data) {
+			return 0, 0
+		}
+		c = data[i]
+		i++
+		value = ((value + 1) << 7) | uint64(c&0x7f)
+	}
+	return value, i
+}
@@ -0,0 +1,86 @@
+package native
+
+import (
+	"context"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+var _ parser.LogReader = (*Repo)(nil)
+
+// Log delegates to git log, whose history walk and --grep are not worth
+// reimplementing
+func (r *Repo) Log(ctx context.Context, opts parser.LogOptions) ([]parser.LogEntry, error) {
+	return r.git.Log(ctx, opts)
+}
+
+// CommitDiff delegates to git; root commits are diffed against the empty tree,
+// which is not stored in the object database
+func (r *Repo) CommitDiff(ctx context.Context, hash string) ([]diff.FileDiff, error) {
+	return r.git.CommitDiff(ctx, hash)
+}
+
+var _ parser.RefLister = (*Repo)(nil)
+
+// Refs delegates to git for-each-ref, which sorts by date and reads annotated
+// tags' messages
+func (r *Repo) Refs(ctx context.Context) ([]parser.Ref, error) {
+	return r.git.Refs(ctx)
+}
+
+var _ parser.Reviewer = (*Repo)(nil)
+
+// Review delegates to git merge-base and git log
+func (r *Repo) Review(ctx context.Context, base string) (*parser.Review, error) {
+	return r.git.Review(ctx, base)
+}
+
+var _ parser.RangeDiffer = (*Repo)(nil)
+
+// RangeDiff delegates to git range-diff, whose commit matching is not worth
+// reimplementing
+func (r *Repo) RangeDiff(ctx context.Context, args ...string) ([]parser.RangePair, error) {
+	return r.git.RangeDiff(ctx, args...)
+}
+
+// Interdiff delegates to git for the patches of both commits
+func (r *Repo) Interdiff(ctx context.Context, oldCommit, newCommit string) ([]diff.FileDiff, error) {
+	return r.git.Interdiff(ctx, oldCommit, newCommit)
+}
+
+var _ parser.Blamer = (*Repo)(nil)
+
+// Blame delegates to git blame, which follows history through renames
+func (r *Repo) Blame(ctx context.Context, rev, path string) ([]parser.BlameLine, error) {
+	return r.git.Blame(ctx, rev, path)
+}
+
+var _ parser.FileHistoryReader = (*Repo)(nil)
+
+// FileHistory delegates to git log --follow, which detects the renames
+func (r *Repo) FileHistory(ctx context.Context, path string) ([]parser.FileRevision, error) {
+	return r.git.FileHistory(ctx, path)
+}
+
+// FileRevisionDiff delegates to git so renames are detected the same way
+func (r *Repo) FileRevisionDiff(ctx context.Context, rev parser.FileRevision) ([]diff.FileDiff, error) {
+	return r.git.FileRevisionDiff(ctx, rev)
+}
+
+var _ parser.NotesKeeper = (*Repo)(nil)
+
+// Note delegates to git notes, which reads notes refs of either layout
+func (r *Repo) Note(ctx context.Context, ref, commit string) (string, error) {
+	return r.git.Note(ctx, ref, commit)
+}
+
+// SetNote delegates to git notes, which commits the change to the notes ref
+func (r *Repo) SetNote(ctx context.Context, ref, commit, text string) error {
+	return r.git.SetNote(ctx, ref, commit, text)
+}
+
+// UserName delegates to git config, which knows all the config files to look in
+func (r *Repo) UserName(ctx context.Context) (string, error) {
+	return r.git.UserName(ctx)
+}
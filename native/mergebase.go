@@ -0,0 +1,157 @@
+package native
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// Paint flags of the merge base walk, as git names them
+const (
+	paintParent1 = 1 << iota // Reachable from a
+	paintParent2             // Reachable from b
+	paintStale               // Below a common ancestor already found
+	paintResult              // A common ancestor found
+)
+
+// paintCommit is a commit seen by the merge base walk
+type paintCommit struct {
+	hash    Hash
+	time    int64
+	parents []Hash
+	flags   int
+}
+
+// paintEntry is a commit queued for painting; a commit is queued again
+// whenever it gets new flags
+type paintEntry struct {
+	commit *paintCommit
+	order  int // Insertion order, which breaks ties between dates
+}
+
+// paintQueue orders commits newest first, like git's commit date queue
+type paintQueue []paintEntry
+
+func (q paintQueue) Len() int { return len(q) }
+func (q paintQueue) Less(i, j int) bool {
+	if q[i].commit.time != q[j].commit.time {
+		return q[i].commit.time > q[j].commit.time
+	}
+	return q[i].order < q[j].order
+}
+func (q paintQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *paintQueue) Push(x any)   { *q = append(*q, x.(paintEntry)) }
+func (q *paintQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// mergeWalk loads the commits of one merge base computation once each
+type mergeWalk struct {
+	repo    *Repo
+	commits map[Hash]*paintCommit
+	pushed  int
+}
+
+func (w *mergeWalk) commit(h Hash) (*paintCommit, error) {
+	if c, ok := w.commits[h]; ok {
+		return c, nil
+	}
+	h, parsed, err := w.repo.peelCommit(h)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := w.commits[h]; ok {
+		return c, nil
+	}
+	c := &paintCommit{hash: h, time: parsed.Time, parents: parsed.Parents}
+	w.commits[h] = c
+	return c, nil
+}
+
+func (w *mergeWalk) push(q *paintQueue, c *paintCommit) {
+	w.pushed++
+	heap.Push(q, paintEntry{commit: c, order: w.pushed})
+}
+
+// mergeBase paints both histories newest first like git merge-base; of
+// several best common ancestors it picks the newest, like git diff a...b
+func (r *Repo) mergeBase(a, b string) (Hash, error) {
+	ha, err := r.ResolveRevision(a)
+	if err != nil {
+		return ZeroHash, err
+	}
+	hb, err := r.ResolveRevision(b)
+	if err != nil {
+		return ZeroHash, err
+	}
+
+	w := &mergeWalk{repo: r, commits: make(map[Hash]*paintCommit)}
+	one, err := w.commit(ha)
+	if err != nil {
+		return ZeroHash, err
+	}
+	two, err := w.commit(hb)
+	if err != nil {
+		return ZeroHash, err
+	}
+	if one == two {
+		return one.hash, nil
+	}
+
+	var queue paintQueue
+	one.flags |= paintParent1
+	two.flags |= paintParent2
+	w.push(&queue, one)
+	w.push(&queue, two)
+
+	var found []*paintCommit
+	for queue.hasNonStale() {
+		c := heap.Pop(&queue).(paintEntry).commit
+		flags := c.flags & (paintParent1 | paintParent2 | paintStale)
+		if flags == paintParent1|paintParent2 {
+			if c.flags&paintResult == 0 {
+				c.flags |= paintResult
+				found = append(found, c)
+			}
+			flags |= paintStale
+		}
+		for _, ph := range c.parents {
+			p, err := w.commit(ph)
+			if err != nil {
+				return ZeroHash, err
+			}
+			if p.flags&flags == flags {
+				continue
+			}
+			p.flags |= flags
+			w.push(&queue, p)
+		}
+	}
+
+	// A result painted stale later is an ancestor of another one
+	var best *paintCommit
+	for _, c := range found {
+		if c.flags&paintStale != 0 {
+			continue
+		}
+		if best == nil || c.time > best.time {
+			best = c
+		}
+	}
+	if best == nil {
+		return ZeroHash, fmt.Errorf("no merge base between %s and %s", a, b)
+	}
+	return best.hash, nil
+}
+
+// hasNonStale reports whether the walk still has commits to paint
+func (q paintQueue) hasNonStale() bool {
+	for _, e := range q {
+		if e.commit.flags&paintStale == 0 {
+			return true
+		}
+	}
+	return false
+}
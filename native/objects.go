@@ -0,0 +1,274 @@
+package native
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ObjectType is the type of a git object
+type ObjectType int
+
+const (
+	ObjectCommit ObjectType = 1
+	ObjectTree   ObjectType = 2
+	ObjectBlob   ObjectType = 3
+	ObjectTag    ObjectType = 4
+)
+
+// objectTypeNames maps loose object header names to types
+var objectTypeNames = map[string]ObjectType{
+	"commit": ObjectCommit,
+	"tree":   ObjectTree,
+	"blob":   ObjectBlob,
+	"tag":    ObjectTag,
+}
+
+func (t ObjectType) String() string {
+	for name, typ := range objectTypeNames {
+		if typ == t {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Hash is a SHA-1 object name
+type Hash [20]byte
+
+// ZeroHash is the all-zero object name git uses for "no object"
+var ZeroHash Hash
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero reports whether h is the all-zero name
+func (h Hash) IsZero() bool {
+	return h == ZeroHash
+}
+
+// ParseHash parses a full 40-character hex object name
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 40 {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	return h, nil
+}
+
+// HashObject computes the object name of data stored as typ
+func HashObject(typ ObjectType, data []byte) Hash {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", typ, len(data))
+	h.Write(data)
+
+	var sum Hash
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// ErrObjectNotFound indicates the object is in neither loose storage nor a pack
+var ErrObjectNotFound = errors.New("object not found")
+
+// objectStore reads loose and packed objects from an objects directory
+type objectStore struct {
+	dir string
+
+	mu      sync.Mutex
+	scanned bool
+	packs   []*packFile
+}
+
+func newObjectStore(dir string) *objectStore {
+	return &objectStore{dir: dir}
+}
+
+// loadPacks returns the open packs, opening every pack index on first use
+func (s *objectStore) loadPacks() ([]*packFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.scanned {
+		if _, err := s.scanPacks(); err != nil {
+			return nil, err
+		}
+	}
+	return s.packs, nil
+}
+
+// rescanPacks picks up packs written since the last scan, by gc, fetch or
+// repack, and reports whether there were any
+func (s *objectStore) rescanPacks() ([]*packFile, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	added, err := s.scanPacks()
+	return s.packs, added, err
+}
+
+// scanPacks opens the pack indexes not open yet and drops the packs whose
+// index is gone; their files are closed once no reader holds them
+func (s *objectStore) scanPacks() (bool, error) {
+	idxFiles, err := filepath.Glob(filepath.Join(s.dir, "pack", "*.idx"))
+	if err != nil {
+		return false, err
+	}
+
+	open := make(map[string]*packFile, len(s.packs))
+	for _, p := range s.packs {
+		open[p.path] = p
+	}
+	packs := make([]*packFile, 0, len(idxFiles))
+	added := false
+	for _, idx := range idxFiles {
+		base := strings.TrimSuffix(idx, ".idx")
+		if p, ok := open[base+".pack"]; ok {
+			packs = append(packs, p)
+			continue
+		}
+		p, err := openPack(base)
+		if err != nil {
+			return false, err
+		}
+		packs = append(packs, p)
+		added = true
+	}
+
+	s.packs = packs
+	s.scanned = true
+	return added, nil
+}
+
+// Read returns the type and content of an object
+func (s *objectStore) Read(h Hash) (ObjectType, []byte, error) {
+	typ, data, err := s.readLoose(h)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return typ, data, err
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, p := range packs {
+		if offset, ok := p.find(h); ok {
+			return p.readAt(offset, s)
+		}
+	}
+
+	// The object may have been packed, or fetched, since the packs were read
+	packs, added, err := s.rescanPacks()
+	if err != nil {
+		return 0, nil, err
+	}
+	if added {
+		for _, p := range packs {
+			if offset, ok := p.find(h); ok {
+				return p.readAt(offset, s)
+			}
+		}
+	}
+
+	return 0, nil, fmt.Errorf("%s: %w", h, ErrObjectNotFound)
+}
+
+// readLoose reads objects/xx/yyyy
+func (s *objectStore) readLoose(h Hash) (ObjectType, []byte, error) {
+	name := h.String()
+	f, err := os.Open(filepath.Join(s.dir, name[:2], name[2:]))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("loose object %s: %w", name, err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, fmt.Errorf("loose object %s: %w", name, err)
+	}
+
+	header, data, ok := bytes.Cut(raw, []byte{0})
+	if !ok {
+		return 0, nil, fmt.Errorf("loose object %s: missing header", name)
+	}
+	typeName, sizeStr, _ := strings.Cut(string(header), " ")
+	typ, ok := objectTypeNames[typeName]
+	if !ok {
+		return 0, nil, fmt.Errorf("loose object %s: unknown type %q", name, typeName)
+	}
+	if size, err := strconv.Atoi(sizeStr); err != nil || size != len(data) {
+		return 0, nil, fmt.Errorf("loose object %s: size mismatch", name)
+	}
+
+	return typ, data, nil
+}
+
+// FindPrefix returns every object whose name starts with the hex prefix
+func (s *objectStore) FindPrefix(prefix string) ([]Hash, error) {
+	var found []Hash
+	seen := make(map[Hash]bool)
+	add := func(h Hash) {
+		if !seen[h] {
+			seen[h] = true
+			found = append(found, h)
+		}
+	}
+
+	if len(prefix) >= 2 {
+		entries, err := os.ReadDir(filepath.Join(s.dir, prefix[:2]))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := prefix[:2] + e.Name()
+			if strings.HasPrefix(name, prefix) {
+				if h, err := ParseHash(name); err == nil {
+					add(h)
+				}
+			}
+		}
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		for _, h := range p.findPrefix(prefix) {
+			add(h)
+		}
+	}
+
+	if len(found) == 0 {
+		packs, added, err := s.rescanPacks()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range packs {
+			if !added {
+				break
+			}
+			for _, h := range p.findPrefix(prefix) {
+				add(h)
+			}
+		}
+	}
+
+	return found, nil
+}
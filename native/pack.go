@@ -0,0 +1,335 @@
+package native
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	packObjOfsDelta = 6
+	packObjRefDelta = 7
+
+	// deltaCacheSize bounds the number of resolved pack objects kept in memory
+	deltaCacheSize = 256
+)
+
+var errCorruptPack = errors.New("corrupt pack")
+
+// packFile is one objects/pack/pack-*.{idx,pack} pair
+type packFile struct {
+	path    string
+	pack    *os.File
+	names   []Hash   // Sorted object names from the index
+	offsets []uint64 // Pack offset of names[i]
+
+	mu    sync.Mutex
+	cache map[uint64]cachedObject
+}
+
+type cachedObject struct {
+	typ  ObjectType
+	data []byte
+}
+
+// openPack reads the .idx of base and opens the matching .pack
+func openPack(base string) (*packFile, error) {
+	idx, err := os.ReadFile(base + ".idx")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &packFile{path: base + ".pack", cache: make(map[uint64]cachedObject)}
+	if err := p.parseIndex(idx); err != nil {
+		return nil, fmt.Errorf("%s.idx: %w", base, err)
+	}
+
+	p.pack, err = os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseIndex understands both version 1 and version 2 pack indexes
+func (p *packFile) parseIndex(idx []byte) error {
+	if len(idx) >= 8 && bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) {
+		if binary.BigEndian.Uint32(idx[4:8]) != 2 {
+			return errCorruptPack
+		}
+		return p.parseIndexV2(idx[8:])
+	}
+	return p.parseIndexV1(idx)
+}
+
+func (p *packFile) parseIndexV1(idx []byte) error {
+	if len(idx) < 256*4 {
+		return errCorruptPack
+	}
+	count := int(binary.BigEndian.Uint32(idx[255*4:]))
+	entries := idx[256*4:]
+	if len(entries) < count*24 {
+		return errCorruptPack
+	}
+
+	p.names = make([]Hash, count)
+	p.offsets = make([]uint64, count)
+	for i := 0; i < count; i++ {
+		e := entries[i*24:]
+		p.offsets[i] = uint64(binary.BigEndian.Uint32(e[:4]))
+		copy(p.names[i][:], e[4:24])
+	}
+	return nil
+}
+
+func (p *packFile) parseIndexV2(idx []byte) error {
+	if len(idx) < 256*4 {
+		return errCorruptPack
+	}
+	count := int(binary.BigEndian.Uint32(idx[255*4:]))
+	rest := idx[256*4:]
+
+	namesEnd := count * 20
+	crcEnd := namesEnd + count*4
+	offsetsEnd := crcEnd + count*4
+	if len(rest) < offsetsEnd {
+		return errCorruptPack
+	}
+	large := rest[offsetsEnd:]
+
+	p.names = make([]Hash, count)
+	p.offsets = make([]uint64, count)
+	for i := 0; i < count; i++ {
+		copy(p.names[i][:], rest[i*20:(i+1)*20])
+
+		off := binary.BigEndian.Uint32(rest[crcEnd+i*4:])
+		if off&0x80000000 == 0 {
+			p.offsets[i] = uint64(off)
+			continue
+		}
+		// The MSB selects an entry in the 64-bit offset table
+		j := int(off & 0x7fffffff)
+		if len(large) < (j+1)*8 {
+			return errCorruptPack
+		}
+		p.offsets[i] = binary.BigEndian.Uint64(large[j*8:])
+	}
+	return nil
+}
+
+// find returns the pack offset of h
+func (p *packFile) find(h Hash) (uint64, bool) {
+	i := sort.Search(len(p.names), func(i int) bool {
+		return bytes.Compare(p.names[i][:], h[:]) >= 0
+	})
+	if i < len(p.names) && p.names[i] == h {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// findPrefix returns the names in this pack starting with the hex prefix
+func (p *packFile) findPrefix(prefix string) []Hash {
+	var found []Hash
+	i := sort.Search(len(p.names), func(i int) bool {
+		return hex.EncodeToString(p.names[i][:]) >= prefix
+	})
+	for ; i < len(p.names); i++ {
+		if !strings.HasPrefix(p.names[i].String(), prefix) {
+			break
+		}
+		found = append(found, p.names[i])
+	}
+	return found
+}
+
+// readAt reads the object at offset, resolving deltas; store finds REF_DELTA
+// bases that live elsewhere
+func (p *packFile) readAt(offset uint64, store *objectStore) (ObjectType, []byte, error) {
+	p.mu.Lock()
+	cached, ok := p.cache[offset]
+	p.mu.Unlock()
+	if ok {
+		return cached.typ, cached.data, nil
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(p.pack, int64(offset), 1<<62))
+
+	// Object header: 3-bit type and a variable-length size
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := int(c>>4) & 7
+	size := uint64(c & 0x0f)
+	shift := uint(4)
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+		size |= uint64(c&0x7f) << shift
+		shift += 7
+	}
+
+	var baseType ObjectType
+	var baseData []byte
+
+	switch typ {
+	case packObjOfsDelta:
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		rel := uint64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			rel = ((rel + 1) << 7) | uint64(c&0x7f)
+		}
+		if rel > offset {
+			return 0, nil, errCorruptPack
+		}
+		baseType, baseData, err = p.readAt(offset-rel, store)
+		if err != nil {
+			return 0, nil, err
+		}
+
+	case packObjRefDelta:
+		var base Hash
+		if _, err := io.ReadFull(r, base[:]); err != nil {
+			return 0, nil, err
+		}
+		baseType, baseData, err = store.Read(base)
+		if err != nil {
+			return 0, nil, err
+		}
+
+	case int(ObjectCommit), int(ObjectTree), int(ObjectBlob), int(ObjectTag):
+
+	default:
+		return 0, nil, fmt.Errorf("%w: object type %d at offset %d", errCorruptPack, typ, offset)
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", errCorruptPack, err)
+	}
+
+	objType := ObjectType(typ)
+	if baseData != nil {
+		objType = baseType
+		if data, err = applyDelta(baseData, data); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	p.mu.Lock()
+	if len(p.cache) >= deltaCacheSize {
+		clear(p.cache)
+	}
+	p.cache[offset] = cachedObject{typ: objType, data: data}
+	p.mu.Unlock()
+
+	return objType, data, nil
+}
+
+// applyDelta rebuilds an object from its base and a git delta
+func applyDelta(base, delta []byte) ([]byte, error) {
+	readSize := func() (uint64, error) {
+		var size uint64
+		var shift uint
+		for {
+			if len(delta) == 0 {
+				return 0, errCorruptPack
+			}
+			c := delta[0]
+			delta = delta[1:]
+			size |= uint64(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+
+	srcSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("%w: delta base size mismatch", errCorruptPack)
+	}
+	dstSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			// Copy from base: offset and size bytes are present per flag bit
+			var offset, size uint64
+			for i := uint(0); i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errCorruptPack
+					}
+					offset |= uint64(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := uint(0); i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errCorruptPack
+					}
+					size |= uint64(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, errCorruptPack
+			}
+			out = append(out, base[offset:offset+size]...)
+
+		case op != 0:
+			// Insert the next op bytes literally
+			if int(op) > len(delta) {
+				return nil, errCorruptPack
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+
+		default:
+			return nil, fmt.Errorf("%w: reserved delta opcode", errCorruptPack)
+		}
+	}
+
+	if uint64(len(out)) != dstSize {
+		return nil, fmt.Errorf("%w: delta result size mismatch", errCorruptPack)
+	}
+	return out, nil
+}
@@ -0,0 +1,173 @@
+package native
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownRevision indicates a revision that does not resolve to an object
+var ErrUnknownRevision = errors.New("unknown revision")
+
+var (
+	hexRE = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+	// revSuffixRE matches a trailing ~N, ^N, ~ or ^
+	revSuffixRE = regexp.MustCompile(`([~^])(\d*)$`)
+)
+
+// ResolveRevision resolves the revision syntax the backend supports: full and
+// abbreviated hashes, HEAD, branch/tag/remote names, and ~N / ^N suffixes
+func (r *Repo) ResolveRevision(rev string) (Hash, error) {
+	if m := revSuffixRE.FindStringSubmatchIndex(rev); m != nil && m[0] > 0 {
+		base, err := r.ResolveRevision(rev[:m[0]])
+		if err != nil {
+			return ZeroHash, err
+		}
+		op := rev[m[2]:m[3]]
+		n := 1
+		if m[4] != m[5] {
+			n, _ = strconv.Atoi(rev[m[4]:m[5]])
+		}
+		return r.walkParents(base, op, n)
+	}
+
+	if rev == "@" {
+		rev = "HEAD"
+	}
+
+	if h, err := r.resolveRef(rev); err == nil {
+		return h, nil
+	}
+	for _, candidate := range []string{"refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev,
+		"refs/remotes/" + rev, "refs/remotes/" + rev + "/HEAD"} {
+		if h, err := r.resolveRef(candidate); err == nil {
+			return h, nil
+		}
+	}
+
+	if hexRE.MatchString(rev) {
+		matches, err := r.objects.FindPrefix(rev)
+		if err != nil {
+			return ZeroHash, err
+		}
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		if len(matches) > 1 {
+			return ZeroHash, fmt.Errorf("short object name %s is ambiguous", rev)
+		}
+	}
+
+	return ZeroHash, fmt.Errorf("%w: %s", ErrUnknownRevision, rev)
+}
+
+// walkParents applies "~n" (n first-parent steps) or "^n" (the n-th parent)
+func (r *Repo) walkParents(h Hash, op string, n int) (Hash, error) {
+	if op == "^" {
+		if n == 0 {
+			return h, nil
+		}
+		c, err := r.readCommit(h)
+		if err != nil {
+			return ZeroHash, err
+		}
+		if n > len(c.Parents) {
+			return ZeroHash, fmt.Errorf("%w: %s has no parent %d", ErrUnknownRevision, h, n)
+		}
+		return c.Parents[n-1], nil
+	}
+
+	for i := 0; i < n; i++ {
+		c, err := r.readCommit(h)
+		if err != nil {
+			return ZeroHash, err
+		}
+		if len(c.Parents) == 0 {
+			return ZeroHash, fmt.Errorf("%w: %s has no parent", ErrUnknownRevision, h)
+		}
+		h = c.Parents[0]
+	}
+	return h, nil
+}
+
+// resolveRef follows a full ref name (or HEAD) through symbolic refs
+func (r *Repo) resolveRef(name string) (Hash, error) {
+	for depth := 0; depth < 5; depth++ {
+		target, err := r.readRef(name)
+		if err != nil {
+			return ZeroHash, err
+		}
+		next, symbolic := strings.CutPrefix(target, "ref: ")
+		if !symbolic {
+			return ParseHash(target)
+		}
+		name = next
+	}
+	return ZeroHash, fmt.Errorf("symbolic ref loop at %s", name)
+}
+
+// readRef returns the raw value of a loose or packed ref
+func (r *Repo) readRef(name string) (string, error) {
+	// HEAD and other pseudo-refs are per worktree, everything else is shared
+	dir := r.commonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.gitDir
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	return r.readPackedRef(name)
+}
+
+// readPackedRef looks name up in packed-refs
+func (r *Repo) readPackedRef(name string) (string, error) {
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRevision, name)
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, ref, ok := strings.Cut(line, " ")
+		if ok && ref == name {
+			return hash, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownRevision, name)
+}
+
+// headBranch returns the short branch name HEAD points at, or "" when detached
+func (r *Repo) headBranch() string {
+	target, err := r.readRef("HEAD")
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(target, "ref: ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
@@ -0,0 +1,272 @@
+// Package native is a parser.Repository that reads objects, refs and the index
+// straight from .git and diffs in-process; writes still go through git
+//
+// Unlike git diff it shows renames as a delete plus an add, applies no content
+// filters and does not support SHA-256 repositories
+package native
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"diff-tui/parser"
+)
+
+// ErrUnsupportedRepository indicates a repository layout the backend can't read
+var ErrUnsupportedRepository = errors.New("unsupported repository")
+
+// Repo reads a non-bare repository from disk
+type Repo struct {
+	root      string // Worktree root
+	gitDir    string // Per-worktree git directory (HEAD, index)
+	commonDir string // Shared git directory (objects, refs)
+	objects   *objectStore
+
+	git *parser.GitRunner // Used for write operations and unsupported diff args
+}
+
+var (
+	_ parser.Repository           = (*Repo)(nil)
+	_ parser.CommitTemplateReader = (*Repo)(nil)
+	_ parser.GitDirFinder         = (*Repo)(nil)
+	_ parser.CommandLogger        = (*Repo)(nil)
+)
+
+// Open finds the repository containing dir; gitPath is the git binary for
+// write operations, "" for the default
+func Open(dir, gitPath string) (*Repo, error) {
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		gitDir, err := findGitDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if gitDir != "" {
+			return openAt(dir, gitDir, gitPath)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, parser.ErrNotGitRepo
+		}
+		dir = parent
+	}
+}
+
+// findGitDir returns the git directory for a worktree root candidate, following
+// "gitdir:" files used by linked worktrees and submodules
+func findGitDir(dir string) (string, error) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("%w: malformed %s", ErrUnsupportedRepository, dotGit)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return filepath.Clean(target), nil
+}
+
+func openAt(root, gitDir, gitPath string) (*Repo, error) {
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	if format := readConfigValue(filepath.Join(commonDir, "config"), "extensions", "objectformat"); format != "" && format != "sha1" {
+		return nil, fmt.Errorf("%w: object format %s", ErrUnsupportedRepository, format)
+	}
+
+	return &Repo{
+		root:      root,
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		objects:   newObjectStore(filepath.Join(commonDir, "objects")),
+		git:       parser.NewGitRunner(gitPath, root),
+	}, nil
+}
+
+// CommandLog returns the record of the git commands run for write operations
+func (r *Repo) CommandLog() *parser.CommandLog {
+	return r.git.CommandLog()
+}
+
+// Root returns the worktree root directory
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// readConfigValue does a minimal lookup of section.key in a git config file
+func readConfigValue(path, section, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if ok && current == section && strings.EqualFold(strings.TrimSpace(name), key) {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// StageFile delegates to git add
+func (r *Repo) StageFile(ctx context.Context, path string) error {
+	return r.git.StageFile(ctx, path)
+}
+
+// UnstageFile delegates to git reset
+func (r *Repo) UnstageFile(ctx context.Context, path string) error {
+	return r.git.UnstageFile(ctx, path)
+}
+
+var _ parser.Resolver = (*Repo)(nil)
+
+// ConflictStages delegates to git show for the index stages
+func (r *Repo) ConflictStages(ctx context.Context, entry parser.FileStatus) (*parser.ConflictStages, error) {
+	return r.git.ConflictStages(ctx, entry)
+}
+
+// Resolve delegates to git, which updates the index
+func (r *Repo) Resolve(ctx context.Context, path string, content []byte) error {
+	return r.git.Resolve(ctx, path, content)
+}
+
+// Commit delegates to git commit so hooks and signing behave as usual
+func (r *Repo) Commit(ctx context.Context, opts parser.CommitOptions) error {
+	return r.git.Commit(ctx, opts)
+}
+
+// GitDir returns the worktree's git directory
+func (r *Repo) GitDir(ctx context.Context) (string, error) {
+	return r.gitDir, nil
+}
+
+// CommitTemplate delegates to git, which knows all the config files to look in
+func (r *Repo) CommitTemplate(ctx context.Context) (string, error) {
+	return r.git.CommitTemplate(ctx)
+}
+
+// HeadMessage reads the message of the HEAD commit
+func (r *Repo) HeadMessage(ctx context.Context) (string, error) {
+	h, err := r.ResolveRevision("HEAD")
+	if err != nil {
+		return "", err
+	}
+	c, err := r.readCommit(h)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(c.Message), nil
+}
+
+// ReadFile reads path from the worktree, an index stage or a revision
+func (r *Repo) ReadFile(ctx context.Context, rev, path string) ([]byte, error) {
+	switch {
+	case rev == parser.WorktreeRev:
+		return readWorktreeFile(r.root, path)
+
+	case strings.HasPrefix(rev, parser.IndexRev):
+		stage := 0
+		if rev != parser.IndexRev {
+			if _, err := fmt.Sscanf(rev, ":%d", &stage); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownRevision, rev)
+			}
+		}
+		idx, err := readIndex(filepath.Join(r.gitDir, "index"))
+		if err != nil {
+			return nil, err
+		}
+		e, ok := idx.StageEntry(path, stage)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+		}
+		return r.readBlob(e.Hash)
+	}
+
+	h, err := r.ResolveRevision(rev)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.commitFiles(h)
+	if err != nil {
+		return nil, err
+	}
+	e, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s:%s: %w", rev, path, os.ErrNotExist)
+	}
+	return r.readBlob(e.Hash)
+}
+
+// readBlob returns the content of a blob object
+func (r *Repo) readBlob(h Hash) ([]byte, error) {
+	typ, data, err := r.objects.Read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != ObjectBlob {
+		return nil, fmt.Errorf("%s is a %s, not a blob", h, typ)
+	}
+	return data, nil
+}
+
+// readWorktreeFile reads a file on disk; symlinks read as their target like git stores them
+func readWorktreeFile(root, path string) ([]byte, error) {
+	full := filepath.Join(root, filepath.FromSlash(path))
+	info, err := os.Lstat(full)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(full)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(target), nil
+	}
+	return os.ReadFile(full)
+}
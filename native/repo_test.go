@@ -0,0 +1,327 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+// newTestRepo creates a git repository with two commits, packs it so pack and
+// delta reading is exercised, then leaves staged, unstaged and untracked changes
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(path, content string) {
+		t.Helper()
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	long := ""
+	for i := 0; i < 200; i++ {
+		long += "line of a long file that packs as a delta\n"
+	}
+
+	git("init", "-q", "-b", "main")
+	write("README.md", "# test\n")
+	write("src/main.go", "package main\n\nfunc main() {}\n")
+	write("src/long.txt", long)
+	write("gone.txt", "bye\n")
+	write(".gitignore", "*.log\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	write("src/long.txt", long+"one more line\n")
+	git("commit", "-q", "-am", "second")
+	git("tag", "-a", "v1", "-m", "release")
+	git("gc", "-q", "--aggressive")
+
+	write("README.md", "# test\n\nstaged\n")
+	write("staged-new.txt", "new\n")
+	git("add", "README.md", "staged-new.txt")
+	write("src/main.go", "package main\n\nfunc main() { println() }\n")
+	write("untracked/file.txt", "hello\n")
+	write("debug.log", "ignored\n")
+	if err := os.Remove(filepath.Join(dir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// summarize reduces file diffs to comparable "name +added -deleted" strings
+func summarize(files []diff.FileDiff) []string {
+	var out []string
+	for _, f := range files {
+		out = append(out, fmt.Sprintf("%s +%d -%d", f.Name, f.AddCount, f.DelCount))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestDiffMatchesGit(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Open(filepath.Join(dir, "src"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := parser.NewGitRunner("", dir)
+
+	for _, args := range [][]string{
+		nil,
+		{"--cached"},
+		{"HEAD~1"},
+		{"HEAD~1", "HEAD"},
+		{"HEAD~1..v1"},
+		{"HEAD~1", "--", "src"},
+	} {
+		got, err := repo.Diff(ctx, args...)
+		if err != nil {
+			t.Fatalf("native diff %v: %v", args, err)
+		}
+		want, err := runner.Diff(ctx, args...)
+		if err != nil {
+			t.Fatalf("git diff %v: %v", args, err)
+		}
+		if g, w := summarize(got), summarize(want); !slices.Equal(g, w) {
+			t.Errorf("diff %v: got %v, want %v", args, g, w)
+		}
+	}
+}
+
+func TestStatusMatchesGit(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := parser.NewGitRunner("", dir).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Branch.Head != want.Branch.Head || got.Branch.OID != want.Branch.OID {
+		t.Errorf("branch = %+v, want %+v", got.Branch, want.Branch)
+	}
+	if len(got.Entries) != len(want.Entries) {
+		t.Fatalf("got %d entries, want %d", len(got.Entries), len(want.Entries))
+	}
+	for _, w := range want.Entries {
+		g, ok := got.Get(w.Path)
+		if !ok {
+			t.Errorf("%s: missing", w.Path)
+			continue
+		}
+		if g.XY() != w.XY() {
+			t.Errorf("%s: XY = %q, want %q", w.Path, g.XY(), w.XY())
+		}
+	}
+}
+
+func TestWorkingTree(t *testing.T) {
+	dir := newTestRepo(t)
+
+	repo, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.WorkingTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantWT, err := parser.NewGitRunner("", dir).WorkingTree(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g, w := summarize(wt.Staged.Files), summarize(wantWT.Staged.Files); !slices.Equal(g, w) {
+		t.Errorf("staged = %v, want %v", g, w)
+	}
+	if g, w := summarize(wt.Unstaged.Files), summarize(wantWT.Unstaged.Files); !slices.Equal(g, w) {
+		t.Errorf("unstaged = %v, want %v", g, w)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ rev, path, want string }{
+		{"HEAD", "README.md", "# test\n"},
+		{parser.IndexRev, "README.md", "# test\n\nstaged\n"},
+		{parser.WorktreeRev, "src/main.go", "package main\n\nfunc main() { println() }\n"},
+		{"v1", "gone.txt", "bye\n"},
+	} {
+		got, err := repo.ReadFile(ctx, tc.rev, tc.path)
+		if err != nil {
+			t.Errorf("ReadFile(%q, %q): %v", tc.rev, tc.path, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("ReadFile(%q, %q) = %q, want %q", tc.rev, tc.path, got, tc.want)
+		}
+	}
+
+	if _, err := repo.ReadFile(ctx, "nope", "README.md"); err == nil {
+		t.Error("expected error for unknown revision")
+	}
+}
+
+func TestReadFile_AfterRepack(t *testing.T) {
+	dir := newTestRepo(t)
+	ctx := context.Background()
+
+	repo, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReadFile(ctx, "HEAD", "README.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	// gc replaces the pack read above and packs the new commit
+	runGit(t, dir, 0, "commit", "-q", "-m", "third")
+	runGit(t, dir, 0, "gc", "-q")
+
+	got, err := repo.ReadFile(ctx, "HEAD", "README.md")
+	if err != nil {
+		t.Fatalf("ReadFile after gc: %v", err)
+	}
+	if string(got) != "# test\n\nstaged\n" {
+		t.Errorf("ReadFile after gc = %q", got)
+	}
+}
+
+// runGit runs git in dir and returns its output; a non-zero date sets the
+// commit dates, in seconds since the epoch
+func runGit(t *testing.T, dir string, date int, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if date != 0 {
+		stamp := fmt.Sprintf("%d +0000", date)
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestMergeBaseMatchesGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	date := 1700000000
+	commit := func(branch, name string) {
+		t.Helper()
+		date += 60
+		if branch != "" {
+			runGit(t, dir, 0, "checkout", "-q", branch)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, 0, "add", name)
+		runGit(t, dir, date, "commit", "-q", "-m", name)
+	}
+	merge := func(branch, other string) {
+		t.Helper()
+		date += 60
+		runGit(t, dir, 0, "checkout", "-q", branch)
+		runGit(t, dir, date, "merge", "-q", "--no-ff", "--no-edit", other)
+	}
+
+	runGit(t, dir, 0, "init", "-q", "-b", "main")
+	commit("", "root")
+	runGit(t, dir, 0, "branch", "side")
+	runGit(t, dir, 0, "branch", "x")
+	runGit(t, dir, 0, "branch", "y")
+
+	// side merges an older commit of main, whose first parent is the root
+	for _, name := range []string{"m1", "m2", "m3"} {
+		commit("main", name)
+	}
+	runGit(t, dir, 0, "tag", "-a", "old", "-m", "old", "main")
+	merge("side", "old")
+	commit("main", "m4")
+
+	// x and y merge each other: criss-cross, with two best ancestors
+	commit("x", "x1")
+	commit("y", "y1")
+	runGit(t, dir, 0, "branch", "y1", "y")
+	merge("y", "x")
+	merge("x", "y1")
+
+	repo, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]string{{"main", "side"}, {"side", "main"}, {"old", "main"}, {"x", "y"}, {"y", "x"}, {"main", "x"}} {
+		want := runGit(t, dir, 0, "merge-base", pair[0], pair[1])
+		got, err := repo.mergeBase(pair[0], pair[1])
+		if err != nil {
+			t.Errorf("mergeBase(%s, %s): %v", pair[0], pair[1], err)
+			continue
+		}
+		if got.String() != want {
+			t.Errorf("mergeBase(%s, %s) = %s, git merge-base says %s", pair[0], pair[1], got, want)
+		}
+	}
+}
+
+func TestOpen_NotARepository(t *testing.T) {
+	if _, err := Open(t.TempDir(), ""); !errors.Is(err, parser.ErrNotGitRepo) {
+		t.Errorf("err = %v, want ErrNotGitRepo", err)
+	}
+}
@@ -0,0 +1,40 @@
+package native
+
+import (
+	"context"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+)
+
+var _ parser.Stasher = (*Repo)(nil)
+
+// StashList delegates to git, which reads the stash reflog
+func (r *Repo) StashList(ctx context.Context) ([]parser.StashEntry, error) {
+	return r.git.StashList(ctx)
+}
+
+// StashDiff delegates to git; stash@{n} names are reflog lookups
+func (r *Repo) StashDiff(ctx context.Context, ref string) ([]diff.FileDiff, error) {
+	return r.git.StashDiff(ctx, ref)
+}
+
+// StashPush delegates to git stash push
+func (r *Repo) StashPush(ctx context.Context, opts parser.StashPushOptions) error {
+	return r.git.StashPush(ctx, opts)
+}
+
+// StashApply delegates to git stash apply
+func (r *Repo) StashApply(ctx context.Context, ref string) error {
+	return r.git.StashApply(ctx, ref)
+}
+
+// StashPop delegates to git stash pop
+func (r *Repo) StashPop(ctx context.Context, ref string) error {
+	return r.git.StashPop(ctx, ref)
+}
+
+// StashDrop delegates to git stash drop
+func (r *Repo) StashDrop(ctx context.Context, ref string) error {
+	return r.git.StashDrop(ctx, ref)
+}
@@ -0,0 +1,156 @@
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TreeEntry is a file in a flattened tree
+type TreeEntry struct {
+	Mode uint32
+	Hash Hash
+}
+
+const (
+	modeTree    = 0o040000
+	modeGitlink = 0o160000
+)
+
+// Commit is the subset of a commit object the backend needs
+type Commit struct {
+	Tree    Hash
+	Parents []Hash
+	Time    int64 // Committer timestamp
+	Message string
+}
+
+// parseCommit parses the headers and message of a commit object
+func parseCommit(data []byte) (*Commit, error) {
+	c := &Commit{}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+	c.Message = string(message)
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			h, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Tree = h
+		case "parent":
+			h, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Parents = append(c.Parents, h)
+		case "committer":
+			// Name <email> timestamp zone
+			if fields := strings.Fields(value); len(fields) >= 2 {
+				c.Time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+
+	if c.Tree.IsZero() {
+		return nil, fmt.Errorf("commit without tree")
+	}
+	return c, nil
+}
+
+// parseTagTarget returns the object an annotated tag points at
+func parseTagTarget(data []byte) (Hash, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "object "); ok {
+			return ParseHash(value)
+		}
+		if line == "" {
+			break
+		}
+	}
+	return ZeroHash, fmt.Errorf("tag without object")
+}
+
+// readTree flattens the tree h into path -> entry, recursing into subtrees
+// and keeping gitlinks as entries of their own
+func (r *Repo) readTree(h Hash, prefix string, out map[string]TreeEntry) error {
+	typ, data, err := r.objects.Read(h)
+	if err != nil {
+		return err
+	}
+	if typ != ObjectTree {
+		return fmt.Errorf("%s is a %s, not a tree", h, typ)
+	}
+
+	for len(data) > 0 {
+		// Each entry is "<octal mode> <name>\0<20-byte hash>"
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+21 {
+			return fmt.Errorf("corrupt tree %s", h)
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return fmt.Errorf("corrupt tree %s", h)
+		}
+		name := string(data[sp+1 : nul])
+		var entryHash Hash
+		copy(entryHash[:], data[nul+1:nul+21])
+		data = data[nul+21:]
+
+		path := prefix + name
+		if mode == modeTree {
+			if err := r.readTree(entryHash, path+"/", out); err != nil {
+				return err
+			}
+			continue
+		}
+		out[path] = TreeEntry{Mode: uint32(mode), Hash: entryHash}
+	}
+	return nil
+}
+
+// readCommit reads and parses the commit h, peeling annotated tags
+func (r *Repo) readCommit(h Hash) (*Commit, error) {
+	_, c, err := r.peelCommit(h)
+	return c, err
+}
+
+// peelCommit follows annotated tags from h to a commit and returns its name
+// along with the parsed commit
+func (r *Repo) peelCommit(h Hash) (Hash, *Commit, error) {
+	for depth := 0; depth < 10; depth++ {
+		typ, data, err := r.objects.Read(h)
+		if err != nil {
+			return ZeroHash, nil, err
+		}
+		switch typ {
+		case ObjectCommit:
+			c, err := parseCommit(data)
+			return h, c, err
+		case ObjectTag:
+			if h, err = parseTagTarget(data); err != nil {
+				return ZeroHash, nil, err
+			}
+		default:
+			return ZeroHash, nil, fmt.Errorf("%s is a %s, not a commit", h, typ)
+		}
+	}
+	return ZeroHash, nil, fmt.Errorf("tag chain too deep")
+}
+
+// commitFiles returns the flattened tree of a commit
+func (r *Repo) commitFiles(h Hash) (map[string]TreeEntry, error) {
+	c, err := r.readCommit(h)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]TreeEntry)
+	if err := r.readTree(c.Tree, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
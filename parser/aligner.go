@@ -24,6 +24,14 @@ func alignHunk(h hunk) ([]diff.Line, []diff.Line, int, int) {
 	var left, right []diff.Line
 	var addCount, delCount int
 
+	// Line numbers on each side, counting from the hunk header
+	oldLine, newLine := h.oldStart, h.newStart
+	numbered := func(line diff.Line, number *int) diff.Line {
+		line.Number = *number
+		*number++
+		return line
+	}
+
 	i := 0
 	for i < len(h.lines) {
 		line := h.lines[i]
@@ -31,15 +39,15 @@ func alignHunk(h hunk) ([]diff.Line, []diff.Line, int, int) {
 		switch line.Type {
 		case diff.Context:
 			// Context lines appear on both sides
-			left = append(left, line)
-			right = append(right, line)
+			left = append(left, numbered(line, &oldLine))
+			right = append(right, numbered(line, &newLine))
 			i++
 
 		case diff.Delete:
 			// Collect consecutive deletes
 			var deletes []diff.Line
 			for i < len(h.lines) && h.lines[i].Type == diff.Delete {
-				deletes = append(deletes, h.lines[i])
+				deletes = append(deletes, numbered(h.lines[i], &oldLine))
 				delCount++
 				i++
 			}
@@ -47,7 +55,7 @@ func alignHunk(h hunk) ([]diff.Line, []diff.Line, int, int) {
 			// Collect consecutive adds that follow
 			var adds []diff.Line
 			for i < len(h.lines) && h.lines[i].Type == diff.Add {
-				adds = append(adds, h.lines[i])
+				adds = append(adds, numbered(h.lines[i], &newLine))
 				addCount++
 				i++
 			}
@@ -59,7 +67,7 @@ func alignHunk(h hunk) ([]diff.Line, []diff.Line, int, int) {
 			// Standalone adds (not following deletes)
 			var adds []diff.Line
 			for i < len(h.lines) && h.lines[i].Type == diff.Add {
-				adds = append(adds, h.lines[i])
+				adds = append(adds, numbered(h.lines[i], &newLine))
 				addCount++
 				i++
 			}
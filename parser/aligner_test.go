@@ -262,3 +262,28 @@ func TestAlignHunks_MultipleHunks(t *testing.T) {
 		t.Fatalf("expected 4 lines each side, got %d/%d", len(left), len(right))
 	}
 }
+
+func TestAlignHunk_LineNumbers(t *testing.T) {
+	h := hunk{
+		oldStart: 10,
+		newStart: 20,
+		lines: []diff.Line{
+			{Type: diff.Context, Content: "ctx1"},
+			{Type: diff.Delete, Content: "del1"},
+			{Type: diff.Delete, Content: "del2"},
+			{Type: diff.Add, Content: "add"},
+			{Type: diff.Context, Content: "ctx2"},
+			{Type: diff.Add, Content: "add2"},
+		},
+	}
+
+	left, right, _, _ := alignHunk(h)
+
+	wantLeft := []int{10, 11, 12, 13, 0}
+	wantRight := []int{20, 21, 0, 22, 23}
+	for i := range wantLeft {
+		if left[i].Number != wantLeft[i] || right[i].Number != wantRight[i] {
+			t.Errorf("row %d: numbers %d | %d, want %d | %d", i, left[i].Number, right[i].Number, wantLeft[i], wantRight[i])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is the commit that last changed a line, from git blame
+type BlameLine struct {
+	Commit   string
+	Author   string
+	Email    string
+	Date     time.Time
+	Summary  string
+	Filename string // Path of the file in Commit, which differs after a rename
+	Line     int    // Line number in Commit's version of the file
+}
+
+// IsCommitted reports whether the line is in a commit rather than only in the
+// index or working tree
+func (b BlameLine) IsCommitted() bool {
+	return strings.Trim(b.Commit, "0") != ""
+}
+
+// Blamer is implemented by repositories that can blame files
+type Blamer interface {
+	// Blame returns who last changed each line of path at rev, indexed by
+	// line number - 1; an empty rev is the index and a...b their merge base
+	Blame(ctx context.Context, rev, path string) ([]BlameLine, error)
+}
+
+var _ Blamer = (*GitRunner)(nil)
+
+// ParseBlame parses git blame --porcelain output, remembering the commit
+// details that are only printed the first time
+func ParseBlame(output string) ([]BlameLine, error) {
+	var lines []BlameLine
+	commits := make(map[string]*BlameLine)
+	var current *BlameLine
+	var orig, final int
+
+	for i, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			// The line's content ends its entry
+			if current == nil {
+				return nil, &ParseError{Line: i + 1, Message: "blame content without a header", Cause: ErrInvalidBlame}
+			}
+			for len(lines) < final {
+				lines = append(lines, BlameLine{})
+			}
+			lines[final-1] = *current
+			lines[final-1].Line = orig
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			// Header: <commit> <original line> <final line> [<lines in group>]
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(fields[0]) < 40 {
+				return nil, &ParseError{Line: i + 1, Message: "invalid blame header " + strconv.Quote(line), Cause: ErrInvalidBlame}
+			}
+			var err1, err2 error
+			orig, err1 = strconv.Atoi(fields[1])
+			final, err2 = strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil || final < 1 {
+				return nil, &ParseError{Line: i + 1, Message: "invalid blame header " + strconv.Quote(line), Cause: ErrInvalidBlame}
+			}
+			commit, ok := commits[fields[0]]
+			if !ok {
+				commit = &BlameLine{Commit: fields[0]}
+				commits[fields[0]] = commit
+			}
+			current = commit
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.Email = strings.Trim(value, "<>")
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, &ParseError{Line: i + 1, Message: "invalid blame date " + strconv.Quote(value), Cause: ErrInvalidBlame}
+			}
+			current.Date = time.Unix(seconds, 0)
+		case "summary":
+			current.Summary = value
+		case "filename":
+			current.Filename = value
+		}
+	}
+	return lines, nil
+}
+
+// Blame runs git blame --porcelain; the index version goes through --contents
+// on stdin, and the path is made absolute since blame takes no pathspec
+func (g *GitRunner) Blame(ctx context.Context, rev, path string) ([]BlameLine, error) {
+	root, err := g.FindGitRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"blame", "--porcelain"}
+	var opts execOptions
+	switch base, head, threeDot := strings.Cut(rev, "..."); {
+	case rev == "":
+		content, err := g.run(ctx, "show", ":"+path)
+		if err != nil {
+			return nil, err
+		}
+		opts.stdin = content
+		args = append(args, "--contents", "-")
+	case threeDot:
+		mergeBase, err := g.trimmed(ctx, execOptions{}, "merge-base", base, head)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, mergeBase)
+	default:
+		args = append(args, rev)
+	}
+
+	out, err := g.exec(ctx, opts, append(args, "--", filepath.Join(root, filepath.FromSlash(path)))...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlame(out)
+}
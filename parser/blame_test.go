@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBlame(t *testing.T) {
+	a := "1111111111111111111111111111111111111111"
+	b := "2222222222222222222222222222222222222222"
+	output := a + " 1 1 2\nauthor Ada\nauthor-mail <ada@example.com>\nauthor-time 1700000000\nsummary First\nfilename old.txt\n\tone\n" +
+		a + " 2 2\n\ttwo\n" +
+		b + " 5 3 1\nauthor Bob\nauthor-mail <bob@example.com>\nauthor-time 1710000000\nsummary Second\nprevious " + a + " old.txt\nfilename new.txt\n\tthree\n"
+
+	lines, err := ParseBlame(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	if l := lines[1]; l.Commit != a || l.Author != "Ada" || l.Email != "ada@example.com" || l.Summary != "First" || l.Filename != "old.txt" || l.Line != 2 {
+		t.Errorf("line 2 = %+v", l)
+	}
+	if l := lines[2]; l.Commit != b || l.Line != 5 || l.Date.Unix() != 1710000000 || l.Filename != "new.txt" {
+		t.Errorf("line 3 = %+v", l)
+	}
+
+	if _, err := ParseBlame("nonsense\n"); !errors.Is(err, ErrInvalidBlame) {
+		t.Errorf("expected ErrInvalidBlame, got %v", err)
+	}
+}
+
+func TestDiffBase(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"--cached"}, "HEAD"},
+		{[]string{"--staged", "v1"}, "v1"},
+		{[]string{"HEAD~2"}, "HEAD~2"},
+		{[]string{"-w", "a", "b", "--", "file"}, "a"},
+		{[]string{"a..b"}, "a"},
+		{[]string{"..b"}, "HEAD"},
+		{[]string{"a...b"}, "a...b"},
+		{[]string{"--merge-base", "main"}, "main...HEAD"},
+		{[]string{"--merge-base", "main", "topic"}, "main...topic"},
+	}
+	for _, tt := range tests {
+		if got := DiffBase(tt.args); got != tt.want {
+			t.Errorf("DiffBase(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGitRunner_Blame(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	writeTestFile(t, dir, "a.txt", "one\ntwo\n")
+	if _, err := git.run(ctx, "commit", "-q", "-am", "Two lines"); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err := git.Blame(ctx, "HEAD", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(committed) != 2 || committed[1].Summary != "Two lines" || !committed[1].IsCommitted() {
+		t.Fatalf("HEAD blame = %+v", committed)
+	}
+
+	// A staged line is not committed yet in the index version
+	writeTestFile(t, dir, "a.txt", "one\ntwo\nthree\n")
+	if _, err := git.run(ctx, "add", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	index, err := git.Blame(ctx, "", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 3 || !index[0].IsCommitted() || index[2].IsCommitted() {
+		t.Fatalf("index blame = %+v", index)
+	}
+}
+
+func TestGitRunner_BlameFromSubdirectory(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "sub/c.txt", "c\n")
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "Add c"}} {
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Paths are relative to the root wherever the viewer was started
+	sub := NewGitRunner("", filepath.Join(dir, "sub"))
+	for _, rev := range []string{"HEAD", ""} {
+		lines, err := sub.Blame(ctx, rev, "sub/c.txt")
+		if err != nil || len(lines) != 1 || lines[0].Summary != "Add c" {
+			t.Errorf("blame at %q = %+v, %v", rev, lines, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultCommandLogSize is how many records a command log keeps in memory
+	DefaultCommandLogSize = 200
+
+	// maxRecordedOutput caps the stdout/stderr kept per record; diffs can be huge
+	maxRecordedOutput = 8 << 10
+)
+
+// CommandRecord describes one finished git invocation
+type CommandRecord struct {
+	Args     []string
+	Dir      string
+	Start    time.Time
+	Duration time.Duration
+	ExitCode int // -1 when git could not be started or was killed
+	Stdout   string
+	Stderr   string
+	Err      error
+}
+
+// Failed reports whether the command exited unsuccessfully
+func (r CommandRecord) Failed() bool {
+	return r.Err != nil
+}
+
+// Summary is the one-line form: time, command, duration and exit code
+func (r CommandRecord) Summary() string {
+	return fmt.Sprintf("%s  git %s  %s  exit %d",
+		r.Start.Format("15:04:05"), strings.Join(r.Args, " "), r.Duration.Round(time.Millisecond), r.ExitCode)
+}
+
+// Format is the full multi-line form used in the log panel and log file
+func (r CommandRecord) Format() string {
+	var sb strings.Builder
+	sb.WriteString(r.Summary() + "\n")
+	if r.Dir != "" {
+		fmt.Fprintf(&sb, "  dir: %s\n", r.Dir)
+	}
+	if r.Err != nil && r.ExitCode == -1 {
+		fmt.Fprintf(&sb, "  error: %v\n", r.Err)
+	}
+	writeIndented(&sb, "stdout", r.Stdout)
+	writeIndented(&sb, "stderr", r.Stderr)
+	return sb.String()
+}
+
+func writeIndented(sb *strings.Builder, label, text string) {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return
+	}
+	sb.WriteString("  " + label + ":\n")
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString("    " + line + "\n")
+	}
+}
+
+// CommandLog keeps the most recent git commands a GitRunner ran, and can
+// append every record to a writer
+type CommandLog struct {
+	mu      sync.Mutex
+	records []CommandRecord
+	size    int
+	out     io.Writer
+}
+
+// NewCommandLog creates a log that keeps the last size records
+func NewCommandLog(size int) *CommandLog {
+	return &CommandLog{size: size}
+}
+
+// SetOutput makes the log also write every record to w (e.g. a --log-file)
+func (l *CommandLog) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = w
+}
+
+// Records returns the recorded commands, oldest first
+func (l *CommandLog) Records() []CommandRecord {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]CommandRecord(nil), l.records...)
+}
+
+// Add records a finished command
+func (l *CommandLog) Add(r CommandRecord) {
+	if l == nil {
+		return
+	}
+	r.Stdout = truncateOutput(r.Stdout)
+	r.Stderr = truncateOutput(r.Stderr)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, r)
+	if len(l.records) > l.size {
+		l.records = l.records[len(l.records)-l.size:]
+	}
+	if l.out != nil {
+		// The log file is best effort; a write error must not fail the command
+		fmt.Fprintf(l.out, "%s %s", r.Start.Format(time.RFC3339), r.Format())
+	}
+}
+
+// truncateOutput cuts s to maxRecordedOutput bytes, backing up to the start
+// of a rune so no UTF-8 sequence is split
+func truncateOutput(s string) string {
+	if len(s) <= maxRecordedOutput {
+		return s
+	}
+	cut := maxRecordedOutput
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n... (%d more bytes)", len(s)-cut)
+}
+
+// exitCode extracts the process exit code from a command error
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// CommandLogger is implemented by repositories that record the git commands
+// they run
+type CommandLogger interface {
+	CommandLog() *CommandLog
+}
+
+var _ CommandLogger = (*GitRunner)(nil)
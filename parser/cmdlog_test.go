@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCommandLog_KeepsLastRecords(t *testing.T) {
+	log := NewCommandLog(2)
+	for _, arg := range []string{"one", "two", "three"} {
+		log.Add(CommandRecord{Args: []string{arg}})
+	}
+
+	records := log.Records()
+	if len(records) != 2 || records[0].Args[0] != "two" || records[1].Args[0] != "three" {
+		t.Errorf("Records = %+v", records)
+	}
+}
+
+func TestCommandLog_TruncatesOutput(t *testing.T) {
+	log := NewCommandLog(1)
+	log.Add(CommandRecord{Stdout: strings.Repeat("x", maxRecordedOutput+10)})
+
+	out := log.Records()[0].Stdout
+	if !strings.HasSuffix(out, "(10 more bytes)") {
+		t.Errorf("output not truncated: ...%q", out[len(out)-20:])
+	}
+
+	// A cut through a multi-byte rune backs up to its start
+	log.Add(CommandRecord{Stdout: strings.Repeat("x", maxRecordedOutput-1) + "é and more"})
+	out = log.Records()[0].Stdout
+	if !utf8.ValidString(out) || !strings.HasSuffix(out, "(11 more bytes)") {
+		t.Errorf("rune split by truncation: ...%q", out[len(out)-20:])
+	}
+}
+
+func TestCommandLog_NilIsSafe(t *testing.T) {
+	var log *CommandLog
+	log.Add(CommandRecord{})
+	if records := log.Records(); records != nil {
+		t.Errorf("Records = %v", records)
+	}
+}
+
+func TestCommandRecord_Format(t *testing.T) {
+	r := CommandRecord{
+		Args:     []string{"status", "--short"},
+		Dir:      "/repo",
+		Start:    time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		Duration: 12 * time.Millisecond,
+		ExitCode: 1,
+		Stderr:   "fatal: oops\n",
+	}
+
+	want := "15:04:05  git status --short  12ms  exit 1\n" +
+		"  dir: /repo\n" +
+		"  stderr:\n" +
+		"    fatal: oops\n"
+	if got := r.Format(); got != want {
+		t.Errorf("Format =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGitRunner_RecordsCommands(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := NewGitRunner("", dir)
+	var file bytes.Buffer
+	git.CommandLog().SetOutput(&file)
+
+	ctx := context.Background()
+	if _, err := git.run(ctx, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git.run(ctx, "rev-parse", "--verify", "no-such-ref"); err == nil {
+		t.Fatal("expected rev-parse to fail")
+	}
+
+	records := git.CommandLog().Records()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Failed() || records[0].ExitCode != 0 {
+		t.Errorf("init recorded as failed: %+v", records[0])
+	}
+	failed := records[1]
+	if !failed.Failed() || failed.ExitCode != 128 || !strings.Contains(failed.Stderr, "fatal:") {
+		t.Errorf("rev-parse record = %+v", failed)
+	}
+	if !strings.Contains(file.String(), "git rev-parse --verify no-such-ref") {
+		t.Errorf("log file missing command: %q", file.String())
+	}
+}
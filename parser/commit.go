@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CommitOptions describes a commit to record
+type CommitOptions struct {
+	Message  string
+	Amend    bool // Replace HEAD instead of adding a commit on top of it
+	SignOff  bool // Add a Signed-off-by trailer for the committer
+	NoVerify bool // Skip the pre-commit and commit-msg hooks
+	Verbatim bool // The message is cleaned up already, as after $EDITOR
+}
+
+// Args returns the git commit arguments; a typed message only gets the
+// whitespace cleanup, so lines starting with # are kept
+func (o CommitOptions) Args() []string {
+	cleanup := "--cleanup=whitespace"
+	if o.Verbatim {
+		cleanup = "--cleanup=verbatim"
+	}
+	args := []string{"commit", cleanup, "-m", o.Message}
+	if o.Amend {
+		args = append(args, "--amend")
+	}
+	if o.SignOff {
+		args = append(args, "--signoff")
+	}
+	if o.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	return args
+}
+
+// FormatCommitMessage joins a subject and body with the blank line git expects
+func FormatCommitMessage(subject, body string) string {
+	subject = strings.TrimSpace(subject)
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return subject
+	}
+	return subject + "\n\n" + body
+}
+
+// SplitCommitMessage splits a message into its first line and the rest
+func SplitCommitMessage(message string) (subject, body string) {
+	message = strings.TrimSpace(message)
+	subject, body, _ = strings.Cut(message, "\n")
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
+
+// CleanupCommitMessage applies git's "strip" cleanup: comment lines and
+// trailing whitespace are removed and runs of blank lines collapse to one
+func CleanupCommitMessage(message string) string {
+	return cleanupMessage(message, true)
+}
+
+// CleanupCommitWhitespace applies git's "whitespace" cleanup, which is the
+// strip cleanup without removing comment lines
+func CleanupCommitWhitespace(message string) string {
+	return cleanupMessage(message, false)
+}
+
+func cleanupMessage(message string, stripComments bool) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if stripComments && strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Commit records the index as a new commit, or amends HEAD
+func (g *GitRunner) Commit(ctx context.Context, opts CommitOptions) error {
+	_, err := g.stream(ctx, opts.Args()...)
+	return err
+}
+
+// HeadMessage returns the full message of the HEAD commit
+func (g *GitRunner) HeadMessage(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "log", "-1", "--format=%B", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CommitTemplate reads the file named by commit.template; relative paths are
+// taken from the repository root
+func (g *GitRunner) CommitTemplate(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "config", "--path", "commit.template")
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.Stderr == "" {
+		// git config exits non-zero without output when the key is unset
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		root, err := g.FindGitRoot(ctx)
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(root, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommitOptions_Args(t *testing.T) {
+	got := CommitOptions{Message: "msg", Amend: true, SignOff: true, NoVerify: true}.Args()
+	want := []string{"commit", "--cleanup=whitespace", "-m", "msg", "--amend", "--signoff", "--no-verify"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Args() = %v, want %v", got, want)
+	}
+	if got := (CommitOptions{Message: "msg", Verbatim: true}).Args(); got[1] != "--cleanup=verbatim" {
+		t.Errorf("expected an edited message to be kept verbatim, got %v", got)
+	}
+}
+
+func TestFormatAndSplitCommitMessage(t *testing.T) {
+	msg := FormatCommitMessage(" Add feature ", "\nLonger explanation.\n\nMore.\n")
+	if msg != "Add feature\n\nLonger explanation.\n\nMore." {
+		t.Errorf("FormatCommitMessage = %q", msg)
+	}
+	if msg := FormatCommitMessage("Subject only", "  "); msg != "Subject only" {
+		t.Errorf("FormatCommitMessage without body = %q", msg)
+	}
+
+	subject, body := SplitCommitMessage(msg)
+	if subject != "Add feature" || body != "Longer explanation.\n\nMore." {
+		t.Errorf("SplitCommitMessage = %q, %q", subject, body)
+	}
+}
+
+func TestCleanupCommitMessage(t *testing.T) {
+	input := "\n\nSubject  \n\n\n# comment\nBody line\n\n# Changes to be committed:\n#\tmodified: a.txt\n"
+	if got := CleanupCommitMessage(input); got != "Subject\n\nBody line" {
+		t.Errorf("CleanupCommitMessage = %q", got)
+	}
+	if got := CleanupCommitMessage("# only comments\n"); got != "" {
+		t.Errorf("expected empty message, got %q", got)
+	}
+	if got := CleanupCommitWhitespace(input); got != "Subject\n\n# comment\nBody line\n\n# Changes to be committed:\n#\tmodified: a.txt" {
+		t.Errorf("CleanupCommitWhitespace = %q", got)
+	}
+}
+
+func TestGitRunner_CommitKeepsHashLines(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	writeTestFile(t, dir, "a.txt", "fixed\n")
+	if err := git.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	message := "Fix the crash\n\n#123 is fixed by this"
+	if err := git.Commit(ctx, CommitOptions{Message: message}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got, err := git.HeadMessage(ctx); err != nil || got != message {
+		t.Errorf("HeadMessage = %q, %v, want %q", got, err, message)
+	}
+
+	// Amending with HEAD's message keeps the line too
+	if err := git.Commit(ctx, CommitOptions{Message: message, Amend: true}); err != nil {
+		t.Fatalf("amend failed: %v", err)
+	}
+	if got, err := git.HeadMessage(ctx); err != nil || got != message {
+		t.Errorf("HeadMessage after amend = %q, %v, want %q", got, err, message)
+	}
+}
+
+func TestGitRunner_CommitStreamsHookOutput(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := NewGitRunner("", dir)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho checking from hook\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := git.Commit(WithOutput(ctx, &out), CommitOptions{Message: "add a"}); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "checking from hook") {
+		t.Errorf("hook output not streamed: %q", out.String())
+	}
+
+	msg, err := git.HeadMessage(ctx)
+	if err != nil || msg != "add a" {
+		t.Errorf("HeadMessage = %q, %v", msg, err)
+	}
+}
+
+func TestGitRunner_CancelStopsHooks(t *testing.T) {
+	git, dir := newTestRepo(t)
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho waiting\nsleep 8\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "a.txt", "changed\n")
+	if err := git.StageFile(context.Background(), "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	start := time.Now()
+	err := git.Commit(WithOutput(ctx, &out), CommitOptions{Message: "slow"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Commit returned after %v; the hook was not stopped", elapsed)
+	}
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConflictStages are the versions of an unmerged file; a missing stage, as
+// when a side added or deleted the file, is nil
+type ConflictStages struct {
+	Path   string
+	Base   []byte // Stage 1, the merge base
+	Ours   []byte // Stage 2, HEAD's side
+	Theirs []byte // Stage 3, the side being merged in
+}
+
+// Resolver is implemented by repositories that can resolve merge conflicts
+type Resolver interface {
+	// ConflictStages loads the base, ours and theirs versions of an
+	// unmerged entry from git status
+	ConflictStages(ctx context.Context, entry FileStatus) (*ConflictStages, error)
+
+	// Resolve writes content to path in the worktree and marks it resolved;
+	// nil content deletes the file
+	Resolve(ctx context.Context, path string, content []byte) error
+}
+
+var _ Resolver = (*GitRunner)(nil)
+
+// ConflictStages runs git show :1:, :2: and :3: for the stages the entry has
+func (g *GitRunner) ConflictStages(ctx context.Context, entry FileStatus) (*ConflictStages, error) {
+	stages := &ConflictStages{Path: entry.Path}
+	for stage, dst := range map[int]*[]byte{1: &stages.Base, 2: &stages.Ours, 3: &stages.Theirs} {
+		if !entry.HasStage(stage) {
+			continue
+		}
+		out, err := g.run(ctx, "show", ":"+string(rune('0'+stage))+":"+entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		*dst = []byte(out)
+	}
+	return stages, nil
+}
+
+// Resolve writes the resolved file, keeping its mode, and runs git add; a
+// deleted file is removed with git rm
+func (g *GitRunner) Resolve(ctx context.Context, path string, content []byte) error {
+	root, err := g.FindGitRoot(ctx)
+	if err != nil {
+		return err
+	}
+	full := filepath.Join(root, filepath.FromSlash(path))
+
+	if content == nil {
+		_, err := g.run(ctx, "rm", "--quiet", "--force", "--", full)
+		return err
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(full); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(full, content, mode); err != nil {
+		return err
+	}
+	_, err = g.run(ctx, "add", "--", full)
+	return err
+}
+
+// ConflictLines splits file content into lines that keep their newline, so
+// joining merged lines gives back the exact file
+func ConflictLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// newConflictRepo merges two branches that both changed a.txt and that
+// modified and deleted b.txt
+func newConflictRepo(t *testing.T) (*GitRunner, string) {
+	t.Helper()
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	mustRun := func(args ...string) {
+		t.Helper()
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustRun("branch", "-M", "main")
+	mustRun("checkout", "-q", "-b", "topic")
+	writeTestFile(t, dir, "a.txt", "theirs\n")
+	mustRun("rm", "-q", "b.txt")
+	mustRun("commit", "-q", "-am", "Topic")
+	mustRun("checkout", "-q", "main")
+	writeTestFile(t, dir, "a.txt", "ours\n")
+	writeTestFile(t, dir, "b.txt", "b changed\n")
+	mustRun("commit", "-q", "-am", "Main")
+	if _, err := git.run(ctx, "merge", "topic"); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+	return git, dir
+}
+
+func TestGitRunner_ConflictStagesAndResolve(t *testing.T) {
+	git, dir := newConflictRepo(t)
+	ctx := context.Background()
+
+	status, err := git.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicts := status.Conflicts()
+	if len(conflicts) != 2 {
+		t.Fatalf("got %d conflicts", len(conflicts))
+	}
+
+	stages, err := git.ConflictStages(ctx, conflicts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stages.Path != "a.txt" || string(stages.Base) != "a\n" || string(stages.Ours) != "ours\n" || string(stages.Theirs) != "theirs\n" {
+		t.Errorf("a.txt stages = %+v", stages)
+	}
+	deleted, err := git.ConflictStages(ctx, conflicts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.Theirs != nil || string(deleted.Ours) != "b changed\n" {
+		t.Errorf("b.txt stages = %+v", deleted)
+	}
+
+	if err := git.Resolve(ctx, "a.txt", []byte("ours\ntheirs\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "ours\ntheirs\n" {
+		t.Errorf("a.txt = %q", got)
+	}
+	if err := git.Resolve(ctx, "b.txt", nil); err != nil {
+		t.Fatal(err)
+	}
+	status, err = git.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Conflicts()) != 0 {
+		t.Errorf("still conflicted: %+v", status.Conflicts())
+	}
+	if st, _ := status.Get("b.txt"); st.Index != StatusDeleted {
+		t.Errorf("b.txt = %+v, want deleted", st)
+	}
+}
+
+func TestConflictLines(t *testing.T) {
+	for _, content := range []string{"", "a\n", "a\nb", "a\n\nb\n"} {
+		lines := ConflictLines([]byte(content))
+		if got := strings.Join(lines, ""); got != content {
+			t.Errorf("ConflictLines(%q) joins to %q", content, got)
+		}
+	}
+	if lines := ConflictLines([]byte("a\nb")); len(lines) != 2 {
+		t.Errorf("got %q", lines)
+	}
+}
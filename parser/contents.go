@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// DefaultContextLines is the number of unchanged lines around each hunk,
+// the same default git diff uses
+const DefaultContextLines = 3
+
+// DiffContents diffs two versions of a file with the built-in diff engine and
+// aligns it like parsed git output; nil content is a new or deleted file
+func DiffContents(name string, oldContent, newContent []byte, contextLines int) diff.FileDiff {
+	fd := diff.FileDiff{
+		Name:      name,
+		OldPath:   name,
+		NewPath:   name,
+		IsNew:     oldContent == nil,
+		IsDeleted: newContent == nil,
+		OldHash:   blobHash(oldContent),
+		NewHash:   blobHash(newContent),
+	}
+
+	if isBinary(oldContent) || isBinary(newContent) {
+		fd.IsBinary = true
+		return fd
+	}
+
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+	hunks := buildHunks(oldLines, newLines, diff.LineEdits(oldLines, newLines), contextLines)
+
+	fd.LeftLines, fd.RightLines, fd.AddCount, fd.DelCount = alignHunks(hunks)
+	return fd
+}
+
+// blobHash returns the hash git gives content as a blob, or "" for nil
+func blobHash(content []byte) string {
+	if content == nil {
+		return ""
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildHunks groups an edit script into hunks with contextLines of context
+func buildHunks(oldLines, newLines []string, edits []diff.Edit, contextLines int) []hunk {
+	// Find the edits that changed something
+	var changed []int
+	for i, e := range edits {
+		if e.Op != diff.EditEqual {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var hunks []hunk
+	i := 0
+	for i < len(changed) {
+		start := max(changed[i]-contextLines, 0)
+
+		// Extend the hunk while the next change is within 2*context lines
+		end := changed[i]
+		for i < len(changed) && changed[i]-end <= 2*contextLines {
+			end = changed[i]
+			i++
+		}
+		end = min(end+contextLines, len(edits)-1)
+
+		hunks = append(hunks, editHunk(oldLines, newLines, edits[start:end+1]))
+	}
+
+	return hunks
+}
+
+// editHunk builds the hunk covering edits
+func editHunk(oldLines, newLines []string, edits []diff.Edit) hunk {
+	h := hunk{oldStart: -1, newStart: -1}
+	for _, e := range edits {
+		switch e.Op {
+		case diff.EditEqual:
+			h.lines = append(h.lines, diff.Line{Type: diff.Context, Content: oldLines[e.OldIndex]})
+			h.oldCount++
+			h.newCount++
+		case diff.EditDelete:
+			h.lines = append(h.lines, diff.Line{Type: diff.Delete, Content: oldLines[e.OldIndex]})
+			h.oldCount++
+		case diff.EditInsert:
+			h.lines = append(h.lines, diff.Line{Type: diff.Add, Content: newLines[e.NewIndex]})
+			h.newCount++
+		}
+		if h.oldStart < 0 && e.OldIndex >= 0 {
+			h.oldStart = e.OldIndex + 1
+		}
+		if h.newStart < 0 && e.NewIndex >= 0 {
+			h.newStart = e.NewIndex + 1
+		}
+	}
+	h.oldStart = max(h.oldStart, 0)
+	h.newStart = max(h.newStart, 0)
+	return h
+}
+
+// splitLines splits file content into lines without their terminators
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	text := strings.TrimSuffix(string(content), "\n")
+	return strings.Split(text, "\n")
+}
+
+// isBinary applies git's heuristic: a NUL byte in the first 8000 bytes
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+)
+
+func TestDiffContents_Modification(t *testing.T) {
+	oldContent := []byte("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	newContent := []byte("package main\n\nfunc main() {\n\tprintln(\"hello world\")\n\tprintln(\"new line\")\n}\n")
+
+	fd := DiffContents("main.go", oldContent, newContent, DefaultContextLines)
+
+	if fd.AddCount != 2 || fd.DelCount != 1 {
+		t.Errorf("expected 2 additions/1 deletion, got %d/%d", fd.AddCount, fd.DelCount)
+	}
+	if fd.IsNew || fd.IsDeleted {
+		t.Error("file should be neither new nor deleted")
+	}
+	if len(fd.LeftLines) != len(fd.RightLines) {
+		t.Fatalf("sides not aligned: %d/%d", len(fd.LeftLines), len(fd.RightLines))
+	}
+
+	// The changed line pairs up with its replacement, like a parsed diff
+	var paired bool
+	for i := range fd.LeftLines {
+		if fd.LeftLines[i].Type == diff.Delete && fd.RightLines[i].Type == diff.Add {
+			paired = true
+		}
+	}
+	if !paired {
+		t.Error("expected a delete/add pair on the same row")
+	}
+}
+
+func TestDiffContents_MatchesParsedDiff(t *testing.T) {
+	oldContent := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n")
+	newContent := []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nM\nn\n")
+
+	input := `diff --git a/f.txt b/f.txt
+--- a/f.txt
++++ b/f.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,5 +10,5 @@
+ j
+ k
+ l
+-m
++M
+ n
+`
+	parsed, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+	want := parsed.Files[0]
+
+	got := DiffContents("f.txt", oldContent, newContent, DefaultContextLines)
+
+	if len(got.LeftLines) != len(want.LeftLines) {
+		t.Fatalf("expected %d rows, got %d", len(want.LeftLines), len(got.LeftLines))
+	}
+	for i := range want.LeftLines {
+		if got.LeftLines[i].Content != want.LeftLines[i].Content || got.LeftLines[i].Type != want.LeftLines[i].Type {
+			t.Errorf("left row %d: expected %+v, got %+v", i, want.LeftLines[i], got.LeftLines[i])
+		}
+		if got.RightLines[i].Content != want.RightLines[i].Content || got.RightLines[i].Type != want.RightLines[i].Type {
+			t.Errorf("right row %d: expected %+v, got %+v", i, want.RightLines[i], got.RightLines[i])
+		}
+	}
+}
+
+func TestDiffContents_NewAndDeleted(t *testing.T) {
+	added := DiffContents("new.txt", nil, []byte("one\ntwo\n"), DefaultContextLines)
+	if !added.IsNew || added.AddCount != 2 {
+		t.Errorf("expected new file with 2 additions, got new=%v adds=%d", added.IsNew, added.AddCount)
+	}
+
+	removed := DiffContents("old.txt", []byte("one\ntwo\n"), nil, DefaultContextLines)
+	if !removed.IsDeleted || removed.DelCount != 2 {
+		t.Errorf("expected deleted file with 2 deletions, got deleted=%v dels=%d", removed.IsDeleted, removed.DelCount)
+	}
+}
+
+func TestDiffContents_Identical(t *testing.T) {
+	content := []byte(strings.Repeat("same\n", 10))
+	fd := DiffContents("same.txt", content, content, DefaultContextLines)
+
+	if len(fd.LeftLines) != 0 || fd.AddCount != 0 || fd.DelCount != 0 {
+		t.Errorf("expected no changes, got %d rows", len(fd.LeftLines))
+	}
+}
+
+func TestDiffContents_Binary(t *testing.T) {
+	fd := DiffContents("blob.bin", []byte{0, 1, 2}, []byte{0, 1, 3}, DefaultContextLines)
+	if !fd.IsBinary {
+		t.Error("expected binary file")
+	}
+}
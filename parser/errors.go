@@ -14,6 +14,27 @@ var (
 
 	// ErrInvalidDiff indicates malformed diff input
 	ErrInvalidDiff = errors.New("invalid diff format")
+
+	// ErrInvalidStatus indicates malformed git status output
+	ErrInvalidStatus = errors.New("invalid status format")
+
+	// ErrInvalidStash indicates malformed git stash list output
+	ErrInvalidStash = errors.New("invalid stash list format")
+
+	// ErrInvalidLog indicates malformed git log output
+	ErrInvalidLog = errors.New("invalid log format")
+
+	// ErrInvalidRefs indicates malformed git for-each-ref output
+	ErrInvalidRefs = errors.New("invalid ref list format")
+
+	// ErrInvalidRangeDiff indicates malformed git range-diff output
+	ErrInvalidRangeDiff = errors.New("invalid range-diff format")
+
+	// ErrInvalidBlame indicates malformed git blame --porcelain output
+	ErrInvalidBlame = errors.New("invalid blame format")
+
+	// ErrNoCommits indicates a branch has nothing to review
+	ErrNoCommits = errors.New("no commits to review")
 )
 
 // GitError wraps errors from git command execution
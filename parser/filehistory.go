@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// FileRevision is a commit that changed a file, with the file's path in it
+type FileRevision struct {
+	LogEntry
+	Path    string // Path of the file in this commit
+	OldPath string // Path in the parent, when this commit renamed the file
+}
+
+// FileHistoryReader is implemented by repositories that can follow the history
+// of a single file
+type FileHistoryReader interface {
+	// FileHistory returns the commits from HEAD that changed path, newest
+	// first, following renames
+	FileHistory(ctx context.Context, path string) ([]FileRevision, error)
+
+	// FileRevisionDiff returns the change a revision made to its file
+	FileRevisionDiff(ctx context.Context, rev FileRevision) ([]diff.FileDiff, error)
+}
+
+var _ FileHistoryReader = (*GitRunner)(nil)
+
+// fileLogFormat is logFormat with the record separator first, so the name and
+// status git prints after each commit end up in that commit's record
+const fileLogFormat = "--format=%x1e%H%x1f%h%x1f%an%x1f%ae%x1f%at%x1f%P%x1f%s%x1f%b%x1f"
+
+// ParseFileLog parses git log --name-status output in fileLogFormat, leaving
+// out commits without a change to the file, like merges
+func ParseFileLog(output string) ([]FileRevision, error) {
+	var revisions []FileRevision
+	for i, record := range strings.Split(output, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		end := strings.LastIndex(record, "\x1f")
+		if end < 0 {
+			return nil, &ParseError{Line: i, Message: "invalid log entry " + strconv.Quote(record), Cause: ErrInvalidLog}
+		}
+		entries, err := ParseLog(record[:end] + "\x1e")
+		if err != nil {
+			return nil, err
+		}
+
+		var fields []string
+		for _, line := range strings.Split(record[end+1:], "\n") {
+			if line != "" {
+				fields = strings.Split(line, "\t")
+				break
+			}
+		}
+		rev := FileRevision{LogEntry: entries[0]}
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) == 3 && (strings.HasPrefix(fields[0], "R") || strings.HasPrefix(fields[0], "C")):
+			rev.OldPath, rev.Path = fields[1], fields[2]
+		case len(fields) == 2:
+			rev.Path = fields[1]
+		default:
+			return nil, &ParseError{Line: i, Message: "invalid name status " + strconv.Quote(strings.Join(fields, "\t")), Cause: ErrInvalidLog}
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// FileHistory runs git log --follow for path
+func (g *GitRunner) FileHistory(ctx context.Context, path string) ([]FileRevision, error) {
+	out, err := g.run(ctx, "log", "--follow", "--name-status", fileLogFormat, "--", topPathspec(path))
+	if err != nil {
+		return nil, err
+	}
+	return ParseFileLog(out)
+}
+
+// FileRevisionDiff diffs the revision's file against the commit's first
+// parent, under its old name too when the commit renamed it
+func (g *GitRunner) FileRevisionDiff(ctx context.Context, rev FileRevision) ([]diff.FileDiff, error) {
+	parent, err := g.parentOf(ctx, rev.Hash)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-M", parent, rev.Hash, "--", topPathspec(rev.Path)}
+	if rev.OldPath != "" {
+		args = append(args, topPathspec(rev.OldPath))
+	}
+	return g.Diff(ctx, args...)
+}
+
+// topPathspec makes a path relative to the repository root into a pathspec
+// that means the same from any directory
+func topPathspec(path string) string {
+	return ":(top)" + path
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseFileLog(t *testing.T) {
+	output := "\x1eaaaa\x1fa1\x1fAda\x1fada@example.com\x1f1700000000\x1fbbbb\x1fRename\x1f\x1f\n\nR090\told.txt\tnew.txt\n" +
+		"\x1ecccc\x1fc1\x1fAda\x1fada@example.com\x1f1600000000\x1fdddd eeee\x1fMerge\x1f\x1f\n" +
+		"\x1ebbbb\x1fb1\x1fBob\x1fbob@example.com\x1f1500000000\x1f\x1fAdd\x1fWith a body\x1f\n\nA\told.txt\n"
+
+	revs, err := ParseFileLog(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The merge changed nothing itself and is left out
+	if len(revs) != 2 {
+		t.Fatalf("got %d revisions", len(revs))
+	}
+	if r := revs[0]; r.Hash != "aaaa" || r.Path != "new.txt" || r.OldPath != "old.txt" {
+		t.Errorf("rename = %+v", r)
+	}
+	if r := revs[1]; r.Path != "old.txt" || r.OldPath != "" || r.Body != "With a body" {
+		t.Errorf("add = %+v", r)
+	}
+
+	if _, err := ParseFileLog("\x1ejunk"); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+}
+
+func TestGitRunner_FileHistoryFollowsRenames(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	mustRun := func(args ...string) {
+		t.Helper()
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeTestFile(t, dir, "a.txt", "a\nchanged\n")
+	mustRun("commit", "-q", "-am", "Change a")
+	mustRun("mv", "a.txt", "renamed.txt")
+	mustRun("commit", "-q", "-m", "Rename a")
+	writeTestFile(t, dir, "b.txt", "b changed\n")
+	mustRun("commit", "-q", "-am", "Change b")
+
+	revs, err := git.FileHistory(ctx, "renamed.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 3 || revs[0].Subject != "Rename a" || revs[1].Path != "a.txt" || revs[2].Subject != "init" {
+		t.Fatalf("history = %+v", revs)
+	}
+	if revs[0].OldPath != "a.txt" || revs[0].Path != "renamed.txt" {
+		t.Errorf("rename = %+v", revs[0])
+	}
+
+	// Each step diffs just the file, under both names for the rename
+	files, err := git.FileRevisionDiff(ctx, revs[0])
+	if err != nil || len(files) != 1 || files[0].OldPath != "a.txt" || files[0].NewPath != "renamed.txt" {
+		t.Fatalf("rename diff = %+v, %v", files, err)
+	}
+	files, err = git.FileRevisionDiff(ctx, revs[2])
+	if err != nil || len(files) != 1 || !files[0].IsNew || files[0].Name != "a.txt" {
+		t.Fatalf("root diff = %+v, %v", files, err)
+	}
+}
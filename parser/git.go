@@ -3,15 +3,19 @@ package parser
 import (
 	"bytes"
 	"context"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // GitRunner handles git command execution
 type GitRunner struct {
 	gitPath string
 	workDir string
+	log     *CommandLog
 }
 
 // NewGitRunner creates a new git runner
@@ -22,166 +26,151 @@ func NewGitRunner(gitPath, workDir string) *GitRunner {
 	return &GitRunner{
 		gitPath: gitPath,
 		workDir: workDir,
+		log:     NewCommandLog(DefaultCommandLogSize),
 	}
 }
 
+// CommandLog returns the record of commands this runner has run
+func (g *GitRunner) CommandLog() *CommandLog {
+	return g.log
+}
+
 // IsGitRepository checks if workDir is inside a git repository
 func (g *GitRunner) IsGitRepository(ctx context.Context) bool {
-	cmd := exec.CommandContext(ctx, g.gitPath, "rev-parse", "--git-dir")
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
-	}
-	err := cmd.Run()
+	_, err := g.run(ctx, "rev-parse", "--git-dir")
 	return err == nil
 }
 
+// GitDir returns the absolute path of the git directory
+func (g *GitRunner) GitDir(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(strings.TrimSpace(out)), nil
+}
+
 // RunDiff executes git diff with the given arguments
 func (g *GitRunner) RunDiff(ctx context.Context, args ...string) (string, error) {
 	// Build command args: always include --no-color to avoid ANSI codes
 	cmdArgs := []string{"diff", "--no-color"}
 	cmdArgs = append(cmdArgs, args...)
-
-	cmd := exec.CommandContext(ctx, g.gitPath, cmdArgs...)
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		// Check if it's a "not a git repo" error
-		if strings.Contains(stderr.String(), "not a git repository") {
-			return "", ErrNotGitRepo
-		}
-		return "", &GitError{
-			Args:   cmdArgs,
-			Stderr: strings.TrimSpace(stderr.String()),
-			Err:    err,
-		}
-	}
-
-	return stdout.String(), nil
+	return g.run(ctx, cmdArgs...)
 }
 
 // FindGitRoot finds the root directory of the git repository
 func (g *GitRunner) FindGitRoot(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, g.gitPath, "rev-parse", "--show-toplevel")
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	out, err := g.run(ctx, "rev-parse", "--show-toplevel")
 	if err != nil {
-		if strings.Contains(stderr.String(), "not a git repository") {
-			return "", ErrNotGitRepo
-		}
-		return "", &GitError{
-			Args:   []string{"rev-parse", "--show-toplevel"},
-			Stderr: strings.TrimSpace(stderr.String()),
-			Err:    err,
-		}
+		return "", err
 	}
-
-	return filepath.Clean(strings.TrimSpace(stdout.String())), nil
+	return filepath.Clean(strings.TrimSpace(out)), nil
 }
 
 // StageFile stages a single file (git add)
 func (g *GitRunner) StageFile(ctx context.Context, filepath string) error {
-	cmd := exec.CommandContext(ctx, g.gitPath, "add", filepath)
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
-	}
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		return &GitError{
-			Args:   []string{"add", filepath},
-			Stderr: strings.TrimSpace(stderr.String()),
-			Err:    err,
-		}
-	}
-
-	return nil
+	_, err := g.run(ctx, "add", filepath)
+	return err
 }
 
 // UnstageFile unstages a single file (git reset HEAD)
 func (g *GitRunner) UnstageFile(ctx context.Context, filepath string) error {
-	cmd := exec.CommandContext(ctx, g.gitPath, "reset", "HEAD", filepath)
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
-	}
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	_, err := g.run(ctx, "reset", "-q", "HEAD", filepath)
+	return err
+}
 
-	err := cmd.Run()
+// GetStagedFiles returns a list of currently staged file paths
+func (g *GitRunner) GetStagedFiles(ctx context.Context) ([]string, error) {
+	out, err := g.run(ctx, "diff", "--cached", "--name-only")
 	if err != nil {
-		return &GitError{
-			Args:   []string{"reset", "HEAD", filepath},
-			Stderr: strings.TrimSpace(stderr.String()),
-			Err:    err,
-		}
+		return nil, err
 	}
 
-	return nil
-}
-
-// Commit creates a commit with the given message
-func (g *GitRunner) Commit(ctx context.Context, message string) error {
-	cmd := exec.CommandContext(ctx, g.gitPath, "commit", "-m", message)
-	if g.workDir != "" {
-		cmd.Dir = g.workDir
+	output := strings.TrimSpace(out)
+	if output == "" {
+		return nil, nil
 	}
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	return strings.Split(output, "\n"), nil
+}
 
-	err := cmd.Run()
-	if err != nil {
-		return &GitError{
-			Args:   []string{"commit", "-m", message},
-			Stderr: strings.TrimSpace(stderr.String()),
-			Err:    err,
-		}
-	}
+// execOptions adjusts how exec runs a git command
+type execOptions struct {
+	stream bool     // Copy stdout to the WithOutput writer too
+	stdin  string   // Fed to the command's stdin
+	env    []string // Added to the inherited environment
+}
 
-	return nil
+// run executes git with the given arguments and returns its stdout
+func (g *GitRunner) run(ctx context.Context, args ...string) (string, error) {
+	return g.exec(ctx, execOptions{}, args...)
 }
 
-// GetStagedFiles returns a list of currently staged file paths
-func (g *GitRunner) GetStagedFiles(ctx context.Context) ([]string, error) {
-	cmd := exec.CommandContext(ctx, g.gitPath, "diff", "--cached", "--name-only")
+// stream is run for commands whose stdout is meant for people (commit and
+// its hooks): it is copied to the WithOutput writer as well as returned
+func (g *GitRunner) stream(ctx context.Context, args ...string) (string, error) {
+	return g.exec(ctx, execOptions{stream: true}, args...)
+}
+
+// cancelWaitDelay bounds how long a cancelled command's output is waited for
+const cancelWaitDelay = 2 * time.Second
+
+func (g *GitRunner) exec(ctx context.Context, opts execOptions, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, g.gitPath, args...)
+	killGroupOnCancel(cmd)
+	cmd.WaitDelay = cancelWaitDelay
 	if g.workDir != "" {
 		cmd.Dir = g.workDir
 	}
+	if opts.stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.stdin)
+	}
+	env := opts.env
+	if optionalLocksOff(ctx) {
+		env = append(env[:len(env):len(env)], "GIT_OPTIONAL_LOCKS=0")
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if w := outputFrom(ctx); w != nil {
+		// Both pipes are copied from their own goroutine
+		w = &syncWriter{w: w}
+		cmd.Stderr = io.MultiWriter(&stderr, w)
+		if opts.stream {
+			cmd.Stdout = io.MultiWriter(&stdout, w)
+		}
+	}
 
+	start := time.Now()
 	err := cmd.Run()
+	g.log.Add(CommandRecord{
+		Args:     args,
+		Dir:      g.workDir,
+		Start:    start,
+		Duration: time.Since(start),
+		ExitCode: exitCode(err),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Err:      err,
+	})
+
 	if err != nil {
-		return nil, &GitError{
-			Args:   []string{"diff", "--cached", "--name-only"},
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if strings.Contains(stderr.String(), "not a git repository") {
+			return "", ErrNotGitRepo
+		}
+		return "", &GitError{
+			Args:   args,
 			Stderr: strings.TrimSpace(stderr.String()),
 			Err:    err,
 		}
 	}
 
-	output := strings.TrimSpace(stdout.String())
-	if output == "" {
-		return nil, nil
-	}
-
-	return strings.Split(output, "\n"), nil
+	return stdout.String(), nil
 }
@@ -0,0 +1,17 @@
+package parser
+
+import "context"
+
+type noLocksKey struct{}
+
+// WithoutOptionalLocks returns a context whose git commands run with
+// GIT_OPTIONAL_LOCKS=0, so background reads never take index.lock
+func WithoutOptionalLocks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noLocksKey{}, true)
+}
+
+// optionalLocksOff reports whether ctx was made by WithoutOptionalLocks
+func optionalLocksOff(ctx context.Context) bool {
+	off, _ := ctx.Value(noLocksKey{}).(bool)
+	return off
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+)
+
+// EmptyTree is the hash of git's empty tree, the "parent" of a root commit
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// LogEntry is one commit of git log
+type LogEntry struct {
+	Hash      string
+	ShortHash string
+	Author    string
+	Email     string
+	Date      time.Time
+	Parents   []string
+	Subject   string
+	Body      string
+}
+
+// LogOptions selects a page of history
+type LogOptions struct {
+	Rev    string // Where to start; empty is HEAD
+	Skip   int
+	Limit  int    // 0 means no limit
+	Search string // Case-insensitive substring of the commit message
+}
+
+// LogReader is implemented by repositories that can browse history
+type LogReader interface {
+	// Log returns commits newest first
+	Log(ctx context.Context, opts LogOptions) ([]LogEntry, error)
+
+	// CommitDiff returns the changes a commit made to its first parent
+	CommitDiff(ctx context.Context, hash string) ([]diff.FileDiff, error)
+}
+
+var _ LogReader = (*GitRunner)(nil)
+
+// logFormat separates fields with unit separators and commits with record
+// separators, since bodies span lines
+const logFormat = "--format=%H%x1f%h%x1f%an%x1f%ae%x1f%at%x1f%P%x1f%s%x1f%b%x1e"
+
+// ParseLog parses git log output in logFormat
+func ParseLog(output string) ([]LogEntry, error) {
+	var entries []LogEntry
+	for i, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, "\x1f")
+		if len(fields) != 8 {
+			return nil, &ParseError{Line: i + 1, Message: "invalid log entry " + strconv.Quote(record), Cause: ErrInvalidLog}
+		}
+		seconds, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Message: "invalid commit date " + strconv.Quote(fields[4]), Cause: ErrInvalidLog}
+		}
+		entries = append(entries, LogEntry{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Email:     fields[3],
+			Date:      time.Unix(seconds, 0),
+			Parents:   strings.Fields(fields[5]),
+			Subject:   fields[6],
+			Body:      strings.TrimSpace(fields[7]),
+		})
+	}
+	return entries, nil
+}
+
+// Log runs git log for a page of history
+func (g *GitRunner) Log(ctx context.Context, opts LogOptions) ([]LogEntry, error) {
+	args := []string{"log", logFormat}
+	if opts.Skip > 0 {
+		args = append(args, "--skip="+strconv.Itoa(opts.Skip))
+	}
+	if opts.Limit > 0 {
+		args = append(args, "-n", strconv.Itoa(opts.Limit))
+	}
+	if opts.Search != "" {
+		args = append(args, "--regexp-ignore-case", "--fixed-strings", "--grep="+opts.Search)
+	}
+	if opts.Rev != "" {
+		args = append(args, opts.Rev)
+	}
+	args = append(args, "--")
+
+	out, err := g.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLog(out)
+}
+
+// CommitDiff diffs a commit against its first parent, or against the empty
+// tree for a root commit
+func (g *GitRunner) CommitDiff(ctx context.Context, hash string) ([]diff.FileDiff, error) {
+	parent, err := g.parentOf(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return g.Diff(ctx, parent, hash)
+}
+
+// parentOf returns the first parent of a commit, or the empty tree for a root
+// commit
+func (g *GitRunner) parentOf(ctx context.Context, hash string) (string, error) {
+	parent := hash + "^"
+	if _, err := g.run(ctx, "rev-parse", "--verify", "--quiet", parent); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return EmptyTree, nil
+	}
+	return parent, nil
+}
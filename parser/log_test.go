@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	output := "aaaa\x1fa1\x1fAda\x1fada@example.com\x1f1700000000\x1fbbbb cccc\x1fMerge things\x1fFirst line\n\nSecond\n\x1e\n" +
+		"bbbb\x1fb1\x1fBob\x1fbob@example.com\x1f1600000000\x1f\x1fInitial\x1f\x1e\n"
+
+	entries, err := ParseLog(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	merge := entries[0]
+	if merge.Hash != "aaaa" || merge.ShortHash != "a1" || merge.Author != "Ada" || len(merge.Parents) != 2 ||
+		merge.Subject != "Merge things" || merge.Body != "First line\n\nSecond" {
+		t.Errorf("merge = %+v", merge)
+	}
+	if root := entries[1]; len(root.Parents) != 0 || root.Body != "" || root.Date.Unix() != 1600000000 {
+		t.Errorf("root = %+v", root)
+	}
+
+	if _, err := ParseLog("just text\x1e"); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("expected ErrInvalidLog, got %v", err)
+	}
+}
+
+func TestGitRunner_LogAndCommitDiff(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	for i, subject := range []string{"Fix the parser", "Add docs", "fix typo"} {
+		writeTestFile(t, dir, "a.txt", subject+"\n")
+		if _, err := git.run(ctx, "commit", "-q", "-am", subject); err != nil {
+			t.Fatalf("commit %d: %v", i, err)
+		}
+	}
+
+	page, err := git.Log(ctx, LogOptions{Skip: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].Subject != "Add docs" || page[1].Subject != "Fix the parser" {
+		t.Fatalf("page = %+v", page)
+	}
+
+	found, err := git.Log(ctx, LogOptions{Search: "FIX"})
+	if err != nil || len(found) != 2 {
+		t.Fatalf("search found %+v, %v", found, err)
+	}
+
+	files, err := git.CommitDiff(ctx, page[0].Hash)
+	if err != nil || len(files) != 1 || files[0].Name != "a.txt" {
+		t.Fatalf("CommitDiff = %+v, %v", files, err)
+	}
+
+	// The root commit is diffed against the empty tree
+	all, _ := git.Log(ctx, LogOptions{})
+	root := all[len(all)-1]
+	files, err = git.CommitDiff(ctx, root.Hash)
+	if err != nil || len(files) != 2 || !files[0].IsNew {
+		t.Fatalf("root CommitDiff = %+v, %v", files, err)
+	}
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ReviewNotesRef is the notes ref review comments are shared in, pushed and
+// fetched like any ref
+const ReviewNotesRef = "refs/notes/review"
+
+// NotesKeeper is implemented by repositories that can read and write git
+// notes on commits
+type NotesKeeper interface {
+	// Note returns the note on commit in the notes ref, or "" if it has none
+	Note(ctx context.Context, ref, commit string) (string, error)
+
+	// SetNote replaces the note on commit in the notes ref; an empty text
+	// removes it
+	SetNote(ctx context.Context, ref, commit, text string) error
+
+	// UserName returns the configured user.name, which signs new notes
+	UserName(ctx context.Context) (string, error)
+}
+
+var _ NotesKeeper = (*GitRunner)(nil)
+
+// Note reads a note with git notes show, which exits with 1 only when there
+// is none
+func (g *GitRunner) Note(ctx context.Context, ref, commit string) (string, error) {
+	out, err := g.run(ctx, "notes", "--ref="+ref, "show", commit)
+	if exitCode(err) == 1 {
+		return "", nil
+	}
+	return out, err
+}
+
+// SetNote writes a note with git notes add -f, which records the change as a
+// commit of the notes ref
+func (g *GitRunner) SetNote(ctx context.Context, ref, commit, text string) error {
+	if text == "" {
+		_, err := g.run(ctx, "notes", "--ref="+ref, "remove", "--ignore-missing", commit)
+		return err
+	}
+	_, err := g.exec(ctx, execOptions{stdin: text}, "notes", "--ref="+ref, "add", "-f", "-F", "-", commit)
+	return err
+}
+
+// UserName reads user.name from git config; it is "" when unset
+func (g *GitRunner) UserName(ctx context.Context) (string, error) {
+	out, err := g.run(ctx, "config", "user.name")
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.Stderr == "" {
+		// git config exits non-zero without output when the key is unset
+		return "", nil
+	}
+	return strings.TrimSpace(out), err
+}
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGitRunner_Notes(t *testing.T) {
+	git, _ := newTestRepo(t)
+	ctx := context.Background()
+
+	// A missing note is told apart by exit status, whatever the language
+	t.Setenv("LANGUAGE", "de")
+	note, err := git.Note(ctx, ReviewNotesRef, "HEAD")
+	if err != nil || note != "" {
+		t.Fatalf("expected no note, got %q, %v", note, err)
+	}
+	if _, err := git.Note(ctx, ReviewNotesRef, "no-such-commit"); err == nil {
+		t.Error("expected an error for an unknown commit")
+	}
+
+	if err := git.SetNote(ctx, ReviewNotesRef, "HEAD", "first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.SetNote(ctx, ReviewNotesRef, "HEAD", "second\n"); err != nil {
+		t.Fatal(err)
+	}
+	if note, err := git.Note(ctx, ReviewNotesRef, "HEAD"); err != nil || note != "second\n" {
+		t.Fatalf("expected the note to be replaced, got %q, %v", note, err)
+	}
+	if note, _ := git.Note(ctx, "refs/notes/commits", "HEAD"); note != "" {
+		t.Errorf("expected the default notes ref to be left alone, got %q", note)
+	}
+
+	if err := git.SetNote(ctx, ReviewNotesRef, "HEAD", ""); err != nil {
+		t.Fatal(err)
+	}
+	if note, err := git.Note(ctx, ReviewNotesRef, "HEAD"); err != nil || note != "" {
+		t.Fatalf("expected the note to be removed, got %q, %v", note, err)
+	}
+
+	if name, err := git.UserName(ctx); err != nil || name != "test" {
+		t.Errorf("UserName = %q, %v", name, err)
+	}
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+type outputKey struct{}
+
+// WithOutput returns a context whose git commands copy their stderr, and the
+// stdout of streamed commands like commit and its hooks, to w as they run
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// outputFrom returns the writer set by WithOutput, or nil
+func outputFrom(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(outputKey{}).(io.Writer)
+	return w
+}
+
+// syncWriter serializes writes to w
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
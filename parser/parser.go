@@ -52,7 +52,8 @@ func (p *Parser) ParseReader(r io.Reader) (*diff.Result, error) {
 	return p.ParseString(string(data))
 }
 
-// ParseGitDiff executes git diff with the given args and parses the output
+// ParseGitDiff executes git diff with the given args and parses the output;
+// without args untracked files are included as new files
 func (p *Parser) ParseGitDiff(ctx context.Context, args ...string) (*diff.Result, error) {
 	// Check if we're in a git repo
 	if !p.git.IsGitRepository(ctx) {
@@ -65,12 +66,78 @@ func (p *Parser) ParseGitDiff(ctx context.Context, args ...string) (*diff.Result
 		return nil, err
 	}
 
+	var untracked []diff.FileDiff
+	if IsWorkingTreeDiff(args) {
+		untracked, err = p.git.UntrackedFileDiffs(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Handle empty diff
 	if strings.TrimSpace(output) == "" {
-		return nil, ErrEmptyDiff
+		if len(untracked) == 0 {
+			return nil, ErrEmptyDiff
+		}
+		return &diff.Result{Files: untracked}, nil
+	}
+
+	result, err := p.ParseString(output)
+	if err != nil {
+		return nil, err
+	}
+	result.Files = append(result.Files, untracked...)
+
+	return result, nil
+}
+
+// IsWorkingTreeDiff reports whether args select the plain index-vs-worktree diff
+func IsWorkingTreeDiff(args []string) bool {
+	return len(args) == 0
+}
+
+// DiffBase returns the revision on the original side of a git diff with args,
+// "" for the index and a...b for their merge base
+func DiffBase(args []string) string {
+	var revs []string
+	cached, mergeBase := false, false
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		switch {
+		case arg == "--cached" || arg == "--staged":
+			cached = true
+		case arg == "--merge-base":
+			mergeBase = true
+		case !strings.HasPrefix(arg, "-"):
+			revs = append(revs, arg)
+		}
 	}
 
-	return p.ParseString(output)
+	orHead := func(rev string) string {
+		if rev == "" {
+			return "HEAD"
+		}
+		return rev
+	}
+	switch {
+	case len(revs) == 0 && cached:
+		return "HEAD"
+	case len(revs) == 0:
+		return ""
+	case strings.Contains(revs[0], "..."):
+		base, head, _ := strings.Cut(revs[0], "...")
+		return orHead(base) + "..." + orHead(head)
+	case strings.Contains(revs[0], ".."):
+		base, _, _ := strings.Cut(revs[0], "..")
+		return orHead(base)
+	case mergeBase && len(revs) > 1:
+		return revs[0] + "..." + revs[1]
+	case mergeBase:
+		return revs[0] + "...HEAD"
+	}
+	return revs[0]
 }
 
 // IsGitRepository checks if the working directory is a git repository
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package parser
+
+import "os/exec"
+
+// killGroupOnCancel leaves the default cancellation, which kills git only;
+// WaitDelay still bounds the wait for its children
+func killGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package parser
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killGroupOnCancel runs cmd in its own process group and has cancelling it
+// kill the whole group, so hooks started by git stop along with it
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
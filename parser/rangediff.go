@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// RangeStatus is how git range-diff matched up a pair of commits
+type RangeStatus byte
+
+const (
+	RangeEqual   RangeStatus = '=' // Same patch in both series
+	RangeChanged RangeStatus = '!' // Patch changed between the series
+	RangeRemoved RangeStatus = '<' // Only in the old series
+	RangeAdded   RangeStatus = '>' // Only in the new series
+)
+
+// CommitMessageFile names the commit message in an interdiff, the way code
+// review tools list it next to the files
+const CommitMessageFile = "COMMIT_MSG"
+
+// RangePair is one line of git range-diff: a commit of the old series, of the
+// new series, or one of each
+type RangePair struct {
+	OldIndex int    // 1-based position in the old series; 0 if only in the new one
+	Old      string // Abbreviated hash; empty if only in the new series
+	NewIndex int
+	New      string
+	Status   RangeStatus
+	Subject  string
+}
+
+// RangeDiffer is implemented by repositories that can compare two versions of
+// a patch series
+type RangeDiffer interface {
+	// RangeDiff pairs up the commits of two series; args are those of git
+	// range-diff, e.g. base old new or old-base..old new-base..new
+	RangeDiff(ctx context.Context, args ...string) ([]RangePair, error)
+
+	// Interdiff compares the message and patches of two commits file by file;
+	// either is empty for a commit in only one series
+	Interdiff(ctx context.Context, oldCommit, newCommit string) ([]diff.FileDiff, error)
+}
+
+var _ RangeDiffer = (*GitRunner)(nil)
+
+// rangePairRE matches "1:  abc1234 ! 1:  def5678 Subject"; a missing side is
+// "-:  -------"
+var rangePairRE = regexp.MustCompile(`^\s*(\d+|-):\s+([0-9a-f]+|-+) ([=!<>])\s+(\d+|-):\s+([0-9a-f]+|-+) (.*)$`)
+
+// ParseRangeDiff parses the pair lines of git range-diff output and skips the
+// patch lines below them
+func ParseRangeDiff(output string) ([]RangePair, error) {
+	var pairs []RangePair
+	for i, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" || strings.HasPrefix(line, "    ") {
+			continue
+		}
+		m := rangePairRE.FindStringSubmatch(line)
+		if m == nil {
+			return nil, &ParseError{Line: i + 1, Message: "invalid range-diff line " + strconv.Quote(line), Cause: ErrInvalidRangeDiff}
+		}
+		pair := RangePair{Status: RangeStatus(m[3][0]), Subject: m[6]}
+		if m[1] != "-" {
+			pair.OldIndex, _ = strconv.Atoi(m[1])
+			pair.Old = m[2]
+		}
+		if m[4] != "-" {
+			pair.NewIndex, _ = strconv.Atoi(m[4])
+			pair.New = m[5]
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// RangeDiff runs git range-diff without its patches; Interdiff shows those
+func (g *GitRunner) RangeDiff(ctx context.Context, args ...string) ([]RangePair, error) {
+	out, err := g.run(ctx, append([]string{"range-diff", "--no-color", "--no-patch"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRangeDiff(out)
+}
+
+// Interdiff reads both commits' messages and patches and diffs them
+func (g *GitRunner) Interdiff(ctx context.Context, oldCommit, newCommit string) ([]diff.FileDiff, error) {
+	oldPatches, err := g.commitPatches(ctx, oldCommit)
+	if err != nil {
+		return nil, err
+	}
+	newPatches, err := g.commitPatches(ctx, newCommit)
+	if err != nil {
+		return nil, err
+	}
+	return InterdiffPatches(oldPatches, newPatches), nil
+}
+
+// commitPatches returns a commit's message and its patch split by file; an
+// empty commit has none
+func (g *GitRunner) commitPatches(ctx context.Context, commit string) (map[string]string, error) {
+	if commit == "" {
+		return nil, nil
+	}
+	message, err := g.run(ctx, "log", "-1", "--format=%B", commit)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := g.parentOf(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := g.RunDiff(ctx, parent, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	patches := SplitPatch(patch)
+	patches[CommitMessageFile] = strings.TrimSpace(message) + "\n"
+	return patches, nil
+}
+
+// SplitPatch splits git diff output by file, dropping index lines and hunk
+// line numbers like git range-diff
+func SplitPatch(patch string) map[string]string {
+	patches := make(map[string]string)
+	var name string
+	var sb strings.Builder
+	flush := func() {
+		if name != "" {
+			patches[name] = sb.String()
+		}
+		sb.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if m := diffGitRE.FindStringSubmatch(strings.TrimSuffix(line, "\n")); m != nil {
+			flush()
+			name = m[2]
+		}
+		switch {
+		case name == "", strings.HasPrefix(line, "index "):
+			continue
+		case hunkHeaderRE.MatchString(line):
+			line = "@@" + strings.TrimPrefix(line, hunkHeaderRE.FindString(line))
+		}
+		sb.WriteString(line)
+	}
+	flush()
+	return patches
+}
+
+// InterdiffPatches diffs two commits' per-file patches, old on the left, with
+// the message first and unchanged patches left out
+func InterdiffPatches(oldPatches, newPatches map[string]string) []diff.FileDiff {
+	names := make(map[string]bool)
+	for name := range oldPatches {
+		names[name] = true
+	}
+	for name := range newPatches {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if (sorted[i] == CommitMessageFile) != (sorted[j] == CommitMessageFile) {
+			return sorted[i] == CommitMessageFile
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	var files []diff.FileDiff
+	for _, name := range sorted {
+		oldPatch, inOld := oldPatches[name]
+		newPatch, inNew := newPatches[name]
+		if inOld && inNew && oldPatch == newPatch {
+			continue
+		}
+		var oldContent, newContent []byte
+		if inOld {
+			oldContent = []byte(oldPatch)
+		}
+		if inNew {
+			newContent = []byte(newPatch)
+		}
+		files = append(files, DiffContents(name, oldContent, newContent, DefaultContextLines))
+	}
+	return files
+}
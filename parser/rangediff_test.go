@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseRangeDiff(t *testing.T) {
+	output := " 1:  7800a48 < -:  ------- Add d\n" +
+		" -:  ------- > 1:  109144b Add d\n" +
+		" 2:  e2c2457 = 2:  02117d7 Add g\n" +
+		"10:  6f22a99 ! 3:  d1ac2bb Change ten\n" +
+		"    @@ f\n" +
+		"     -10\n"
+
+	pairs, err := ParseRangeDiff(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 4 {
+		t.Fatalf("got %d pairs", len(pairs))
+	}
+	if p := pairs[0]; p.Status != RangeRemoved || p.Old != "7800a48" || p.OldIndex != 1 || p.New != "" || p.NewIndex != 0 {
+		t.Errorf("removed = %+v", p)
+	}
+	if p := pairs[1]; p.Status != RangeAdded || p.Old != "" || p.New != "109144b" || p.Subject != "Add d" {
+		t.Errorf("added = %+v", p)
+	}
+	if p := pairs[3]; p.Status != RangeChanged || p.OldIndex != 10 || p.NewIndex != 3 || p.Subject != "Change ten" {
+		t.Errorf("changed = %+v", p)
+	}
+
+	if _, err := ParseRangeDiff("something else"); !errors.Is(err, ErrInvalidRangeDiff) {
+		t.Errorf("expected ErrInvalidRangeDiff, got %v", err)
+	}
+}
+
+func TestSplitPatch(t *testing.T) {
+	patch := "diff --git a/f b/f\nindex de98044..5790697 100644\n--- a/f\n+++ b/f\n@@ -10,3 +10,4 @@ func main() {\n a\n+b\n" +
+		"diff --git a/g b/g\nnew file mode 100644\nindex 0000000..587be6b\n--- /dev/null\n+++ b/g\n@@ -0,0 +1 @@\n+x\n"
+
+	patches := SplitPatch(patch)
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches", len(patches))
+	}
+	if got, want := patches["f"], "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ func main() {\n a\n+b\n"; got != want {
+		t.Errorf("f = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(patches["g"], "diff --git a/g b/g\nnew file mode") || strings.Contains(patches["g"], "index") {
+		t.Errorf("g = %q", patches["g"])
+	}
+}
+
+func TestGitRunner_RangeDiffAndInterdiff(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	mustRun := func(args ...string) {
+		t.Helper()
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = strings.Repeat("line ", 3) + string(rune('a'+i%26))
+	}
+	writeTestFile(t, dir, "a.txt", strings.Join(lines, "\n")+"\n")
+	mustRun("commit", "-q", "-am", "Longer a")
+	mustRun("branch", "-M", "main")
+
+	// Two versions of a one-commit series that edit the same line differently
+	for _, v := range []struct{ branch, text string }{{"v1", "first try"}, {"v2", "second try"}} {
+		mustRun("checkout", "-q", "-b", v.branch, "main")
+		edited := append([]string(nil), lines...)
+		edited[10] = v.text
+		writeTestFile(t, dir, "a.txt", strings.Join(edited, "\n")+"\n")
+		mustRun("commit", "-q", "-am", "Edit line 11")
+	}
+
+	pairs, err := git.RangeDiff(ctx, "main", "v1", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0].Status != RangeChanged || pairs[0].Subject != "Edit line 11" {
+		t.Fatalf("pairs = %+v", pairs)
+	}
+
+	files, err := git.Interdiff(ctx, pairs[0].Old, pairs[0].New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same message, so only the file's patch differs
+	if len(files) != 1 || files[0].Name != "a.txt" || files[0].AddCount != 1 || files[0].DelCount != 1 {
+		t.Fatalf("interdiff = %+v", files)
+	}
+
+	// A commit only in one series shows its whole patch and message
+	files, err = git.Interdiff(ctx, "", pairs[0].New)
+	if err != nil || len(files) != 2 || files[0].Name != CommitMessageFile || !files[1].IsNew {
+		t.Fatalf("one-sided interdiff = %+v, %v", files, err)
+	}
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// RefKind says what kind of name a Ref is
+type RefKind int
+
+const (
+	RefBranch RefKind = iota
+	RefRemote
+	RefTag
+)
+
+func (k RefKind) String() string {
+	switch k {
+	case RefRemote:
+		return "remote"
+	case RefTag:
+		return "tag"
+	}
+	return "branch"
+}
+
+// Ref is a branch, remote-tracking branch or tag
+type Ref struct {
+	Name    string // Short name, e.g. main or origin/main
+	Kind    RefKind
+	Hash    string // Abbreviated hash of what the ref points at
+	Subject string // Subject of the commit (or tag message)
+}
+
+// RefLister is implemented by repositories that can list their refs
+type RefLister interface {
+	// Refs returns branches, remote-tracking branches and tags
+	Refs(ctx context.Context) ([]Ref, error)
+}
+
+var _ RefLister = (*GitRunner)(nil)
+
+// refFormat separates the fields of git for-each-ref with unit separators
+const refFormat = "--format=%(refname)%1f%(objectname:short)%1f%(subject)"
+
+// ParseRefs parses git for-each-ref output in refFormat; symbolic refs such
+// as origin/HEAD are left out
+func ParseRefs(output string) ([]Ref, error) {
+	var refs []Ref
+	for i, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 {
+			return nil, &ParseError{Line: i + 1, Message: "invalid ref " + strconv.Quote(line), Cause: ErrInvalidRefs}
+		}
+
+		ref := Ref{Hash: fields[1], Subject: fields[2]}
+		full := fields[0]
+		switch {
+		case strings.HasPrefix(full, "refs/heads/"):
+			ref.Name, ref.Kind = strings.TrimPrefix(full, "refs/heads/"), RefBranch
+		case strings.HasPrefix(full, "refs/remotes/"):
+			ref.Name, ref.Kind = strings.TrimPrefix(full, "refs/remotes/"), RefRemote
+			if strings.HasSuffix(ref.Name, "/HEAD") {
+				continue
+			}
+		case strings.HasPrefix(full, "refs/tags/"):
+			ref.Name, ref.Kind = strings.TrimPrefix(full, "refs/tags/"), RefTag
+		default:
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Refs lists branches, remote-tracking branches and tags, most recent first
+func (g *GitRunner) Refs(ctx context.Context) ([]Ref, error) {
+	out, err := g.run(ctx, "for-each-ref", "--sort=-creatordate", refFormat,
+		"refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	return ParseRefs(out)
+}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseRefs(t *testing.T) {
+	refs, err := ParseRefs("refs/heads/main\x1fabc1234\x1fFix it\n" +
+		"refs/remotes/origin/HEAD\x1fabc1234\x1fFix it\n" +
+		"refs/remotes/origin/feature/x\x1fdef5678\x1fWork\n" +
+		"refs/tags/v1.0\x1f0123456\x1fRelease 1.0\n" +
+		"refs/stash\x1f9999999\x1fWIP\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Ref{
+		{Name: "main", Kind: RefBranch, Hash: "abc1234", Subject: "Fix it"},
+		{Name: "origin/feature/x", Kind: RefRemote, Hash: "def5678", Subject: "Work"},
+		{Name: "v1.0", Kind: RefTag, Hash: "0123456", Subject: "Release 1.0"},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("got %+v", refs)
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("ref %d = %+v, want %+v", i, refs[i], want[i])
+		}
+	}
+
+	if _, err := ParseRefs("refs/heads/main only\n"); !errors.Is(err, ErrInvalidRefs) {
+		t.Errorf("expected ErrInvalidRefs, got %v", err)
+	}
+}
+
+func TestGitRunner_Refs(t *testing.T) {
+	git, _ := newTestRepo(t)
+	ctx := context.Background()
+	for _, args := range [][]string{{"branch", "feature"}, {"tag", "v1"}} {
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs, err := git.Refs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]RefKind{}
+	for _, ref := range refs {
+		kinds[ref.Name] = ref.Kind
+	}
+	if len(refs) != 3 || kinds["feature"] != RefBranch || kinds["v1"] != RefTag {
+		t.Errorf("Refs = %+v", refs)
+	}
+}
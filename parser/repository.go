@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"context"
+
+	"diff-tui/diff"
+)
+
+// Revisions understood by Repository.ReadFile besides commit-ish names
+const (
+	WorktreeRev = ""  // The file as it is on disk
+	IndexRev    = ":" // The staged version; ":1", ":2" and ":3" select conflict stages
+)
+
+// Repository is the set of repository operations the TUI is built on,
+// implemented by GitRunner and, in memory, by package memrepo
+type Repository interface {
+	// Diff returns the parsed diff for git diff-style args; an empty diff is not an error
+	Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error)
+
+	// WorkingTree returns the staged and unstaged changes, including untracked files
+	WorkingTree(ctx context.Context) (*WorkingTree, error)
+
+	// Status returns the per-path index and worktree state
+	Status(ctx context.Context) (*Status, error)
+
+	// StageFile stages path, UnstageFile resets it to HEAD in the index
+	StageFile(ctx context.Context, path string) error
+	UnstageFile(ctx context.Context, path string) error
+
+	// Commit records the index as a new commit, or amends HEAD
+	Commit(ctx context.Context, opts CommitOptions) error
+
+	// HeadMessage returns the message of the HEAD commit, used to prefill an amend
+	HeadMessage(ctx context.Context) (string, error)
+
+	// ReadFile returns the content of path at rev (a commit-ish, IndexRev or WorktreeRev)
+	ReadFile(ctx context.Context, rev, path string) ([]byte, error)
+}
+
+var _ Repository = (*GitRunner)(nil)
+
+// CommitTemplateReader is implemented by repositories that can read the
+// commit.template file configured in git config
+type CommitTemplateReader interface {
+	// CommitTemplate returns the template content, or "" when none is configured
+	CommitTemplate(ctx context.Context) (string, error)
+}
+
+var _ CommitTemplateReader = (*GitRunner)(nil)
+
+// GitDirFinder is implemented by repositories with a git directory on disk,
+// where the viewer can keep state between sessions
+type GitDirFinder interface {
+	// GitDir returns the absolute path of the worktree's git directory
+	GitDir(ctx context.Context) (string, error)
+}
+
+var _ GitDirFinder = (*GitRunner)(nil)
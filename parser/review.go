@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Review is a branch's commits since it forked from a base
+type Review struct {
+	Base      string // As given, e.g. main
+	MergeBase string
+	Head      string     // HEAD's commit when the review was loaded
+	Commits   []LogEntry // Oldest first
+}
+
+// Args returns the git diff args for all of the branch's changes, the same
+// diff as git diff base...HEAD
+func (r *Review) Args() []string {
+	return []string{r.MergeBase, r.Head}
+}
+
+// CommitArgs returns the git diff args for one commit against its first
+// parent, or against the empty tree for a root commit
+func CommitArgs(e LogEntry) []string {
+	parent := EmptyTree
+	if len(e.Parents) > 0 {
+		parent = e.Parents[0]
+	}
+	return []string{parent, e.Hash}
+}
+
+// Reviewer is implemented by repositories that can load a branch review
+type Reviewer interface {
+	// Review finds the merge base of base and HEAD and the commits after it
+	Review(ctx context.Context, base string) (*Review, error)
+}
+
+var _ Reviewer = (*GitRunner)(nil)
+
+// Review runs git merge-base and lists the commits from there to HEAD
+func (g *GitRunner) Review(ctx context.Context, base string) (*Review, error) {
+	head, err := g.trimmed(ctx, execOptions{}, "rev-parse", "--verify", "HEAD^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := g.trimmed(ctx, execOptions{}, "merge-base", base, head)
+	if err != nil {
+		// merge-base exits 1 without a message when there is no common commit
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && gitErr.Stderr == "" {
+			return nil, fmt.Errorf("%s and HEAD have no common history: %w", base, err)
+		}
+		return nil, err
+	}
+
+	commits, err := g.Log(ctx, LogOptions{Rev: mergeBase + ".." + head})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("%w: HEAD has no commits that are not on %s", ErrNoCommits, base)
+	}
+	return &Review{Base: base, MergeBase: mergeBase, Head: head, Commits: commits}, nil
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGitRunner_Review(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	mustRun := func(args ...string) {
+		t.Helper()
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustRun("branch", "-M", "main")
+	mustRun("checkout", "-q", "-b", "feature")
+	for _, subject := range []string{"Add c", "Change a"} {
+		writeTestFile(t, dir, "c.txt", subject+"\n")
+		writeTestFile(t, dir, "a.txt", subject+"\n")
+		mustRun("add", "-A")
+		mustRun("commit", "-q", "-m", subject)
+	}
+	// main moves on; its commit is not part of the review
+	mustRun("checkout", "-q", "main")
+	writeTestFile(t, dir, "b.txt", "moved on\n")
+	mustRun("commit", "-q", "-am", "Main only")
+	mustRun("checkout", "-q", "feature")
+
+	review, err := git.Review(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(review.Commits) != 2 || review.Commits[0].Subject != "Add c" || review.Commits[1].Subject != "Change a" {
+		t.Fatalf("commits = %+v", review.Commits)
+	}
+	if review.Commits[0].Parents[0] != review.MergeBase {
+		t.Errorf("first commit's parent %s is not the merge base %s", review.Commits[0].Parents[0], review.MergeBase)
+	}
+
+	files, err := git.Diff(ctx, review.Args()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("cumulative diff has %d files, want a.txt and c.txt", len(files))
+	}
+	files, err = git.Diff(ctx, CommitArgs(review.Commits[1])...)
+	if err != nil || len(files) != 2 {
+		t.Errorf("second commit diff = %d files, %v", len(files), err)
+	}
+
+	if _, err := git.Review(ctx, "feature"); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("expected ErrNoCommits, got %v", err)
+	}
+	if _, err := git.Review(ctx, "no-such-branch"); err == nil {
+		t.Error("expected an error for an unknown base")
+	}
+}
+
+func TestCommitArgs(t *testing.T) {
+	if got := CommitArgs(LogEntry{Hash: "b", Parents: []string{"a", "m"}}); got[0] != "a" || got[1] != "b" {
+		t.Errorf("CommitArgs = %v", got)
+	}
+	if got := CommitArgs(LogEntry{Hash: "r"}); got[0] != EmptyTree {
+		t.Errorf("root CommitArgs = %v", got)
+	}
+}
@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+)
+
+// StashEntry is one entry of git stash list
+type StashEntry struct {
+	Ref     string // stash@{n}
+	Commit  string
+	Message string
+	Date    time.Time
+}
+
+// StashPushOptions describes what git stash push should save
+type StashPushOptions struct {
+	Message          string   // Empty uses git's "WIP on <branch>" message
+	Paths            []string // Limit the stash to these paths; empty stashes everything
+	IncludeUntracked bool
+	WorktreeOnly     bool           // Stash only the unstaged changes of Paths and keep the index
+	Hunks            *HunkSelection // Stash only the hunks touching these lines; needs WorktreeOnly and one path
+}
+
+// HunkSelection picks the hunks of a file's diff that touch a range of lines
+type HunkSelection struct {
+	New        bool // Start and End number the worktree version's lines rather than the index's
+	Start, End int
+}
+
+// Stasher is implemented by repositories that support git stash
+type Stasher interface {
+	// StashList returns the stash entries, newest first
+	StashList(ctx context.Context) ([]StashEntry, error)
+
+	// StashDiff returns the changes saved in the stash entry ref
+	StashDiff(ctx context.Context, ref string) ([]diff.FileDiff, error)
+
+	// StashPush saves changes to a new stash entry and reverts them
+	StashPush(ctx context.Context, opts StashPushOptions) error
+
+	// StashApply, StashPop and StashDrop act on the entry ref like their git
+	// stash counterparts
+	StashApply(ctx context.Context, ref string) error
+	StashPop(ctx context.Context, ref string) error
+	StashDrop(ctx context.Context, ref string) error
+}
+
+var _ Stasher = (*GitRunner)(nil)
+
+// stashListFormat separates the fields of git stash list with unit separators
+const stashListFormat = "--format=%gd%x1f%H%x1f%ct%x1f%gs"
+
+// ParseStashList parses git stash list output in stashListFormat
+func ParseStashList(output string) ([]StashEntry, error) {
+	var entries []StashEntry
+	for i, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			return nil, &ParseError{Line: i + 1, Message: "invalid stash entry " + strconv.Quote(line), Cause: ErrInvalidStash}
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Message: "invalid stash date " + strconv.Quote(fields[2]), Cause: ErrInvalidStash}
+		}
+		entries = append(entries, StashEntry{
+			Ref:     fields[0],
+			Commit:  fields[1],
+			Date:    time.Unix(seconds, 0),
+			Message: fields[3],
+		})
+	}
+	return entries, nil
+}
+
+// StashList returns the stash entries, newest first
+func (g *GitRunner) StashList(ctx context.Context) ([]StashEntry, error) {
+	out, err := g.run(ctx, "stash", "list", stashListFormat)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStashList(out)
+}
+
+// StashDiff returns the changes of a stash entry against the commit it was made on
+func (g *GitRunner) StashDiff(ctx context.Context, ref string) ([]diff.FileDiff, error) {
+	return g.Diff(ctx, ref+"^1", ref)
+}
+
+// StashPush runs git stash push; WorktreeOnly stashes are built from a patch
+func (g *GitRunner) StashPush(ctx context.Context, opts StashPushOptions) error {
+	if opts.Hunks != nil && (!opts.WorktreeOnly || len(opts.Paths) != 1) {
+		return errors.New("hunks can only be stashed from the unstaged changes of one file")
+	}
+	if opts.WorktreeOnly {
+		return g.stashWorktree(ctx, opts)
+	}
+
+	args := []string{"stash", "push"}
+	if opts.Message != "" {
+		args = append(args, "-m", opts.Message)
+	}
+	if opts.IncludeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if len(opts.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, opts.Paths...)
+	}
+	_, err := g.stream(ctx, args...)
+	return err
+}
+
+// stashWorktree stashes only the unstaged changes of opts.Paths, which git
+// stash push --keep-index can't; the entry's base commit is the index
+func (g *GitRunner) stashWorktree(ctx context.Context, opts StashPushOptions) error {
+	patch, err := g.RunDiff(ctx, append([]string{"--binary", "--"}, opts.Paths...)...)
+	if err != nil {
+		return err
+	}
+	if patch == "" {
+		return fmt.Errorf("no unstaged changes in %s", strings.Join(opts.Paths, ", "))
+	}
+	if opts.Hunks != nil {
+		if patch = selectHunks(patch, *opts.Hunks); patch == "" {
+			return fmt.Errorf("no unstaged changes in the selected lines of %s", opts.Paths[0])
+		}
+	}
+
+	message := opts.Message
+	if message == "" {
+		message, err = g.wipMessage(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Base and index commits: the current index on top of HEAD
+	indexTree, err := g.trimmed(ctx, execOptions{}, "write-tree")
+	if err != nil {
+		return err
+	}
+	base, err := g.trimmed(ctx, execOptions{}, "commit-tree", indexTree, "-p", "HEAD", "-m", "base of "+message)
+	if err != nil {
+		return err
+	}
+	index, err := g.trimmed(ctx, execOptions{}, "commit-tree", indexTree, "-p", base, "-m", "index on "+message)
+	if err != nil {
+		return err
+	}
+
+	// Worktree commit: the index tree with the patch applied, built in a
+	// scratch index so the real one is left alone
+	dir, err := os.MkdirTemp("", "diff-tui-stash-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	scratch := execOptions{env: []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}}
+
+	if _, err := g.exec(ctx, scratch, "read-tree", indexTree); err != nil {
+		return err
+	}
+	if _, err := g.exec(ctx, execOptions{env: scratch.env, stdin: patch}, "apply", "--cached", "-"); err != nil {
+		return err
+	}
+	worktreeTree, err := g.trimmed(ctx, scratch, "write-tree")
+	if err != nil {
+		return err
+	}
+	worktree, err := g.trimmed(ctx, execOptions{}, "commit-tree", worktreeTree, "-p", base, "-p", index, "-m", message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := g.run(ctx, "stash", "store", "-m", message, worktree); err != nil {
+		return err
+	}
+	_, err = g.exec(ctx, execOptions{stdin: patch}, "apply", "-R", "-")
+	return err
+}
+
+// selectHunks keeps the hunks of a single-file patch that touch the selected
+// lines, or returns "" if there are none
+func selectHunks(patch string, sel HunkSelection) string {
+	lines := strings.SplitAfter(patch, "\n")
+	var header, kept strings.Builder
+	keep, found := false, false
+	for _, line := range lines {
+		if m := hunkHeaderRE.FindStringSubmatch(line); m != nil {
+			start, count := m[1], m[2]
+			if sel.New {
+				start, count = m[3], m[4]
+			}
+			keep = hunkTouches(start, count, sel)
+			found = true
+		}
+		switch {
+		case !found:
+			header.WriteString(line)
+		case keep:
+			kept.WriteString(line)
+		}
+	}
+	if kept.Len() == 0 {
+		return ""
+	}
+	return header.String() + kept.String()
+}
+
+// hunkTouches reports whether a hunk header's range overlaps the selection;
+// the count defaults to 1 when git leaves it out
+func hunkTouches(start, count string, sel HunkSelection) bool {
+	from, _ := strconv.Atoi(start)
+	n := 1
+	if count != "" {
+		n, _ = strconv.Atoi(count)
+	}
+	return n > 0 && from <= sel.End && from+n-1 >= sel.Start
+}
+
+// wipMessage is git's default stash message: WIP on <branch>: <hash> <subject>
+func (g *GitRunner) wipMessage(ctx context.Context) (string, error) {
+	head, err := g.trimmed(ctx, execOptions{}, "log", "-1", "--format=%h %s", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch, err := g.trimmed(ctx, execOptions{}, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if branch == "HEAD" {
+		branch = "(no branch)"
+	}
+	return fmt.Sprintf("WIP on %s: %s", branch, head), nil
+}
+
+// trimmed runs git and returns its output without the trailing newline
+func (g *GitRunner) trimmed(ctx context.Context, opts execOptions, args ...string) (string, error) {
+	out, err := g.exec(ctx, opts, args...)
+	return strings.TrimSpace(out), err
+}
+
+// StashApply applies a stash entry and keeps it
+func (g *GitRunner) StashApply(ctx context.Context, ref string) error {
+	_, err := g.stream(ctx, "stash", "apply", ref)
+	return err
+}
+
+// StashPop applies a stash entry and drops it if that succeeded
+func (g *GitRunner) StashPop(ctx context.Context, ref string) error {
+	_, err := g.stream(ctx, "stash", "pop", ref)
+	return err
+}
+
+// StashDrop deletes a stash entry
+func (g *GitRunner) StashDrop(ctx context.Context, ref string) error {
+	_, err := g.stream(ctx, "stash", "drop", ref)
+	return err
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStashList(t *testing.T) {
+	entries, err := ParseStashList("stash@{0}\x1fabc123\x1f1700000000\x1fWIP on main: 1234567 first\n" +
+		"stash@{1}\x1fdef456\x1f1600000000\x1fOn main: saved\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if e := entries[1]; e.Ref != "stash@{1}" || e.Commit != "def456" || e.Message != "On main: saved" || !e.Date.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("entry = %+v", e)
+	}
+
+	if entries, err := ParseStashList(""); err != nil || len(entries) != 0 {
+		t.Errorf("empty list = %v, %v", entries, err)
+	}
+	if _, err := ParseStashList("stash@{0} only\n"); !errors.Is(err, ErrInvalidStash) {
+		t.Errorf("expected ErrInvalidStash, got %v", err)
+	}
+}
+
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGitRunner_StashPathsAndPop(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	writeTestFile(t, dir, "a.txt", "a changed\n")
+	writeTestFile(t, dir, "b.txt", "b changed\n")
+
+	if err := git.StashPush(ctx, StashPushOptions{Message: "only a", Paths: []string{"a.txt"}}); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "a\n" {
+		t.Errorf("a.txt not reverted: %q", got)
+	}
+	if got := readTestFile(t, dir, "b.txt"); got != "b changed\n" {
+		t.Errorf("b.txt should keep its change: %q", got)
+	}
+
+	entries, err := git.StashList(ctx)
+	if err != nil || len(entries) != 1 || entries[0].Ref != "stash@{0}" || !strings.HasSuffix(entries[0].Message, ": only a") {
+		t.Fatalf("StashList = %+v, %v", entries, err)
+	}
+
+	files, err := git.StashDiff(ctx, "stash@{0}")
+	if err != nil || len(files) != 1 || files[0].Name != "a.txt" {
+		t.Fatalf("StashDiff = %+v, %v", files, err)
+	}
+
+	if err := git.StashPop(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashPop failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "a changed\n" {
+		t.Errorf("a.txt not restored: %q", got)
+	}
+	if entries, _ := git.StashList(ctx); len(entries) != 0 {
+		t.Errorf("expected pop to drop the entry, got %+v", entries)
+	}
+}
+
+func TestGitRunner_StashWorktreeOnly(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	// a.txt has a staged change and an unstaged one on top
+	writeTestFile(t, dir, "a.txt", "a staged\n")
+	if err := git.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "a.txt", "a staged\nand unstaged\n")
+
+	if err := git.StashPush(ctx, StashPushOptions{Paths: []string{"a.txt"}, WorktreeOnly: true}); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "a staged\n" {
+		t.Errorf("worktree should be back at the index version: %q", got)
+	}
+	staged, err := git.Diff(ctx, "--cached")
+	if err != nil || len(staged) != 1 {
+		t.Fatalf("staged change lost: %+v, %v", staged, err)
+	}
+
+	// The entry holds only the unstaged part
+	files, err := git.StashDiff(ctx, "stash@{0}")
+	if err != nil || len(files) != 1 || files[0].AddCount != 1 || files[0].DelCount != 0 {
+		t.Fatalf("StashDiff = %+v, %v", files, err)
+	}
+
+	if err := git.StashApply(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashApply failed: %v", err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "a staged\nand unstaged\n" {
+		t.Errorf("apply should restore the unstaged change: %q", got)
+	}
+
+	if err := git.StashDrop(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashDrop failed: %v", err)
+	}
+	if entries, _ := git.StashList(ctx); len(entries) != 0 {
+		t.Errorf("expected no entries after drop, got %+v", entries)
+	}
+}
+
+func TestGitRunner_StashHunks(t *testing.T) {
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+
+	var original strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&original, "line %d\n", i)
+	}
+	writeTestFile(t, dir, "h.txt", original.String())
+	if err := git.StageFile(ctx, "h.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git.Commit(ctx, CommitOptions{Message: "h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two hunks far enough apart to stay separate
+	changed := strings.Replace(original.String(), "line 2\n", "line two\n", 1)
+	changed = strings.Replace(changed, "line 18\n", "line eighteen\n", 1)
+	writeTestFile(t, dir, "h.txt", changed)
+
+	opts := StashPushOptions{Paths: []string{"h.txt"}, WorktreeOnly: true, Hunks: &HunkSelection{New: true, Start: 18, End: 18}}
+	if err := git.StashPush(ctx, opts); err != nil {
+		t.Fatalf("StashPush failed: %v", err)
+	}
+	want := strings.Replace(original.String(), "line 2\n", "line two\n", 1)
+	if got := readTestFile(t, dir, "h.txt"); got != want {
+		t.Errorf("only the second hunk should be reverted:\n%s", got)
+	}
+
+	files, err := git.StashDiff(ctx, "stash@{0}")
+	if err != nil || len(files) != 1 || files[0].AddCount != 1 || files[0].DelCount != 1 {
+		t.Fatalf("StashDiff = %+v, %v", files, err)
+	}
+
+	// git only pops onto a clean file, and the entry holds just its hunk
+	writeTestFile(t, dir, "h.txt", original.String())
+	if err := git.StashPop(ctx, "stash@{0}"); err != nil {
+		t.Fatalf("StashPop failed: %v", err)
+	}
+	want = strings.Replace(original.String(), "line 18\n", "line eighteen\n", 1)
+	if got := readTestFile(t, dir, "h.txt"); got != want {
+		t.Errorf("pop should bring back only the stashed hunk:\n%s", got)
+	}
+
+	opts.Hunks = &HunkSelection{Start: 9, End: 11}
+	if err := git.StashPush(ctx, opts); err == nil {
+		t.Error("expected an error for lines without changes")
+	}
+}
+
+func TestSelectHunks(t *testing.T) {
+	patch := "diff --git a/f b/f\n--- a/f\n+++ b/f\n" +
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -10 +10,0 @@\n-j\n"
+	if got := selectHunks(patch, HunkSelection{Start: 10, End: 10}); got != "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -10 +10,0 @@\n-j\n" {
+		t.Errorf("old side selection = %q", got)
+	}
+	if got := selectHunks(patch, HunkSelection{New: true, Start: 10, End: 12}); got != "" {
+		t.Errorf("a pure deletion has no new lines to select, got %q", got)
+	}
+}
@@ -0,0 +1,329 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// StatusCode is one column of git's two-letter XY status
+type StatusCode byte
+
+const (
+	StatusUnmodified  StatusCode = '.'
+	StatusModified    StatusCode = 'M'
+	StatusTypeChanged StatusCode = 'T'
+	StatusAdded       StatusCode = 'A'
+	StatusDeleted     StatusCode = 'D'
+	StatusRenamed     StatusCode = 'R'
+	StatusCopied      StatusCode = 'C'
+	StatusUnmerged    StatusCode = 'U'
+	StatusUntracked   StatusCode = '?'
+	StatusIgnored     StatusCode = '!'
+)
+
+// EntryKind is the kind of a porcelain v2 status line
+type EntryKind int
+
+const (
+	EntryOrdinary  EntryKind = iota // "1": changed tracked entry
+	EntryRenamed                    // "2": renamed or copied entry
+	EntryUnmerged                   // "u": unmerged (conflicted) entry
+	EntryUntracked                  // "?": untracked file
+	EntryIgnored                    // "!": ignored file
+)
+
+// FileStatus is the status of a single path as reported by git status
+type FileStatus struct {
+	Path     string
+	OrigPath string // Source path of a rename or copy
+	Kind     EntryKind
+
+	Index    StatusCode // X: HEAD vs index
+	Worktree StatusCode // Y: index vs worktree
+
+	Submodule string // "N..." for non-submodules, "S<c><m><u>" otherwise
+	Score     string // Rename/copy score such as "R100"
+
+	HeadMode     string
+	IndexMode    string
+	WorktreeMode string
+	HeadHash     string
+	IndexHash    string
+
+	// Stages holds the object names of an unmerged entry for stage 1 (base),
+	// 2 (ours) and 3 (theirs); an all-zero name means the stage is absent
+	Stages [3]string
+}
+
+// XY returns the two-letter status code
+func (f FileStatus) XY() string {
+	return string([]byte{byte(f.Index), byte(f.Worktree)})
+}
+
+// IsStaged reports whether the index differs from HEAD for this path
+func (f FileStatus) IsStaged() bool {
+	switch f.Kind {
+	case EntryOrdinary, EntryRenamed:
+		return f.Index != StatusUnmodified
+	}
+	return false
+}
+
+// HasUnstagedChanges reports whether the worktree differs from the index
+func (f FileStatus) HasUnstagedChanges() bool {
+	switch f.Kind {
+	case EntryOrdinary, EntryRenamed:
+		return f.Worktree != StatusUnmodified
+	case EntryUntracked:
+		return true
+	}
+	return false
+}
+
+// IsPartiallyStaged reports whether the path has both staged and unstaged changes
+func (f FileStatus) IsPartiallyStaged() bool {
+	return f.IsStaged() && f.HasUnstagedChanges()
+}
+
+// IsUntracked reports whether the path is not tracked by git
+func (f FileStatus) IsUntracked() bool {
+	return f.Kind == EntryUntracked
+}
+
+// IsConflicted reports whether the path is unmerged
+func (f FileStatus) IsConflicted() bool {
+	return f.Kind == EntryUnmerged
+}
+
+// HasStage reports whether an unmerged entry has the given stage (1, 2 or 3)
+func (f FileStatus) HasStage(stage int) bool {
+	if stage < 1 || stage > 3 {
+		return false
+	}
+	name := f.Stages[stage-1]
+	return name != "" && strings.Trim(name, "0") != ""
+}
+
+// ConflictDescription describes an unmerged entry the way git status does
+func (f FileStatus) ConflictDescription() string {
+	switch f.XY() {
+	case "DD":
+		return "both deleted"
+	case "AU":
+		return "added by us"
+	case "UD":
+		return "deleted by them"
+	case "UA":
+		return "added by them"
+	case "DU":
+		return "deleted by us"
+	case "AA":
+		return "both added"
+	case "UU":
+		return "both modified"
+	}
+	return ""
+}
+
+// BranchStatus holds the "# branch.*" headers
+type BranchStatus struct {
+	OID      string // Commit hash, "(initial)" on an unborn branch
+	Head     string // Branch name, "(detached)" when detached
+	Upstream string
+	Ahead    int
+	Behind   int
+}
+
+// Status is the parsed result of git status --porcelain=v2
+type Status struct {
+	Branch  BranchStatus
+	Entries []FileStatus
+	byPath  map[string]int
+}
+
+// Get returns the status of path
+func (s *Status) Get(path string) (FileStatus, bool) {
+	if s == nil {
+		return FileStatus{}, false
+	}
+	i, ok := s.byPath[path]
+	if !ok {
+		return FileStatus{}, false
+	}
+	return s.Entries[i], true
+}
+
+// HasStaged reports whether any path has staged changes
+func (s *Status) HasStaged() bool {
+	if s == nil {
+		return false
+	}
+	for _, e := range s.Entries {
+		if e.IsStaged() {
+			return true
+		}
+	}
+	return false
+}
+
+// Conflicts returns all unmerged entries
+func (s *Status) Conflicts() []FileStatus {
+	if s == nil {
+		return nil
+	}
+	var result []FileStatus
+	for _, e := range s.Entries {
+		if e.IsConflicted() {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Status runs git status --porcelain=v2 with paths relative to the repository
+// root, like those of diffs
+func (g *GitRunner) Status(ctx context.Context) (*Status, error) {
+	output, err := g.run(ctx, "-c", "status.relativePaths=false", "status", "--porcelain=v2", "-z", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return ParseStatus(output)
+}
+
+// ParseStatus parses NUL-separated git status --porcelain=v2 -z output
+func ParseStatus(input string) (*Status, error) {
+	s := &Status{byPath: make(map[string]int)}
+
+	records := strings.Split(input, "\x00")
+	for i := 0; i < len(records); i++ {
+		rec := records[i]
+		if rec == "" {
+			continue
+		}
+
+		var entry FileStatus
+		switch rec[0] {
+		case '#':
+			parseBranchHeader(&s.Branch, rec)
+			continue
+
+		case '1':
+			f := strings.SplitN(rec, " ", 9)
+			if len(f) != 9 {
+				return nil, statusError(rec)
+			}
+			entry = FileStatus{
+				Kind:         EntryOrdinary,
+				Submodule:    f[2],
+				HeadMode:     f[3],
+				IndexMode:    f[4],
+				WorktreeMode: f[5],
+				HeadHash:     f[6],
+				IndexHash:    f[7],
+				Path:         f[8],
+			}
+			if err := setXY(&entry, f[1]); err != nil {
+				return nil, err
+			}
+
+		case '2':
+			f := strings.SplitN(rec, " ", 10)
+			if len(f) != 10 || i+1 >= len(records) {
+				return nil, statusError(rec)
+			}
+			entry = FileStatus{
+				Kind:         EntryRenamed,
+				Submodule:    f[2],
+				HeadMode:     f[3],
+				IndexMode:    f[4],
+				WorktreeMode: f[5],
+				HeadHash:     f[6],
+				IndexHash:    f[7],
+				Score:        f[8],
+				Path:         f[9],
+			}
+			if err := setXY(&entry, f[1]); err != nil {
+				return nil, err
+			}
+			// With -z the original path is the next NUL-separated record
+			i++
+			entry.OrigPath = records[i]
+
+		case 'u':
+			f := strings.SplitN(rec, " ", 11)
+			if len(f) != 11 {
+				return nil, statusError(rec)
+			}
+			entry = FileStatus{
+				Kind:         EntryUnmerged,
+				Submodule:    f[2],
+				WorktreeMode: f[6],
+				Stages:       [3]string{f[7], f[8], f[9]},
+				Path:         f[10],
+			}
+			if err := setXY(&entry, f[1]); err != nil {
+				return nil, err
+			}
+
+		case '?':
+			entry = FileStatus{
+				Kind:     EntryUntracked,
+				Index:    StatusUntracked,
+				Worktree: StatusUntracked,
+				Path:     strings.TrimPrefix(rec, "? "),
+			}
+
+		case '!':
+			entry = FileStatus{
+				Kind:     EntryIgnored,
+				Index:    StatusIgnored,
+				Worktree: StatusIgnored,
+				Path:     strings.TrimPrefix(rec, "! "),
+			}
+
+		default:
+			return nil, statusError(rec)
+		}
+
+		s.byPath[entry.Path] = len(s.Entries)
+		s.Entries = append(s.Entries, entry)
+	}
+
+	return s, nil
+}
+
+// parseBranchHeader fills b from a "# branch.<key> <value>" header
+func parseBranchHeader(b *BranchStatus, rec string) {
+	rest, ok := strings.CutPrefix(rec, "# branch.")
+	if !ok {
+		return
+	}
+	key, value, _ := strings.Cut(rest, " ")
+
+	switch key {
+	case "oid":
+		b.OID = value
+	case "head":
+		b.Head = value
+	case "upstream":
+		b.Upstream = value
+	case "ab":
+		ahead, behind, _ := strings.Cut(value, " ")
+		b.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+		b.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+	}
+}
+
+func setXY(entry *FileStatus, xy string) error {
+	if len(xy) != 2 {
+		return &ParseError{Message: "invalid status code " + strconv.Quote(xy), Cause: ErrInvalidStatus}
+	}
+	entry.Index = StatusCode(xy[0])
+	entry.Worktree = StatusCode(xy[1])
+	return nil
+}
+
+func statusError(rec string) error {
+	return &ParseError{Message: "invalid status entry " + strconv.Quote(rec), Cause: ErrInvalidStatus}
+}
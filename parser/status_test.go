@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	input := strings.Join([]string{
+		"# branch.oid 0046dcf4af207c9be6b6b6b11c5a189efa717f1f",
+		"# branch.head main",
+		"# branch.upstream origin/main",
+		"# branch.ab +2 -1",
+		"1 MM N... 100644 100644 100644 1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 src/partial.go",
+		"1 .M N... 100644 100644 100644 3333333333333333333333333333333333333333 3333333333333333333333333333333333333333 docs/read me.md",
+		"1 .T N... 100644 100644 120000 4444444444444444444444444444444444444444 4444444444444444444444444444444444444444 link",
+		"2 R. N... 100644 100644 100644 5555555555555555555555555555555555555555 5555555555555555555555555555555555555555 R100 new/name.go",
+		"old/name.go",
+		"u UU N... 100644 100644 100644 100644 6666666666666666666666666666666666666666 7777777777777777777777777777777777777777 8888888888888888888888888888888888888888 conflict.go",
+		"u AU N... 000000 100644 000000 100644 0000000000000000000000000000000000000000 9999999999999999999999999999999999999999 0000000000000000000000000000000000000000 ours-only.go",
+		"? untracked file.txt",
+		"",
+	}, "\x00")
+
+	status, err := ParseStatus(input)
+	if err != nil {
+		t.Fatalf("ParseStatus failed: %v", err)
+	}
+
+	b := status.Branch
+	if b.Head != "main" || b.Upstream != "origin/main" || b.Ahead != 2 || b.Behind != 1 {
+		t.Errorf("unexpected branch status: %+v", b)
+	}
+
+	if len(status.Entries) != 7 {
+		t.Fatalf("expected 7 entries, got %d", len(status.Entries))
+	}
+
+	partial, ok := status.Get("src/partial.go")
+	if !ok {
+		t.Fatal("expected src/partial.go in status")
+	}
+	if partial.XY() != "MM" || !partial.IsPartiallyStaged() {
+		t.Errorf("expected partially staged MM entry, got %s", partial.XY())
+	}
+
+	spaced, ok := status.Get("docs/read me.md")
+	if !ok {
+		t.Fatal("expected path with space in status")
+	}
+	if spaced.IsStaged() || !spaced.HasUnstagedChanges() {
+		t.Error("expected unstaged-only entry")
+	}
+
+	link, _ := status.Get("link")
+	if link.Worktree != StatusTypeChanged {
+		t.Errorf("expected type change in worktree, got %c", link.Worktree)
+	}
+
+	renamed, ok := status.Get("new/name.go")
+	if !ok {
+		t.Fatal("expected renamed entry")
+	}
+	if renamed.Kind != EntryRenamed || renamed.OrigPath != "old/name.go" || renamed.Score != "R100" {
+		t.Errorf("unexpected rename entry: %+v", renamed)
+	}
+	if !renamed.IsStaged() || renamed.HasUnstagedChanges() {
+		t.Error("expected staged-only rename")
+	}
+
+	conflict, _ := status.Get("conflict.go")
+	if !conflict.IsConflicted() || conflict.ConflictDescription() != "both modified" {
+		t.Errorf("expected both-modified conflict, got %q", conflict.ConflictDescription())
+	}
+	for stage := 1; stage <= 3; stage++ {
+		if !conflict.HasStage(stage) {
+			t.Errorf("expected stage %d to be present", stage)
+		}
+	}
+
+	oursOnly, _ := status.Get("ours-only.go")
+	if oursOnly.HasStage(1) || !oursOnly.HasStage(2) || oursOnly.HasStage(3) {
+		t.Errorf("expected only stage 2, got %v", oursOnly.Stages)
+	}
+
+	untracked, _ := status.Get("untracked file.txt")
+	if !untracked.IsUntracked() || untracked.XY() != "??" {
+		t.Errorf("expected untracked entry, got %s", untracked.XY())
+	}
+
+	if !status.HasStaged() {
+		t.Error("expected HasStaged to be true")
+	}
+	if len(status.Conflicts()) != 2 {
+		t.Errorf("expected 2 conflicts, got %d", len(status.Conflicts()))
+	}
+}
+
+func TestParseStatus_Empty(t *testing.T) {
+	status, err := ParseStatus("# branch.oid (initial)\x00# branch.head main\x00")
+	if err != nil {
+		t.Fatalf("ParseStatus failed: %v", err)
+	}
+	if len(status.Entries) != 0 || status.HasStaged() {
+		t.Error("expected no entries")
+	}
+	if status.Branch.OID != "(initial)" {
+		t.Errorf("expected initial oid, got %q", status.Branch.OID)
+	}
+}
+
+func TestParseStatus_Invalid(t *testing.T) {
+	_, err := ParseStatus("1 M N... truncated\x00")
+	if !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("expected ErrInvalidStatus, got %v", err)
+	}
+}
+
+func TestGitRunner_StatusFromSubdirectory(t *testing.T) {
+	_, dir := newTestRepo(t)
+	ctx := context.Background()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "sub/c.txt", "c\n")
+	writeTestFile(t, dir, "b.txt", "changed\n")
+
+	// Paths are relative to the root, like those of diffs
+	status, err := NewGitRunner("", filepath.Join(dir, "sub")).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := status.Get("b.txt"); !ok || st.Worktree != StatusModified {
+		t.Errorf("b.txt = %+v, %v", st, ok)
+	}
+	if st, ok := status.Get("sub/c.txt"); !ok || !st.IsUntracked() {
+		t.Errorf("sub/c.txt = %+v, %v", st, ok)
+	}
+}
+
+// newTestRepo creates a repository with a.txt and b.txt committed
+func newTestRepo(t *testing.T) (*GitRunner, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := NewGitRunner("", dir)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, dir, "a.txt", "a\n")
+	writeTestFile(t, dir, "b.txt", "b\n")
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "init"}} {
+		if _, err := git.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return git, dir
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithoutOptionalLocks(t *testing.T) {
+	git, dir := newTestRepo(t)
+	index := filepath.Join(dir, ".git", "index")
+
+	// A new mtime makes git status want to refresh the index
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "a.txt"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(index)
+
+	ctx := WithoutOptionalLocks(context.Background())
+	if _, err := git.run(ctx, "status", "--porcelain"); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(index); !bytes.Equal(before, after) {
+		t.Error("expected the index to be left alone")
+	}
+
+	if _, err := git.run(context.Background(), "status", "--porcelain"); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(index); bytes.Equal(before, after) {
+		t.Error("expected a plain git status to refresh the index")
+	}
+}
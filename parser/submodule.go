@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// gitlinkMode is the file mode git records for a submodule
+const gitlinkMode = "160000"
+
+var (
+	// submoduleHeaderRE matches the header --submodule=log and
+	// --submodule=diff print instead of a gitlink diff, such as
+	// "Submodule lib 58a0494..4cd1bd4:" or "Submodule lib 0000000...58a0494 (new submodule)"
+	submoduleHeaderRE = regexp.MustCompile(`^Submodule (.+?) ([0-9a-f]+)\.\.\.?([0-9a-f]+)(?: \(.*\))?:?$`)
+
+	// submoduleDirtyRE matches "Submodule lib contains modified content"
+	submoduleDirtyRE = regexp.MustCompile(`^Submodule (.+) contains (?:modified|untracked) content$`)
+
+	// subprojectRE matches the content of a gitlink diff's hunk line
+	subprojectRE = regexp.MustCompile(`^Subproject commit ([0-9a-f]+)(-dirty)?$`)
+)
+
+// isNullCommit reports whether a commit name is all zeros, git's way of
+// writing "none"
+func isNullCommit(commit string) bool {
+	return strings.Trim(commit, "0") == ""
+}
+
+// markGitlink marks fd as a submodule change if mode is the gitlink mode
+func markGitlink(fd *diff.FileDiff, mode string) {
+	if mode == gitlinkMode && fd.Submodule == nil {
+		fd.Submodule = &diff.Submodule{}
+	}
+}
+
+// parseSubmoduleHeader parses a submodule's header lines of --submodule=log
+// or --submodule=diff output, and the commit list that follows them, into
+// the submodule's entry in files
+func parseSubmoduleHeader(files []diff.FileDiff, lines []string, pos int) ([]diff.FileDiff, int) {
+	var path string
+	var update func(sub *diff.Submodule)
+	if m := submoduleHeaderRE.FindStringSubmatch(lines[pos]); m != nil {
+		path = m[1]
+		update = func(sub *diff.Submodule) {
+			sub.OldCommit, sub.NewCommit = m[2], m[3]
+			if isNullCommit(sub.OldCommit) {
+				sub.OldCommit = ""
+			}
+			if isNullCommit(sub.NewCommit) {
+				sub.NewCommit = ""
+			}
+		}
+	} else if m := submoduleDirtyRE.FindStringSubmatch(lines[pos]); m != nil {
+		path = m[1]
+		update = func(sub *diff.Submodule) { sub.Dirty = true }
+	} else {
+		return files, pos + 1
+	}
+
+	i := submoduleIndex(files, path)
+	if i < 0 {
+		files = append(files, diff.FileDiff{Name: path, OldPath: path, NewPath: path, Submodule: &diff.Submodule{}})
+		i = len(files) - 1
+	}
+	sub := files[i].Submodule
+	update(sub)
+	pos++
+	for ; pos < len(lines); pos++ {
+		c, ok := parseSubmoduleCommit(lines[pos])
+		if !ok {
+			break
+		}
+		sub.Commits = append(sub.Commits, c)
+	}
+
+	// A new or deleted submodule has a null commit on the other side
+	files[i].IsNew = sub.OldCommit == "" && sub.NewCommit != ""
+	files[i].IsDeleted = sub.NewCommit == "" && sub.OldCommit != ""
+	setSubmoduleLines(&files[i])
+	return files, pos
+}
+
+// parseSubprojectLine records the commit of a "Subproject commit" hunk line
+func parseSubprojectLine(sub *diff.Submodule, line diff.Line) {
+	m := subprojectRE.FindStringSubmatch(line.Content)
+	switch {
+	case m == nil:
+	case line.Type == diff.Delete:
+		sub.OldCommit = m[1]
+	case line.Type == diff.Add:
+		sub.NewCommit = m[1]
+		sub.Dirty = m[2] != ""
+	}
+}
+
+// parseSubmoduleCommit parses a commit line of --submodule=log output, "  > subject"
+// or "  < subject"
+func parseSubmoduleCommit(line string) (diff.SubmoduleCommit, bool) {
+	if len(line) < 4 || line[:2] != "  " || line[3] != ' ' || (line[2] != '>' && line[2] != '<') {
+		return diff.SubmoduleCommit{}, false
+	}
+	return diff.SubmoduleCommit{Subject: line[4:], Removed: line[2] == '<'}, true
+}
+
+// submoduleIndex returns the index of the submodule change for path in files,
+// or -1 if there is none
+func submoduleIndex(files []diff.FileDiff, path string) int {
+	for i := range files {
+		if files[i].Submodule != nil && files[i].Name == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// submoduleOwner returns the index of the submodule change whose submodule
+// contains path, or -1 if there is none
+func submoduleOwner(files []diff.FileDiff, path string) int {
+	for i := range files {
+		if files[i].Submodule != nil && !files[i].IsDeleted && strings.HasPrefix(path, files[i].Name+"/") {
+			return i
+		}
+	}
+	return -1
+}
+
+// setSubmoduleLines lays out a submodule change the way git shows a gitlink
+// diff, "Subproject commit" before and after, followed by its commits
+func setSubmoduleLines(fd *diff.FileDiff) {
+	sub := fd.Submodule
+	fd.LeftLines, fd.RightLines, fd.AddCount, fd.DelCount = nil, nil, 0, 0
+
+	left := diff.Line{Type: diff.Placeholder}
+	if sub.OldCommit != "" {
+		left = diff.Line{Type: diff.Delete, Content: "Subproject commit " + sub.OldCommit}
+		fd.DelCount++
+	}
+	right := diff.Line{Type: diff.Placeholder}
+	if sub.NewCommit != "" {
+		content := "Subproject commit " + sub.NewCommit
+		if sub.Dirty {
+			content += "-dirty"
+		}
+		right = diff.Line{Type: diff.Add, Content: content}
+		fd.AddCount++
+	}
+	fd.LeftLines = append(fd.LeftLines, left)
+	fd.RightLines = append(fd.RightLines, right)
+
+	for _, c := range sub.Commits {
+		if c.Removed {
+			fd.LeftLines = append(fd.LeftLines, diff.Line{Type: diff.Delete, Content: "< " + c.Subject})
+			fd.RightLines = append(fd.RightLines, diff.Line{Type: diff.Placeholder})
+		} else {
+			fd.LeftLines = append(fd.LeftLines, diff.Line{Type: diff.Placeholder})
+			fd.RightLines = append(fd.RightLines, diff.Line{Type: diff.Add, Content: "> " + c.Subject})
+		}
+	}
+}
+
+// at returns a runner for the repository in dir that shares g's command log
+func (g *GitRunner) at(dir string) *GitRunner {
+	return &GitRunner{gitPath: g.gitPath, workDir: dir, log: g.log}
+}
+
+// expandSubmodules fills in the commits and changes of submodule changes
+// that came without them, running git inside each checked-out submodule
+func (g *GitRunner) expandSubmodules(ctx context.Context, files []diff.FileDiff) error {
+	var root string
+	for i := range files {
+		sub := files[i].Submodule
+		if sub == nil || sub.Commits != nil || sub.Files != nil {
+			continue
+		}
+		if root == "" {
+			var err error
+			if root, err = g.FindGitRoot(ctx); err != nil {
+				return err
+			}
+		}
+		if err := g.expandSubmodule(ctx, root, &files[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandSubmodule loads and diffs the commits between a submodule's old and
+// new commit, if it is checked out and has them and neither is missing
+func (g *GitRunner) expandSubmodule(ctx context.Context, root string, fd *diff.FileDiff) error {
+	sub := fd.Submodule
+	dir := filepath.Join(root, filepath.FromSlash(fd.Name))
+	if sub.OldCommit == "" || sub.NewCommit == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil
+	}
+	subGit := g.at(dir)
+
+	var commits []diff.SubmoduleCommit
+	if sub.OldCommit != sub.NewCommit {
+		out, err := subGit.run(ctx, "log", "--left-right", "--format=%m%s", sub.OldCommit+"..."+sub.NewCommit, "--")
+		if err != nil {
+			return ignoreGitError(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if line != "" {
+				commits = append(commits, diff.SubmoduleCommit{Subject: line[1:], Removed: line[0] == '<'})
+			}
+		}
+	}
+
+	// A dirty submodule is diffed against its worktree
+	args := []string{sub.OldCommit}
+	if !sub.Dirty {
+		args = append(args, sub.NewCommit)
+	}
+	files, err := subGit.Diff(ctx, args...)
+	if err != nil {
+		return ignoreGitError(err)
+	}
+	for i := range files {
+		files[i].Name = fd.Name + "/" + files[i].Name
+		files[i].OldPath = fd.Name + "/" + files[i].OldPath
+		files[i].NewPath = fd.Name + "/" + files[i].NewPath
+	}
+
+	sub.Commits, sub.Files = commits, files
+	setSubmoduleLines(fd)
+	return nil
+}
+
+// ignoreGitError drops git's own failures, such as missing commits, and keeps
+// the rest, such as cancellation
+func ignoreGitError(err error) error {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && !errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"diff-tui/diff"
+)
+
+func TestParseString_Submodules(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    diff.Submodule
+		files   int // Files of the submodule itself
+		commits int
+	}{
+		{
+			name: "gitlink diff",
+			input: "diff --git a/lib b/lib\nindex 58a0494..4cd1bd4 160000\n--- a/lib\n+++ b/lib\n@@ -1 +1 @@\n" +
+				"-Subproject commit 58a0494707e14739ff12b2504c548d13911d7e59\n+Subproject commit 4cd1bd4fde199ce4271f6d8caa6035db7f790834-dirty\n",
+			want: diff.Submodule{OldCommit: "58a0494707e14739ff12b2504c548d13911d7e59", NewCommit: "4cd1bd4fde199ce4271f6d8caa6035db7f790834", Dirty: true},
+		},
+		{
+			name:    "log",
+			input:   "Submodule lib contains modified content\nSubmodule lib 58a0494..4cd1bd4:\n  > Second\n  > Third\n",
+			want:    diff.Submodule{OldCommit: "58a0494", NewCommit: "4cd1bd4", Dirty: true},
+			commits: 2,
+		},
+		{
+			name: "diff",
+			input: "Submodule lib 58a0494..4cd1bd4:\ndiff --git a/lib/x.txt b/lib/x.txt\nindex 587be6b..04ec35a 100644\n--- a/lib/x.txt\n+++ b/lib/x.txt\n@@ -1 +1,2 @@\n x\n+y\n" +
+				"diff --git a/top.txt b/top.txt\nindex 587be6b..04ec35a 100644\n--- a/top.txt\n+++ b/top.txt\n@@ -1 +1 @@\n-a\n+b\n",
+			want:  diff.Submodule{OldCommit: "58a0494", NewCommit: "4cd1bd4"},
+			files: 1,
+		},
+		{
+			name:  "new submodule",
+			input: "Submodule lib 0000000...58a0494 (new submodule)\n",
+			want:  diff.Submodule{NewCommit: "58a0494"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub := result.Files[0].Submodule
+			if result.Files[0].Name != "lib" || sub == nil {
+				t.Fatalf("first file = %+v", result.Files[0])
+			}
+			if sub.OldCommit != tt.want.OldCommit || sub.NewCommit != tt.want.NewCommit || sub.Dirty != tt.want.Dirty {
+				t.Errorf("submodule = %+v, want %+v", sub, tt.want)
+			}
+			if len(sub.Files) != tt.files || len(sub.Commits) != tt.commits {
+				t.Errorf("%d files and %d commits", len(sub.Files), len(sub.Commits))
+			}
+			// The commit change and then one row per commit
+			if len(result.Files[0].RightLines) != 1+tt.commits {
+				t.Errorf("%d rows", len(result.Files[0].RightLines))
+			}
+		})
+	}
+}
+
+func TestGitRunner_DiffExpandsSubmodules(t *testing.T) {
+	_, libDir := newTestRepo(t)
+	git, dir := newTestRepo(t)
+	ctx := context.Background()
+	mustRun := func(g *GitRunner, args ...string) {
+		t.Helper()
+		if _, err := g.run(ctx, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustRun(git, "-c", "protocol.file.allow=always", "submodule", "--quiet", "add", libDir, "lib")
+	mustRun(git, "commit", "-q", "-m", "Add lib")
+
+	// Move the submodule on by a commit, then change its worktree
+	sub := git.at(filepath.Join(dir, "lib"))
+	writeTestFile(t, filepath.Join(dir, "lib"), "a.txt", "a\nmore\n")
+	mustRun(sub, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-am", "More a")
+	writeTestFile(t, filepath.Join(dir, "lib"), "b.txt", "b changed\n")
+
+	files, err := git.Diff(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Submodule == nil {
+		t.Fatalf("files = %+v", files)
+	}
+	s := files[0].Submodule
+	if !s.Dirty || len(s.Commits) != 1 || s.Commits[0].Subject != "More a" {
+		t.Errorf("submodule = %+v", s)
+	}
+	// The dirty submodule's files are diffed against its worktree
+	if len(s.Files) != 2 || s.Files[0].Name != "lib/a.txt" || s.Files[1].Name != "lib/b.txt" {
+		t.Errorf("submodule files = %+v", s.Files)
+	}
+}
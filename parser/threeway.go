@@ -0,0 +1,56 @@
+package parser
+
+import "diff-tui/diff"
+
+// ThreeWayFile is the HEAD, index and worktree versions of a file aligned row
+// by row, typed by the staged and unstaged diffs
+type ThreeWayFile struct {
+	Name     string
+	IsBinary bool
+	Head     []diff.Line
+	Index    []diff.Line
+	Worktree []diff.Line
+}
+
+// AlignThree aligns the staged and unstaged diffs of a whole file and joins
+// their rows on the index lines; nil content is a missing version
+func AlignThree(name string, head, index, worktree []byte) ThreeWayFile {
+	file := ThreeWayFile{Name: name}
+	if isBinary(head) || isBinary(index) || isBinary(worktree) {
+		file.IsBinary = true
+		return file
+	}
+
+	headLines, indexLines, worktreeLines := splitLines(head), splitLines(index), splitLines(worktree)
+	stagedHead, stagedIndex, _, _ := alignHunk(editHunk(headLines, indexLines, diff.LineEdits(headLines, indexLines)))
+	unstagedIndex, unstagedWorktree, _, _ := alignHunk(editHunk(indexLines, worktreeLines, diff.LineEdits(indexLines, worktreeLines)))
+
+	placeholder := diff.Line{Type: diff.Placeholder}
+	row := func(h, i, w diff.Line) {
+		file.Head = append(file.Head, h)
+		file.Index = append(file.Index, i)
+		file.Worktree = append(file.Worktree, w)
+	}
+
+	s, u := 0, 0
+	for s < len(stagedIndex) || u < len(unstagedIndex) {
+		switch {
+		case s < len(stagedIndex) && stagedIndex[s].Type == diff.Placeholder:
+			// Removed from the index
+			row(stagedHead[s], placeholder, placeholder)
+			s++
+		case u < len(unstagedIndex) && unstagedIndex[u].Type == diff.Placeholder:
+			// Only in the worktree
+			row(placeholder, placeholder, unstagedWorktree[u])
+			u++
+		case s < len(stagedIndex) && u < len(unstagedIndex):
+			// The same index line on both sides
+			row(stagedHead[s], stagedIndex[s], unstagedWorktree[u])
+			s, u = s+1, u+1
+		default:
+			// Unreachable while both diffs cover the same index lines
+			return file
+		}
+	}
+	return file
+}
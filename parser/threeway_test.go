@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+)
+
+func TestAlignThree(t *testing.T) {
+	head := []byte("gone\na\nb\nc\nd\n")
+	index := []byte("a\nB\nc\nd\n")
+	worktree := []byte("a\nB\nc\nD\nextra\n")
+
+	file := AlignThree("f.txt", head, index, worktree)
+	if len(file.Head) != len(file.Index) || len(file.Index) != len(file.Worktree) {
+		t.Fatalf("columns not aligned: %d/%d/%d", len(file.Head), len(file.Index), len(file.Worktree))
+	}
+
+	// Each row as head | index | worktree, with - for a delete, + for an
+	// add and . for a placeholder
+	cell := func(l diff.Line) string {
+		switch l.Type {
+		case diff.Placeholder:
+			return "."
+		case diff.Delete:
+			return "-" + l.Content
+		case diff.Add:
+			return "+" + l.Content
+		}
+		return l.Content
+	}
+	var rows []string
+	for i := range file.Head {
+		rows = append(rows, fmt.Sprintf("%s|%s|%s", cell(file.Head[i]), cell(file.Index[i]), cell(file.Worktree[i])))
+	}
+	want := []string{
+		"-gone|.|.",
+		"a|a|a",
+		"-b|+B|B",
+		"c|c|c",
+		"d|d|+D",
+		".|.|+extra",
+	}
+	if got := strings.Join(rows, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("rows:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// Line numbers are each version's own
+	if file.Head[2].Number != 3 || file.Index[2].Number != 2 || file.Worktree[5].Number != 5 {
+		t.Errorf("numbers %d/%d/%d", file.Head[2].Number, file.Index[2].Number, file.Worktree[5].Number)
+	}
+
+	// An untracked file exists only in the worktree
+	untracked := AlignThree("new.txt", nil, nil, []byte("x\n"))
+	if len(untracked.Worktree) != 1 || untracked.Worktree[0].Type != diff.Add || untracked.Head[0].Type != diff.Placeholder {
+		t.Errorf("untracked = %+v", untracked)
+	}
+}
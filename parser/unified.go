@@ -45,6 +45,12 @@ func parseUnified(input string) ([]diff.FileDiff, error) {
 			continue
 		}
 
+		// --submodule=log and --submodule=diff describe submodules in headers
+		if strings.HasPrefix(lines[pos], "Submodule ") {
+			files, pos = parseSubmoduleHeader(files, lines, pos)
+			continue
+		}
+
 		// Look for diff --git header
 		if !strings.HasPrefix(lines[pos], "diff --git ") {
 			pos++
@@ -56,7 +62,12 @@ func parseUnified(input string) ([]diff.FileDiff, error) {
 			return nil, err
 		}
 		if fd != nil {
-			files = append(files, *fd)
+			// --submodule=diff lists the submodule's own changes after its header
+			if owner := submoduleOwner(files, fd.Name); owner >= 0 {
+				files[owner].Submodule.Files = append(files[owner].Submodule.Files, *fd)
+			} else {
+				files = append(files, *fd)
+			}
 		}
 		pos = newPos
 	}
@@ -96,15 +107,22 @@ func parseFileDiff(lines []string, pos int) (*diff.FileDiff, int, error) {
 		}
 		if strings.HasPrefix(line, "new file mode ") {
 			fd.IsNew = true
+			markGitlink(fd, strings.TrimPrefix(line, "new file mode "))
 			pos++
 			continue
 		}
 		if strings.HasPrefix(line, "deleted file mode ") {
 			fd.IsDeleted = true
+			markGitlink(fd, strings.TrimPrefix(line, "deleted file mode "))
 			pos++
 			continue
 		}
 		if strings.HasPrefix(line, "index ") {
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				markGitlink(fd, fields[2])
+			}
+			parseIndexHashes(fd, fields[1])
 			pos++
 			continue
 		}
@@ -193,6 +211,17 @@ func parseFileDiff(lines []string, pos int) (*diff.FileDiff, int, error) {
 		pos++
 	}
 
+	// A gitlink's hunk holds the submodule commits
+	if fd.Submodule != nil {
+		for _, h := range hunks {
+			for _, line := range h.lines {
+				parseSubprojectLine(fd.Submodule, line)
+			}
+		}
+		setSubmoduleLines(fd)
+		return fd, pos, nil
+	}
+
 	// Align hunks into left/right lines
 	fd.LeftLines, fd.RightLines, fd.AddCount, fd.DelCount = alignHunks(hunks)
 
@@ -278,3 +307,17 @@ func parseHunk(lines []string, pos int) (hunk, int, error) {
 
 	return h, pos, nil
 }
+
+// parseIndexHashes records the blob hashes of an index line's "old..new"
+func parseIndexHashes(fd *diff.FileDiff, hashes string) {
+	oldHash, newHash, ok := strings.Cut(hashes, "..")
+	if !ok {
+		return
+	}
+	if !isNullCommit(oldHash) {
+		fd.OldHash = oldHash
+	}
+	if !isNullCommit(newHash) {
+		fd.NewHash = newHash
+	}
+}
@@ -191,6 +191,34 @@ func TestParseUnified_FileHeaders(t *testing.T) {
 	}
 }
 
+func TestParseUnified_IndexHashes(t *testing.T) {
+	input := `diff --git a/a.go b/a.go
+index 1a2b3c4..5d6e7f8 100644
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-old
++new
+diff --git a/b.go b/b.go
+new file mode 100644
+index 0000000..9abcdef
+--- /dev/null
++++ b/b.go
+@@ -0,0 +1 @@
++new
+`
+	files, err := parseUnified(input)
+	if err != nil {
+		t.Fatalf("parseUnified failed: %v", err)
+	}
+	if files[0].OldHash != "1a2b3c4" || files[0].NewHash != "5d6e7f8" {
+		t.Errorf("a.go: expected hashes 1a2b3c4..5d6e7f8, got %s..%s", files[0].OldHash, files[0].NewHash)
+	}
+	if files[1].OldHash != "" || files[1].NewHash != "9abcdef" {
+		t.Errorf("b.go: expected no old hash, got %s..%s", files[1].OldHash, files[1].NewHash)
+	}
+}
+
 func TestParseUnified_NoNewlineAtEOF(t *testing.T) {
 	input := `diff --git a/test.go b/test.go
 --- a/test.go
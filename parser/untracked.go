@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// ListUntrackedFiles returns untracked paths (relative to the repository root)
+// that are not excluded by .gitignore
+func (g *GitRunner) ListUntrackedFiles(ctx context.Context) ([]string, error) {
+	output, err := g.run(ctx, "ls-files", "-z", "--others", "--exclude-standard", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, p := range strings.Split(output, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// UntrackedFileDiffs synthesises an all-added FileDiff for every untracked file
+func (g *GitRunner) UntrackedFileDiffs(ctx context.Context) ([]diff.FileDiff, error) {
+	paths, err := g.ListUntrackedFiles(ctx)
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+
+	root, err := g.FindGitRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]diff.FileDiff, 0, len(paths))
+	for _, p := range paths {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			// The file may have vanished between listing and reading
+			continue
+		}
+		files = append(files, NewUntrackedFileDiff(p, content))
+	}
+	return files, nil
+}
+
+// NewUntrackedFileDiff builds the diff of a file that git does not track yet:
+// every line is an addition and the original side is all placeholders
+func NewUntrackedFileDiff(path string, content []byte) diff.FileDiff {
+	fd := diff.FileDiff{
+		Name:        path,
+		NewPath:     path,
+		IsNew:       true,
+		IsUntracked: true,
+	}
+
+	if isBinary(content) {
+		fd.IsBinary = true
+		return fd
+	}
+
+	if len(content) == 0 {
+		return fd
+	}
+
+	text := strings.TrimSuffix(string(content), "\n")
+	for i, line := range strings.Split(text, "\n") {
+		fd.LeftLines = append(fd.LeftLines, diff.Line{Type: diff.Placeholder})
+		fd.RightLines = append(fd.RightLines, diff.Line{Type: diff.Add, Content: line, Number: i + 1})
+	}
+	fd.AddCount = len(fd.RightLines)
+
+	return fd
+}
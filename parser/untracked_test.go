@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"testing"
+
+	"diff-tui/diff"
+)
+
+func TestNewUntrackedFileDiff(t *testing.T) {
+	fd := NewUntrackedFileDiff("src/new.go", []byte("package main\n\nfunc main() {}\n"))
+
+	if fd.Name != "src/new.go" {
+		t.Errorf("expected name 'src/new.go', got '%s'", fd.Name)
+	}
+	if !fd.IsNew || !fd.IsUntracked {
+		t.Error("expected file to be marked new and untracked")
+	}
+	if fd.AddCount != 3 {
+		t.Errorf("expected 3 additions, got %d", fd.AddCount)
+	}
+	if len(fd.LeftLines) != 3 || len(fd.RightLines) != 3 {
+		t.Fatalf("expected 3 lines each side, got %d/%d", len(fd.LeftLines), len(fd.RightLines))
+	}
+
+	for i := range fd.RightLines {
+		if fd.LeftLines[i].Type != diff.Placeholder {
+			t.Errorf("line %d: expected placeholder on left", i)
+		}
+		if fd.RightLines[i].Type != diff.Add {
+			t.Errorf("line %d: expected add on right", i)
+		}
+	}
+	if fd.RightLines[1].Content != "" {
+		t.Errorf("expected empty second line, got '%s'", fd.RightLines[1].Content)
+	}
+}
+
+func TestNewUntrackedFileDiff_NoTrailingNewline(t *testing.T) {
+	fd := NewUntrackedFileDiff("a.txt", []byte("one\ntwo"))
+
+	if fd.AddCount != 2 {
+		t.Errorf("expected 2 additions, got %d", fd.AddCount)
+	}
+}
+
+func TestNewUntrackedFileDiff_Empty(t *testing.T) {
+	fd := NewUntrackedFileDiff("empty.txt", nil)
+
+	if fd.AddCount != 0 || len(fd.RightLines) != 0 {
+		t.Errorf("expected no lines for empty file, got %d", len(fd.RightLines))
+	}
+}
+
+func TestNewUntrackedFileDiff_Binary(t *testing.T) {
+	fd := NewUntrackedFileDiff("image.png", []byte{0x89, 'P', 'N', 'G', 0x00, 0x01})
+
+	if !fd.IsBinary {
+		t.Error("expected file to be marked binary")
+	}
+	if len(fd.RightLines) != 0 {
+		t.Errorf("expected no lines for binary file, got %d", len(fd.RightLines))
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// WorkingTree holds both halves of the uncommitted changes
+type WorkingTree struct {
+	Staged   *diff.Result // HEAD vs index (git diff --cached)
+	Unstaged *diff.Result // index vs worktree (git diff) plus untracked files
+}
+
+// IsEmpty reports whether there are neither staged nor unstaged changes
+func (w *WorkingTree) IsEmpty() bool {
+	return len(w.Staged.Files) == 0 && len(w.Unstaged.Files) == 0
+}
+
+// Diff runs git diff with the given args and parses the output; unlike
+// Parser.ParseGitDiff an empty diff is not an error
+func (g *GitRunner) Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error) {
+	output, err := g.RunDiff(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+
+	result, err := ParseString(output)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.expandSubmodules(ctx, result.Files); err != nil {
+		return nil, err
+	}
+	return result.Files, nil
+}
+
+// WorkingTree loads the staged and unstaged diffs of the repository
+func (g *GitRunner) WorkingTree(ctx context.Context) (*WorkingTree, error) {
+	staged, err := g.Diff(ctx, "--cached")
+	if err != nil {
+		return nil, err
+	}
+
+	unstaged, err := g.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := g.UntrackedFileDiffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkingTree{
+		Staged:   &diff.Result{Files: staged},
+		Unstaged: &diff.Result{Files: append(unstaged, untracked...)},
+	}, nil
+}
+
+// ReadFile returns the content of path (relative to the repository root) at rev
+func (g *GitRunner) ReadFile(ctx context.Context, rev, path string) ([]byte, error) {
+	if rev == WorktreeRev {
+		root, err := g.FindGitRoot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	}
+
+	output, err := g.run(ctx, "cat-file", "blob", objectSpec(rev, path))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(output), nil
+}
+
+// objectSpec builds the <rev>:<path> name git uses for a blob; index stages
+// are written ":<n>:<path>" and the plain index ":<path>"
+func objectSpec(rev, path string) string {
+	if rev == IndexRev {
+		return ":" + path
+	}
+	return rev + ":" + path
+}
@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// blameGutterWidth is the width of the blame annotation in front of each
+// original line: short hash, author and date
+const blameGutterWidth = 28
+
+// blameMsg carries the blame of a file's original side; seq numbers the load
+type blameMsg struct {
+	seq   int
+	path  string
+	key   string
+	lines []parser.BlameLine
+	err   error
+}
+
+// blameTarget returns the revision and path of the selected file's original
+// side; an empty rev is the index
+func (m Model) blameTarget() (rev, path string, ok bool) {
+	if m.selectedIdx >= len(m.visibleNodes) {
+		return "", "", false
+	}
+	node := m.visibleNodes[m.selectedIdx]
+	if node.File == nil || node.File.IsNew || node.File.IsUntracked {
+		return "", "", false
+	}
+	path = node.File.OldPath
+	if path == "" {
+		path = node.File.Name
+	}
+
+	switch {
+	case m.detached != nil:
+		return m.detached.base, path, m.detached.base != ""
+	case node.Section == SectionStaged:
+		return "HEAD", path, true
+	case node.Section == SectionUnstaged:
+		return "", path, true
+	}
+	return parser.DiffBase(m.diffArgs), path, true
+}
+
+func blameKey(rev, path string) string {
+	return rev + "\x00" + path
+}
+
+// toggleBlame shows or hides blame annotations on the original side
+func (m *Model) toggleBlame() tea.Cmd {
+	if _, ok := m.repo.(parser.Blamer); !ok {
+		return m.notify(SeverityWarning, "This repository does not support blame")
+	}
+	m.blameOn = !m.blameOn
+	m.threePane = false
+	m.resize()
+	if !m.blameOn {
+		return nil
+	}
+	if _, _, ok := m.blameTarget(); !ok {
+		return m.notify(SeverityInfo, "The selected file has no original side to blame")
+	}
+	return m.loadBlame()
+}
+
+// loadBlame blames the selected file in the background, outside the
+// operation slot, unless it is loaded already
+func (m *Model) loadBlame() tea.Cmd {
+	blamer, ok := m.repo.(parser.Blamer)
+	rev, path, target := m.blameTarget()
+	if !ok || !m.blameOn || !target {
+		return nil
+	}
+	key := blameKey(rev, path)
+	if _, loaded := m.blames[key]; loaded || m.blameLoad.loading(key) {
+		return nil
+	}
+	seq := m.blameLoad.start(key)
+	return func() tea.Msg {
+		lines, err := blamer.Blame(context.Background(), rev, path)
+		return blameMsg{seq: seq, path: path, key: key, lines: lines, err: err}
+	}
+}
+
+// handleBlame stores a loaded blame; failures are kept so they aren't retried
+func (m *Model) handleBlame(msg blameMsg) tea.Cmd {
+	if !m.blameLoad.finish(msg.seq) {
+		return nil
+	}
+	m.applyBlame(msg)
+	if msg.err != nil {
+		return m.notifyf(SeverityError, "Blaming %s failed: %v", msg.path, msg.err)
+	}
+	return nil
+}
+
+// applyBlame stores a loaded blame and redraws the original side
+func (m *Model) applyBlame(msg blameMsg) {
+	if m.blames == nil {
+		m.blames = make(map[string][]parser.BlameLine)
+	}
+	m.blames[msg.key] = msg.lines
+	m.updateDiffContent()
+}
+
+// selectedBlame returns the blame of the selected file's original side, nil
+// when blame is off or not loaded yet
+func (m Model) selectedBlame() []parser.BlameLine {
+	if !m.blameOn {
+		return nil
+	}
+	rev, path, ok := m.blameTarget()
+	if !ok {
+		return nil
+	}
+	return m.blames[blameKey(rev, path)]
+}
+
+// blameGutter renders the annotation for one original line
+func blameGutter(blame []parser.BlameLine, line diff.Line) string {
+	if line.Type == diff.Placeholder || line.Number < 1 || line.Number > len(blame) {
+		return strings.Repeat(" ", blameGutterWidth)
+	}
+	b := blame[line.Number-1]
+	author := []rune(b.Author)
+	if len(author) > 8 {
+		author = append(author[:7], '~')
+	}
+	return BlameStyle.Render(fmt.Sprintf("%.7s %-8s %s ", b.Commit, string(author), b.Date.Format("2006-01-02")))
+}
+
+// showBlamedCommit opens the commit that last changed the top line of the
+// original side, with the blamed file selected
+func (m *Model) showBlamedCommit() tea.Cmd {
+	blame := m.selectedBlame()
+	reader, ok := m.logReader()
+	if blame == nil || !ok || m.selectedIdx >= len(m.visibleNodes) || m.visibleNodes[m.selectedIdx].File == nil {
+		return nil
+	}
+
+	lines := m.visibleNodes[m.selectedIdx].File.LeftLines
+	for i := m.leftViewport.YOffset; i < len(lines) && i < m.leftViewport.YOffset+m.leftViewport.Height; i++ {
+		number := lines[i].Number
+		if lines[i].Type == diff.Placeholder || number < 1 || number > len(blame) {
+			continue
+		}
+		b := blame[number-1]
+		if !b.IsCommitted() {
+			return m.notifyf(SeverityInfo, "Line %d is not committed yet", number)
+		}
+		return m.startOperation("show "+b.Commit[:7], func(ctx context.Context) tea.Msg {
+			msg := commitDiffMsg{path: b.Filename, line: b.Line}
+			entries, err := reader.Log(ctx, parser.LogOptions{Rev: b.Commit, Limit: 1})
+			if err != nil || len(entries) == 0 {
+				msg.err = err
+				return msg
+			}
+			msg.entry = entries[0]
+			msg.files, msg.err = reader.CommitDiff(ctx, b.Commit)
+			return msg
+		})
+	}
+	return nil
+}
+
+// scrollToLine scrolls both diff panels to the row of a line of the new side
+func (m *Model) scrollToLine(number int) {
+	if m.selectedIdx >= len(m.visibleNodes) || m.visibleNodes[m.selectedIdx].File == nil {
+		return
+	}
+	for i, line := range m.visibleNodes[m.selectedIdx].File.RightLines {
+		if line.Number == number && line.Type != diff.Placeholder {
+			m.leftViewport.SetYOffset(max(i-3, 0))
+			m.rightViewport.SetYOffset(max(i-3, 0))
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// blameRepo is a memrepo.Repo that blames every line on its newest commit
+type blameRepo struct {
+	*memrepo.Repo
+	blamed *[]string // Revisions blamed
+}
+
+func (r blameRepo) Blame(ctx context.Context, rev, path string) ([]parser.BlameLine, error) {
+	*r.blamed = append(*r.blamed, rev)
+	head, err := r.Repo.Log(ctx, parser.LogOptions{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	var lines []parser.BlameLine
+	for i := 1; i <= 3; i++ {
+		lines = append(lines, parser.BlameLine{Commit: head[0].Hash, Author: "Ada Lovelace", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Filename: path, Line: i})
+	}
+	return lines, nil
+}
+
+func (r blameRepo) Log(ctx context.Context, opts parser.LogOptions) ([]parser.LogEntry, error) {
+	rev := opts.Rev
+	opts.Rev = ""
+	entries, err := r.Repo.Log(ctx, opts)
+	if rev == "" || err != nil {
+		return entries, err
+	}
+	for _, e := range entries {
+		if e.Hash == rev {
+			return []parser.LogEntry{e}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestModel_BlameOriginalSide(t *testing.T) {
+	ctx := context.Background()
+	repo := memrepo.New(map[string]string{"a.txt": "one\ntwo\nthree\n"})
+	repo.WriteFile("a.txt", "one\nTWO\nthree\n")
+	if err := repo.StageFile(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit(ctx, parser.CommitOptions{Message: "Shout two"}); err != nil {
+		t.Fatal(err)
+	}
+	repo.WriteFile("a.txt", "one\nTWO\n3\n")
+
+	blamed := &[]string{}
+	m := newTestModel(t, repo)
+	m.repo = blameRepo{Repo: repo, blamed: blamed}
+
+	// The unstaged change's original side is the index
+	m = pressKey(m, "B")
+	if len(*blamed) != 1 || (*blamed)[0] != "" {
+		t.Fatalf("blamed %q, want the index", *blamed)
+	}
+	view := m.View()
+	if !strings.Contains(view, "Original (blame)") || !strings.Contains(view, "Ada Lov~ 2024-03-01") {
+		t.Fatal("expected blame annotations in the original panel")
+	}
+
+	// Other keys do not blame the same file again
+	m = pressKey(m, "s")
+	m = pressKey(m, "s")
+	if len(*blamed) != 1 {
+		t.Errorf("blamed %d times", len(*blamed))
+	}
+
+	// Enter in the original panel opens the blamed commit with the file selected
+	m = update(m, tea.KeyMsg{Type: tea.KeyTab})
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detached == nil || !strings.HasPrefix(m.detached.title, "Commit ") {
+		t.Fatal("expected the blamed commit's diff")
+	}
+	if name := m.visibleNodes[m.selectedIdx].File.Name; name != "a.txt" {
+		t.Errorf("selected %q", name)
+	}
+
+	// A root commit has no original side to blame
+	if len(*blamed) != 1 || m.detached.base != "" {
+		t.Errorf("blamed %q in the root commit's view", *blamed)
+	}
+
+	m = pressKey(m, "B")
+	if strings.Contains(m.View(), "Original (blame)") {
+		t.Error("expected B to turn blame off")
+	}
+}
+
+func TestModel_BlameLeavesOperationSlotFree(t *testing.T) {
+	repo := newHistoryRepo(t, 1)
+	repo.WriteFile("a.txt", "two\n")
+	m := newTestModel(t, repo)
+	m.repo = blameRepo{Repo: repo, blamed: &[]string{}}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	m = next.(Model)
+	if cmd == nil || m.op != nil {
+		t.Fatal("expected blame to load outside the operation slot")
+	}
+
+	// Staging goes ahead while the blame loads, and the blame of the index
+	// from before it is dropped
+	m = pressKey(m, " ")
+	if len(m.staged.Files) != 1 {
+		t.Fatal("expected the file to be staged while blame loads")
+	}
+	m = runCmd(m, cmd)
+	if _, ok := m.blames[blameKey("", "a.txt")]; ok {
+		t.Error("expected the outdated blame to be dropped")
+	}
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BottomPanel selects what is shown below the file list and diffs
+type BottomPanel int
+
+const (
+	BottomNone       BottomPanel = iota
+	BottomOutput                 // Output of the running/last operation
+	BottomCommandLog             // Every git command run so far
+)
+
+// bottomHeight is the height of the bottom panel, 0 when hidden
+func (m Model) bottomHeight() int {
+	if m.bottom == BottomNone {
+		return 0
+	}
+	return min(12, m.height/3)
+}
+
+// mainHeight is the height left for the file list and diff panels
+func (m Model) mainHeight() int {
+	return m.height - m.headerHeight() - m.bottomHeight()
+}
+
+// panelCount is the number of panels Tab cycles through
+func (m Model) panelCount() FocusedPanel {
+	if m.bottom == BottomNone {
+		return 3
+	}
+	return 4
+}
+
+// toggleBottomPanel shows p, or hides it when it is already shown
+func (m *Model) toggleBottomPanel(p BottomPanel) {
+	if m.bottom == p {
+		p = BottomNone
+	}
+	m.setBottomPanel(p)
+}
+
+// setBottomPanel switches the bottom panel and resizes the diff panels
+func (m *Model) setBottomPanel(p BottomPanel) {
+	if m.bottom != p {
+		m.bottomScroll = 0
+	}
+	m.bottom = p
+	if p == BottomNone && m.focused == FocusBottomPanel {
+		m.focused = FocusFileList
+	}
+	m.resize()
+}
+
+// scrollBottom scrolls the bottom panel by lines; positive scrolls back in time
+func (m *Model) scrollBottom(lines int) {
+	maxScroll := max(len(m.bottomLines())-(m.bottomHeight()-3), 0)
+	m.bottomScroll = min(max(m.bottomScroll+lines, 0), maxScroll)
+}
+
+// bottomLine is one line of the bottom panel
+type bottomLine struct {
+	text   string
+	failed bool // Summary line of a failed command
+}
+
+// bottomLines returns the full content of the bottom panel
+func (m Model) bottomLines() []bottomLine {
+	var lines []bottomLine
+	if m.bottom != BottomCommandLog {
+		for _, text := range m.output {
+			lines = append(lines, bottomLine{text: text})
+		}
+		return lines
+	}
+
+	logger, ok := m.repo.(parser.CommandLogger)
+	if !ok {
+		return []bottomLine{{text: "This repository does not run git commands"}}
+	}
+
+	for _, r := range logger.CommandLog().Records() {
+		for i, text := range strings.Split(strings.TrimRight(r.Format(), "\n"), "\n") {
+			lines = append(lines, bottomLine{text: text, failed: i == 0 && r.Failed()})
+		}
+	}
+	return lines
+}
+
+// renderBottomPanel renders the visible window of the bottom panel
+func (m Model) renderBottomPanel(width, height int) string {
+	isFocused := m.focused == FocusBottomPanel
+	titleText := "Output"
+	if m.bottom == BottomCommandLog {
+		titleText = "Command Log"
+	}
+
+	var title string
+	if isFocused || (m.bottom == BottomOutput && m.op != nil) {
+		title = TitleStyle.Render(titleText)
+	} else {
+		title = TitleInactiveStyle.Render(titleText)
+	}
+
+	// Show the window ending bottomScroll lines before the end
+	lines := m.bottomLines()
+	visible := max(height-1, 0)
+	end := max(len(lines)-m.bottomScroll, 0)
+	start := max(end-visible, 0)
+
+	content := make([]string, 0, visible)
+	for _, line := range lines[start:end] {
+		text := line.text
+		text = truncate(text, width-2)
+		if line.failed {
+			text = StatusConflictStyle.Render(text)
+		}
+		content = append(content, text)
+	}
+
+	panelStyle := PanelStyle
+	if isFocused {
+		panelStyle = FocusedPanelStyle
+	}
+	return panelStyle.
+		Width(width).
+		Height(height).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(content, "\n")))
+}
+
+// truncate cuts s to width runes, marking the cut with ~
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(width-1, 0)]) + "~"
+}
@@ -0,0 +1,465 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"diff-tui/comments"
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CommentMarker follows the number of a line with a comment on it
+const CommentMarker = "◆"
+
+// maxQuotedLines bounds the commented lines shown above the comment input
+const maxQuotedLines = 5
+
+// lineSelection is a range of rows of one diff panel picked for a comment
+type lineSelection struct {
+	right  bool
+	anchor int // Row the selection started on
+	cursor int // Row it extends to
+}
+
+// rows returns the selected rows in order
+func (s lineSelection) rows() (from, to int) {
+	return min(s.anchor, s.cursor), max(s.anchor, s.cursor)
+}
+
+// newCommentInput creates the editor of the comment input
+func newCommentInput() textarea.Model {
+	input := textarea.New()
+	input.Placeholder = "Comment"
+	input.ShowLineNumbers = false
+	input.CharLimit = 0
+	input.SetWidth(commitModalWidth - 6)
+	input.SetHeight(6)
+	return input
+}
+
+// loadComments reads the comments kept in the repository's git directory, or
+// keeps them for the session if there is none
+func loadComments(ctx context.Context, repo parser.Repository) (*comments.Store, error) {
+	gitDir, err := repoGitDir(ctx, repo)
+	if gitDir == "" || err != nil {
+		return comments.NewMemory(), err
+	}
+	store, err := comments.Load(gitDir)
+	if err != nil {
+		return comments.NewMemory(), err
+	}
+	return store, nil
+}
+
+// sideOf returns the side of the diff a panel shows
+func sideOf(right bool) comments.Side {
+	if right {
+		return comments.New
+	}
+	return comments.Old
+}
+
+// commentedLines returns the numbers of the selected file's lines on one side
+// that have comments
+func (m Model) commentedLines(isLeft bool) map[int]bool {
+	file := m.commentFile()
+	store, _ := m.commentStore()
+	if file == nil || store == nil {
+		return nil
+	}
+	want := sideOf(!isLeft)
+	lines := make(map[int]bool)
+	for _, c := range store.ForFile(file.Name) {
+		if c.Side == want {
+			for n := c.Start; n <= c.End; n++ {
+				lines[n] = true
+			}
+		}
+	}
+	return lines
+}
+
+// selectedRows returns the rows of a panel picked for a comment
+func (m Model) selectedRows(isLeft bool) (from, to int, ok bool) {
+	if m.lineSelect == nil || m.lineSelect.right == isLeft {
+		return 0, 0, false
+	}
+	from, to = m.lineSelect.rows()
+	return from, to, true
+}
+
+// commentFile returns the selected file if its diff can be commented on
+func (m Model) commentFile() *diff.FileDiff {
+	if m.threePaneView() || m.conflicts != nil || m.selectedIdx >= len(m.visibleNodes) {
+		return nil
+	}
+	return m.visibleNodes[m.selectedIdx].File
+}
+
+// startLineSelect starts picking lines of the focused panel for a comment,
+// from the first numbered line in view
+func (m *Model) startLineSelect() tea.Cmd {
+	if m.focused != FocusLeftDiff && m.focused != FocusRightDiff {
+		return nil
+	}
+	file := m.commentFile()
+	if file == nil {
+		return m.notify(SeverityInfo, "Select a file's diff to comment on")
+	}
+	if store, _ := m.commentStore(); store == nil {
+		return m.notify(SeverityInfo, "The commit's review notes are still loading")
+	}
+	right := m.focused == FocusRightDiff
+	vp := m.leftViewport
+	if right {
+		vp = m.rightViewport
+	}
+	lines := side(file, right)
+	for row := vp.YOffset; row < len(lines); row++ {
+		if lines[row].Number > 0 {
+			m.lineSelect = &lineSelection{right: right, anchor: row, cursor: row}
+			m.updateDiffContent()
+			return nil
+		}
+	}
+	return m.notifyf(SeverityInfo, "No %s lines to comment on here", sideOf(right))
+}
+
+// updateLineSelect handles keys while lines are picked: up and down extend
+// the selection, a or enter write the comment, z stashes the hunks it touches,
+// esc gives up
+func (m *Model) updateLineSelect(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Quit):
+		return nil, false
+	case key.Matches(msg, m.keys.Cancel):
+		m.lineSelect = nil
+		m.updateDiffContent()
+	case key.Matches(msg, m.keys.Up):
+		m.moveLineCursor(-1)
+	case key.Matches(msg, m.keys.Down):
+		m.moveLineCursor(1)
+	case key.Matches(msg, m.keys.AddComment), key.Matches(msg, m.keys.Enter):
+		m.openCommentInput()
+	case key.Matches(msg, m.keys.StashFile):
+		return m.stashSelectedHunks(), true
+	}
+	return nil, true
+}
+
+// moveLineCursor moves the selection's end to the next numbered line in the
+// direction of delta, scrolling to keep it in view
+func (m *Model) moveLineCursor(delta int) {
+	file := m.commentFile()
+	if file == nil {
+		return
+	}
+	sel := m.lineSelect
+	lines := side(file, sel.right)
+	row := sel.cursor + delta
+	for row >= 0 && row < len(lines) && lines[row].Number == 0 {
+		row += delta
+	}
+	if row < 0 || row >= len(lines) {
+		return
+	}
+	sel.cursor = row
+
+	vp := m.leftViewport
+	if sel.right {
+		vp = m.rightViewport
+	}
+	if row < vp.YOffset {
+		m.scrollUp(vp.YOffset - row)
+	} else if bottom := vp.YOffset + vp.Height - 1; row > bottom {
+		m.scrollDown(row - bottom)
+	}
+	m.updateDiffContent()
+}
+
+// openCommentInput opens the input for a comment on the selected lines
+func (m *Model) openCommentInput() {
+	file := m.commentFile()
+	if file == nil {
+		return
+	}
+	sel := m.lineSelect
+	from, to := sel.rows()
+	draft := &comments.Comment{Path: file.Name, Side: sideOf(sel.right)}
+	for _, line := range side(file, sel.right)[from : to+1] {
+		if line.Number == 0 {
+			continue
+		}
+		if draft.Start == 0 {
+			draft.Start = line.Number
+		}
+		draft.End = line.Number
+		draft.Code = append(draft.Code, line.Content)
+	}
+	m.commentDraft = draft
+	m.commentInput.SetValue("")
+	m.commentInput.Focus()
+}
+
+// closeCommentInput closes the comment input and ends the line selection
+func (m *Model) closeCommentInput() {
+	m.commentDraft = nil
+	m.commentInput.Blur()
+	m.lineSelect = nil
+	m.updateDiffContent()
+}
+
+// updateCommentInput handles input while a comment is written
+func (m Model) updateCommentInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	// While the note is written, esc cancels it and input waits
+	if m.commentWriting {
+		if keyMsg.String() == "esc" {
+			m.cancelOperation()
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		m.closeCommentInput()
+		return m, nil
+	case "ctrl+s":
+		return m, m.saveComment()
+	}
+	var cmd tea.Cmd
+	m.commentInput, cmd = m.commentInput.Update(keyMsg)
+	return m, cmd
+}
+
+// saveComment adds the comment being written to the store, or to the notes
+// of the commit shown; the input stays open until the notes are written
+func (m *Model) saveComment() tea.Cmd {
+	body := strings.TrimSpace(m.commentInput.Value())
+	if body == "" {
+		return m.notify(SeverityWarning, "The comment is empty")
+	}
+	store, commit := m.commentStore()
+	if commit != "" && m.op != nil {
+		// Keep the comment open until it can be written
+		return m.notifyf(SeverityWarning, "Busy: %s is still running", m.op.name)
+	}
+	c := *m.commentDraft
+	c.Body = body
+	c.Created = time.Now()
+	if commit != "" {
+		c.Author = m.noteAuthor
+		done := fmt.Sprintf("Commented on %s, %s in the notes of %.7s", c.Path, c.Lines(), commit)
+		cmd := m.editNotes(commit, func(s *comments.Store) { s.Add(c) }, done)
+		m.commentWriting = m.op != nil
+		return cmd
+	}
+	store.Add(c)
+	m.closeCommentInput()
+	if err := store.Save(); err != nil {
+		return m.notify(SeverityError, "Could not save comments: "+err.Error())
+	}
+	return m.notifyf(SeverityInfo, "Commented on %s, %s", c.Path, c.Lines())
+}
+
+// openComments shows the comment panel
+func (m *Model) openComments() tea.Cmd {
+	store, _ := m.commentStore()
+	if store == nil || len(store.All()) == 0 {
+		return m.notify(SeverityInfo, "No comments yet; press a in a diff panel to add one")
+	}
+	m.commentsActive = true
+	m.commentsConfirm = false
+	m.commentsSelected = min(m.commentsSelected, len(store.All())-1)
+	return nil
+}
+
+// updateCommentsPanel handles input while the comment panel is open
+func (m Model) updateCommentsPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	store, commit := m.commentStore()
+	if store == nil {
+		// The commit shown changed under the panel
+		m.commentsActive = false
+		return m, nil
+	}
+	all := store.All()
+
+	// Deleting waits for y
+	if m.commentsConfirm {
+		m.commentsConfirm = false
+		if keyMsg.String() != "y" {
+			return m, nil
+		}
+		if commit != "" {
+			c := all[m.commentsSelected]
+			return m, m.editNotes(commit, func(s *comments.Store) { s.Delete(c) }, "")
+		}
+		store.Remove(m.commentsSelected)
+		m.updateDiffContent()
+		if len(store.All()) == 0 {
+			m.commentsActive = false
+		}
+		m.commentsSelected = max(min(m.commentsSelected, len(store.All())-1), 0)
+		if err := store.Save(); err != nil {
+			return m, m.notify(SeverityError, "Could not save comments: "+err.Error())
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel), key.Matches(keyMsg, m.keys.Comments):
+		m.commentsActive = false
+	case key.Matches(keyMsg, m.keys.Quit):
+		m.cancelOperation()
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Down):
+		m.commentsSelected = min(m.commentsSelected+1, max(len(all)-1, 0))
+	case key.Matches(keyMsg, m.keys.Up):
+		m.commentsSelected = max(m.commentsSelected-1, 0)
+	case key.Matches(keyMsg, m.keys.Stage):
+		m.commentsExpanded = !m.commentsExpanded
+	case key.Matches(keyMsg, m.keys.Enter):
+		m.commentsActive = false
+		return m, m.jumpToComment(all[m.commentsSelected])
+	case key.Matches(keyMsg, m.keys.Export):
+		return m, m.exportComments()
+	case keyMsg.String() == "d":
+		m.commentsConfirm = true
+	}
+	return m, nil
+}
+
+// jumpToComment selects the file a comment is on and scrolls to its lines
+func (m *Model) jumpToComment(c comments.Comment) tea.Cmd {
+	if !m.selectFile(c.Path) {
+		return m.notifyf(SeverityInfo, "%s is not in this diff", c.Path)
+	}
+	target := m.visibleNodes[m.selectedIdx]
+
+	right := c.Side == comments.New
+	m.focused = FocusLeftDiff
+	if right {
+		m.focused = FocusRightDiff
+	}
+	for row, line := range side(target.File, right) {
+		if line.Number >= c.Start {
+			m.leftViewport.SetYOffset(max(row-3, 0))
+			m.rightViewport.SetYOffset(max(row-3, 0))
+			break
+		}
+	}
+	return tea.Batch(m.loadBlame(), m.loadThreeWay())
+}
+
+// exportComments writes every comment as Markdown to the output panel and,
+// unless they are a commit's notes, next to the stored comments
+func (m *Model) exportComments() tea.Cmd {
+	store, _ := m.commentStore()
+	if store == nil || len(store.All()) == 0 {
+		return m.notify(SeverityInfo, "No comments to export")
+	}
+	markdown := comments.Markdown(store.All())
+	m.appendOutputLine("$ export comments")
+	m.appendOutput(markdown)
+
+	dir := store.Dir()
+	if dir == "" {
+		return m.notify(SeverityInfo, "Comments exported to the output panel (o)")
+	}
+	path := filepath.Join(dir, "comments.md")
+	if err := os.WriteFile(path, []byte(markdown), 0o644); err != nil {
+		return m.notify(SeverityError, "Could not export comments: "+err.Error())
+	}
+	return m.notifyf(SeverityInfo, "Exported %s to %s", plural(len(store.All()), "comment"), path)
+}
+
+// renderCommentInput renders the comment input over the main view
+func (m Model) renderCommentInput() string {
+	c := m.commentDraft
+	title := ModalTitleStyle.Render(fmt.Sprintf("Comment on %s, %s (%s)", c.Path, c.Lines(), c.Side))
+
+	quoted := c.Code
+	if len(quoted) > maxQuotedLines {
+		quoted = append(quoted[:maxQuotedLines-1:maxQuotedLines-1], "...")
+	}
+	var lines []string
+	for _, line := range quoted {
+		lines = append(lines, "│ "+truncate(line, commitModalWidth-4))
+	}
+	code := ModalHelpStyle.MarginTop(0).Render(strings.Join(lines, "\n"))
+
+	help := ModalHelpStyle.Render("Ctrl+s: save | Esc: cancel")
+	if m.commentWriting {
+		help = ModalHelpStyle.Render(m.operationStatus())
+	}
+	modal := ModalStyle.Width(commitModalWidth).Render(lipgloss.JoinVertical(lipgloss.Left, title, code, m.commentInput.View(), help))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
+
+// renderCommentsPanel renders the list of comments over the main view; the
+// selected one is expanded to its lines and whole text on request
+func (m Model) renderCommentsPanel() string {
+	store, commit := m.commentStore()
+	if store == nil {
+		store = comments.NewMemory() // Closed on the next key
+	}
+	all := store.All()
+	title := ModalTitleStyle.Render(fmt.Sprintf("Comments (%d)", len(all)))
+	if commit != "" {
+		title = ModalTitleStyle.Render(fmt.Sprintf("Review notes of %.7s (%d)", commit, len(all)))
+	}
+	width := commitModalWidth + 20
+
+	var lines []string
+	selectedLine := 0
+	for i, c := range all {
+		body, _, _ := strings.Cut(c.Body, "\n")
+		line := fmt.Sprintf("%s:%d %-8s %s", c.Path, c.Start, c.Side, body)
+		line = truncate(line, width)
+		if i == m.commentsSelected {
+			selectedLine = len(lines)
+			line = FileItemSelectedStyle.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+
+		if i == m.commentsSelected && m.commentsExpanded {
+			for _, code := range c.Code {
+				lines = append(lines, ModalHelpStyle.MarginTop(0).Render("  │ "+code))
+			}
+			for _, text := range strings.Split(c.Body, "\n") {
+				lines = append(lines, "  "+text)
+			}
+		}
+	}
+
+	// Keep the selection in view
+	visible := max(m.height-12, 1)
+	start := max(selectedLine-visible+1, 0)
+	end := min(start+visible, len(lines))
+	body := lipgloss.NewStyle().Width(width).Render(strings.Join(lines[start:end], "\n"))
+
+	help := ModalHelpStyle.Render("enter: go to | space: expand | d: delete | E: export | V/Esc: close")
+	if m.commentsConfirm {
+		help = ModalWarningStyle.Render(fmt.Sprintf("Delete the comment on %s? y/n", all[m.commentsSelected].Path))
+	}
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, body, help))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"diff-tui/comments"
+	"diff-tui/memrepo"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestModel_AddComment(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\ntwo\nthree\n", "b.txt": "b\n"})
+	repo.WriteFile("a.txt", "one\nTWO\nthree\n")
+	repo.WriteFile("b.txt", "B\n")
+	m := newTestModel(t, repo)
+	m.selectedIdx = findNode(t, m, "a.txt")
+	m.updateDiffContent()
+
+	// Pick lines 1-3 of the modified side
+	m = pressKey(m, "tab")
+	m = pressKey(m, "tab")
+	m = pressKey(m, "a")
+	if m.lineSelect == nil || !m.lineSelect.right {
+		t.Fatal("expected lines of the modified side to be picked")
+	}
+	m = pressKey(m, "j")
+	m = pressKey(m, "j")
+	m = pressKey(m, "a")
+	if m.commentDraft == nil || m.commentDraft.Start != 1 || m.commentDraft.End != 3 {
+		t.Fatalf("expected a comment on lines 1-3, got %+v", m.commentDraft)
+	}
+	if !strings.Contains(m.View(), "Comment on a.txt, lines 1-3 (modified)") {
+		t.Error("expected the comment input on screen")
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Use a constant")})
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	all := m.comments.All()
+	if len(all) != 1 || all[0].Body != "Use a constant" || all[0].Side != comments.New || len(all[0].Code) != 3 {
+		t.Fatalf("unexpected comments %+v", all)
+	}
+	if m.commentDraft != nil || m.lineSelect != nil {
+		t.Error("expected the input and selection to be closed")
+	}
+	if !strings.Contains(m.rightViewport.View(), "2"+CommentMarker) {
+		t.Error("expected a marker on the commented lines")
+	}
+	if strings.Contains(m.leftViewport.View(), CommentMarker) {
+		t.Error("expected no marker on the original side")
+	}
+}
+
+func TestModel_CommentsPanel(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	repo.WriteFile("a.txt", "A\n")
+	repo.WriteFile("b.txt", "B\n")
+	m := newTestModel(t, repo)
+	m.comments.Add(comments.Comment{Path: "b.txt", Side: comments.Old, Start: 1, End: 1, Body: "Why?", Code: []string{"b"}})
+
+	m = pressKey(m, "V")
+	if !m.commentsActive || !strings.Contains(m.View(), "Comments (1)") {
+		t.Fatal("expected the comment panel")
+	}
+
+	// Space expands the selected comment to its lines
+	m = pressKey(m, " ")
+	if !strings.Contains(m.View(), "│ b") {
+		t.Error("expected the commented line in the expanded comment")
+	}
+
+	m = pressKey(m, "E")
+	if !strings.Contains(strings.Join(m.output, "\n"), "**Line 1** (original)") {
+		t.Error("expected the Markdown export in the output panel")
+	}
+
+	// Enter goes to the comment's file and side
+	m = pressKey(m, "enter")
+	if m.commentsActive || m.visibleNodes[m.selectedIdx].Path != "b.txt" || m.focused != FocusLeftDiff {
+		t.Fatal("expected to jump to b.txt's original side")
+	}
+
+	m = pressKey(m, "V")
+	m = pressKey(m, "d")
+	m = pressKey(m, "y")
+	if len(m.comments.All()) != 0 || m.commentsActive {
+		t.Error("expected the comment to be deleted and the panel closed")
+	}
+}
@@ -0,0 +1,417 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"diff-tui/commitlint"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const commitModalWidth = 60
+
+// editorFinishedMsg is sent when the external commit message editor exits
+type editorFinishedMsg struct {
+	path     string
+	template string // commit.template content the file was prefilled with, if any
+	err      error
+}
+
+// headMessageMsg carries HEAD's message, read to prefill an amend
+type headMessageMsg struct {
+	message string
+	err     error
+}
+
+// commitTemplateMsg carries the commit.template read before opening $EDITOR;
+// the editor opens without it if it cannot be read
+type commitTemplateMsg struct {
+	template string
+}
+
+// newCommitInputs creates the subject line and body editors of the commit modal
+func newCommitInputs() (textinput.Model, textarea.Model) {
+	subject := textinput.New()
+	subject.Placeholder = "Subject"
+	subject.Width = commitModalWidth - 8
+
+	body := textarea.New()
+	body.Placeholder = "Body (optional)"
+	body.ShowLineNumbers = false
+	body.CharLimit = 0
+	body.SetWidth(commitModalWidth - 6)
+	body.SetHeight(6)
+
+	return subject, body
+}
+
+// updateCommitModal handles input while the commit modal is open
+func (m Model) updateCommitModal(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case editorFinishedMsg:
+		return m, m.finishEditor(msg)
+
+	case tea.KeyMsg:
+		// While the commit (and its hooks) runs, esc cancels it and input waits
+		if m.op != nil {
+			if msg.String() == "esc" {
+				m.cancelOperation()
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			m.closeCommitModal()
+			return m, nil
+		case "ctrl+s":
+			return m, m.executeCommit(m.commitMessage(), false)
+		case "enter":
+			// Enter commits from the subject line and adds a line in the body
+			if !m.commitBodyFocused {
+				return m, m.executeCommit(m.commitMessage(), false)
+			}
+		case "tab", "shift+tab":
+			m.setCommitBodyFocused(!m.commitBodyFocused)
+			return m, nil
+		case "ctrl+r":
+			return m, m.toggleAmend()
+		case "ctrl+o":
+			m.commitOpts.SignOff = !m.commitOpts.SignOff
+			return m, nil
+		case "ctrl+n":
+			m.commitOpts.NoVerify = !m.commitOpts.NoVerify
+			return m, nil
+		case "ctrl+x":
+			return m, m.openEditor()
+		}
+
+		var cmd tea.Cmd
+		if m.commitBodyFocused {
+			m.commitBody, cmd = m.commitBody.Update(msg)
+		} else {
+			m.commitSubject, cmd = m.commitSubject.Update(msg)
+		}
+		m.lintCommitMessage()
+		return m, cmd
+	}
+	return m, nil
+}
+
+// openCommitModal opens the commit message modal
+func (m *Model) openCommitModal() {
+	m.commitModalActive = true
+	m.commitError = ""
+	m.commitOpts = parser.CommitOptions{}
+	m.amendPrefill = ""
+	m.commitSubject.SetValue("")
+	m.commitBody.SetValue("")
+	m.commitIssues = nil
+	m.setCommitBodyFocused(false)
+}
+
+// closeCommitModal closes the commit message modal
+func (m *Model) closeCommitModal() {
+	m.commitModalActive = false
+	m.commitError = ""
+	m.commitSubject.Blur()
+	m.commitBody.Blur()
+}
+
+func (m *Model) setCommitBodyFocused(focused bool) {
+	m.commitBodyFocused = focused
+	if focused {
+		m.commitSubject.Blur()
+		m.commitBody.Focus()
+	} else {
+		m.commitBody.Blur()
+		m.commitSubject.Focus()
+	}
+}
+
+// commitMessage returns the message currently entered in the modal
+func (m *Model) commitMessage() string {
+	return parser.FormatCommitMessage(m.commitSubject.Value(), m.commitBody.Value())
+}
+
+// setCommitMessage fills the subject and body from a full message
+func (m *Model) setCommitMessage(message string) {
+	subject, body := parser.SplitCommitMessage(message)
+	m.commitSubject.SetValue(subject)
+	m.commitBody.SetValue(body)
+	m.lintCommitMessage()
+}
+
+// lintCommitMessage re-checks the entered message against the configured rules
+func (m *Model) lintCommitMessage() {
+	m.commitIssues = nil
+	if msg := m.commitMessage(); msg != "" {
+		m.commitIssues = m.commitLint.Lint(parser.CleanupCommitWhitespace(msg))
+	}
+}
+
+// toggleAmend switches amend mode, prefilling an empty message with HEAD's,
+// which is read in the background
+func (m *Model) toggleAmend() tea.Cmd {
+	m.commitOpts.Amend = !m.commitOpts.Amend
+	m.commitError = ""
+	if !m.commitOpts.Amend {
+		// Untouched prefill: turning amend off shouldn't leave HEAD's message behind
+		if m.amendPrefill != "" && m.commitMessage() == m.amendPrefill {
+			m.setCommitMessage("")
+		}
+		m.amendPrefill = ""
+		return nil
+	}
+	if m.repo == nil || m.commitMessage() != "" {
+		return nil
+	}
+
+	repo := m.repo
+	return m.startOperation("read HEAD message", func(ctx context.Context) tea.Msg {
+		message, err := repo.HeadMessage(ctx)
+		return headMessageMsg{message: message, err: err}
+	})
+}
+
+// applyHeadMessage prefills the amend with HEAD's message, unless amend was
+// turned off or a message was typed meanwhile
+func (m *Model) applyHeadMessage(msg headMessageMsg) {
+	if !m.commitModalActive || !m.commitOpts.Amend || m.commitMessage() != "" {
+		return
+	}
+	if msg.err != nil {
+		m.commitError = msg.err.Error()
+		return
+	}
+	m.setCommitMessage(msg.message)
+	m.amendPrefill = m.commitMessage()
+}
+
+// executeCommit lints the message as git will record it and commits in the
+// background; the modal stays open so hook failures can be shown
+func (m *Model) executeCommit(message string, verbatim bool) tea.Cmd {
+	if m.repo == nil {
+		m.commitError = "Repository not available"
+		return nil
+	}
+
+	if message == "" {
+		m.commitError = "Commit message cannot be empty"
+		return nil
+	}
+	if !verbatim {
+		message = parser.CleanupCommitWhitespace(message)
+	}
+	m.commitIssues = m.commitLint.Lint(message)
+	if commitlint.HasErrors(m.commitIssues) {
+		m.commitError = "Fix the commit message errors first"
+		return nil
+	}
+	if !m.commitOpts.Amend && !m.hasStagedFiles() {
+		m.commitError = "Nothing staged (stage files, or amend with ctrl+r)"
+		return nil
+	}
+
+	m.commitError = ""
+	repo, opts, sections, args := m.repo, m.commitOpts, m.sections, m.diffArgs
+	opts.Message, opts.Verbatim = message, verbatim
+	return m.startOperation("commit", func(ctx context.Context) tea.Msg {
+		if err := repo.Commit(ctx, opts); err != nil {
+			return committedMsg{opts: opts, err: err}
+		}
+		return committedMsg{refresh: loadRefresh(ctx, repo, sections, args), opts: opts}
+	})
+}
+
+// openEditor hands the message to $EDITOR, starting an empty one from the
+// commit.template like git commit
+func (m *Model) openEditor() tea.Cmd {
+	reader, ok := m.repo.(parser.CommitTemplateReader)
+	if !ok || m.commitMessage() != "" {
+		return m.launchEditor("")
+	}
+	return m.startOperation("read commit template", func(ctx context.Context) tea.Msg {
+		template, _ := reader.CommitTemplate(ctx)
+		return commitTemplateMsg{template: template}
+	})
+}
+
+// launchEditor writes the message, or template, to a COMMIT_EDITMSG file and
+// opens it in $EDITOR; the TUI is suspended until the editor exits
+func (m *Model) launchEditor(template string) tea.Cmd {
+	dir, err := os.MkdirTemp("", "diff-tui-")
+	if err != nil {
+		m.commitError = err.Error()
+		return nil
+	}
+
+	path := filepath.Join(dir, "COMMIT_EDITMSG")
+	if err := os.WriteFile(path, []byte(m.commitTemplate(template)), 0o600); err != nil {
+		m.commitError = err.Error()
+		os.RemoveAll(dir)
+		return nil
+	}
+
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editorFinishedMsg{path: path, template: template, err: err}
+	})
+}
+
+// finishEditor reads the edited message back and commits it, like git commit does
+func (m *Model) finishEditor(msg editorFinishedMsg) tea.Cmd {
+	defer os.RemoveAll(filepath.Dir(msg.path))
+
+	if msg.err != nil {
+		m.commitError = fmt.Sprintf("Editor failed: %v", msg.err)
+		return nil
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.commitError = err.Error()
+		return nil
+	}
+
+	message := parser.CleanupCommitMessage(string(data))
+	m.setCommitMessage(message)
+	if message == "" {
+		m.commitError = "Aborting commit due to empty commit message"
+		return nil
+	}
+	if msg.template != "" && message == parser.CleanupCommitMessage(msg.template) {
+		m.commitError = "Aborting commit; you did not edit the message"
+		return nil
+	}
+
+	// Comments are stripped already; git must not strip lines starting with #
+	// that were left in on purpose, nor reformat what gets linted
+	return m.executeCommit(message, true)
+}
+
+// commitTemplate is the COMMIT_EDITMSG content: the current message (or the
+// commit.template) followed by the commented summary git itself writes
+func (m *Model) commitTemplate(template string) string {
+	var sb strings.Builder
+	if msg := m.commitMessage(); msg != "" {
+		sb.WriteString(msg + "\n")
+	} else if template != "" {
+		sb.WriteString(strings.TrimRight(template, "\n") + "\n")
+	}
+	sb.WriteString("\n# Please enter the commit message for your changes. Lines starting\n")
+	sb.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n#\n")
+	if m.commitOpts.Amend {
+		sb.WriteString("# Amending the previous commit.\n#\n")
+	}
+
+	sb.WriteString("# Changes to be committed:\n")
+	if m.status != nil {
+		for _, e := range m.status.Entries {
+			if e.IsStaged() {
+				fmt.Fprintf(&sb, "#\t%-12s%s\n", stagedChangeLabel(e.Index)+":", e.Path)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// stagedChangeLabel describes an index status the way git status does
+func stagedChangeLabel(code parser.StatusCode) string {
+	switch code {
+	case parser.StatusAdded:
+		return "new file"
+	case parser.StatusDeleted:
+		return "deleted"
+	case parser.StatusRenamed:
+		return "renamed"
+	case parser.StatusCopied:
+		return "copied"
+	case parser.StatusTypeChanged:
+		return "typechange"
+	}
+	return "modified"
+}
+
+// editorCommand runs the user's editor through the shell so values like
+// "code --wait" work, following git's GIT_EDITOR, VISUAL, EDITOR order
+func editorCommand(path string) *exec.Cmd {
+	editor := "vi"
+	for _, name := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if v := os.Getenv(name); v != "" {
+			editor = v
+			break
+		}
+	}
+	return exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+}
+
+// renderCommitModal renders the commit message modal overlay
+func (m Model) renderCommitModal(background string) string {
+	// Modal title
+	titleText := "Commit"
+	if m.commitOpts.Amend {
+		titleText = "Amend Commit"
+	}
+	title := ModalTitleStyle.Render(titleText)
+
+	// Subject and body
+	subject := m.commitSubject.View()
+	subjectInfo := ModalHelpStyle.MarginTop(0).Render(fmt.Sprintf("%d chars", len([]rune(m.commitSubject.Value()))))
+	body := m.commitBody.View()
+
+	// Progress of a running commit
+	if m.op != nil {
+		subjectInfo = ModalHelpStyle.MarginTop(0).Render(m.operationStatus())
+	}
+
+	// Option toggles
+	toggles := ModalHelpStyle.Render(strings.Join([]string{
+		commitToggle("amend", m.commitOpts.Amend),
+		commitToggle("sign-off", m.commitOpts.SignOff),
+		commitToggle("no-verify", m.commitOpts.NoVerify),
+	}, "  "))
+
+	parts := []string{title, subject, subjectInfo, body, toggles}
+
+	// Error message and lint results if any
+	if m.commitError != "" {
+		parts = append(parts, ModalErrorStyle.Render(m.commitError))
+	}
+	if len(m.commitIssues) > 0 {
+		var issues []string
+		for _, issue := range m.commitIssues {
+			style := ModalErrorStyle
+			if issue.Severity == commitlint.SeverityWarning {
+				style = ModalWarningStyle
+			}
+			issues = append(issues, style.MarginTop(0).Render(issue.Severity.String()+": "+issue.String()))
+		}
+		parts = append(parts, lipgloss.NewStyle().MarginTop(1).Render(strings.Join(issues, "\n")))
+	}
+
+	// Help text
+	parts = append(parts, ModalHelpStyle.Render(
+		"Enter/Ctrl+s: commit | Tab: subject/body | Esc: cancel\n"+
+			"Ctrl+r: amend | Ctrl+o: sign-off | Ctrl+n: no-verify | Ctrl+x: $EDITOR"))
+
+	// Style and size the modal
+	modal := ModalStyle.Width(commitModalWidth).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+
+	// Center modal on screen
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
+
+func commitToggle(label string, on bool) string {
+	if on {
+		return "[x] " + label
+	}
+	return "[ ] " + label
+}
@@ -0,0 +1,443 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resolution is how one conflict region is resolved
+type resolution int
+
+const (
+	unresolved resolution = iota
+	takeOurs
+	takeTheirs
+	takeBoth // Ours followed by theirs
+	takeBase
+)
+
+func (r resolution) String() string {
+	switch r {
+	case takeOurs:
+		return "ours"
+	case takeTheirs:
+		return "theirs"
+	case takeBoth:
+		return "both"
+	case takeBase:
+		return "base"
+	}
+	return "unresolved"
+}
+
+// lines returns the lines a region resolves to
+func (r resolution) lines(c diff.MergeChunk) []string {
+	switch r {
+	case takeOurs:
+		return c.Ours
+	case takeTheirs:
+		return c.Theirs
+	case takeBoth:
+		return append(append([]string{}, c.Ours...), c.Theirs...)
+	case takeBase:
+		return c.Base
+	}
+	return nil
+}
+
+// conflictFile is an unmerged file resolved one region at a time; a deleted or
+// binary file is a single region
+type conflictFile struct {
+	entry    parser.FileStatus
+	stages   *parser.ConflictStages
+	whole    bool
+	binary   bool
+	chunks   []diff.MergeChunk
+	regions  []int              // Indexes of the conflict chunks
+	picks    map[int]resolution // By chunk index
+	rows     []int              // Row of each region's header in the diff
+	selected int                // Index into regions
+}
+
+// conflictsMsg carries the unmerged files loaded for conflict mode
+type conflictsMsg struct {
+	status *parser.Status
+	files  []*conflictFile
+	err    error
+}
+
+// conflictResolvedMsg reports a file written and marked resolved, plus the
+// status reloaded after it
+type conflictResolvedMsg struct {
+	path     string
+	resolved bool
+	status   *parser.Status
+	err      error
+}
+
+func newConflictFile(entry parser.FileStatus, stages *parser.ConflictStages) *conflictFile {
+	c := &conflictFile{entry: entry, stages: stages, picks: make(map[int]resolution)}
+	c.binary = isBinaryContent(stages.Base) || isBinaryContent(stages.Ours) || isBinaryContent(stages.Theirs)
+	c.whole = c.binary || stages.Ours == nil || stages.Theirs == nil
+
+	if c.whole {
+		c.chunks = []diff.MergeChunk{{
+			Conflict: true,
+			Base:     c.describe(stages.Base),
+			Ours:     c.describe(stages.Ours),
+			Theirs:   c.describe(stages.Theirs),
+		}}
+	} else {
+		base := parser.ConflictLines(stages.Base)
+		c.chunks = diff.Merge3(base, parser.ConflictLines(stages.Ours), parser.ConflictLines(stages.Theirs))
+	}
+	for i, chunk := range c.chunks {
+		if chunk.Conflict {
+			c.regions = append(c.regions, i)
+		}
+	}
+	return c
+}
+
+// isBinaryContent guesses binary content the way git does, by looking for a
+// NUL byte near the start
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// describe returns the lines shown for one side of a whole-file region
+func (c *conflictFile) describe(content []byte) []string {
+	switch {
+	case content == nil:
+		return []string{"(deleted)\n"}
+	case c.binary:
+		return []string{fmt.Sprintf("(binary, %d bytes)\n", len(content))}
+	}
+	return parser.ConflictLines(content)
+}
+
+// unresolved counts the regions without a resolution
+func (c *conflictFile) unresolved() int {
+	n := 0
+	for _, i := range c.regions {
+		if c.picks[i] == unresolved {
+			n++
+		}
+	}
+	return n
+}
+
+// nextUnresolved returns the first unresolved region after the selected one,
+// wrapping around, or -1 if all are resolved
+func (c *conflictFile) nextUnresolved() int {
+	for step := 1; step <= len(c.regions); step++ {
+		k := (c.selected + step) % len(c.regions)
+		if c.picks[c.regions[k]] == unresolved {
+			return k
+		}
+	}
+	return -1
+}
+
+// result returns the resolved file; nil means the file is deleted
+func (c *conflictFile) result() []byte {
+	if c.whole {
+		var content []byte
+		switch c.picks[0] {
+		case takeOurs:
+			content = c.stages.Ours
+		case takeTheirs:
+			content = c.stages.Theirs
+		case takeBoth:
+			if c.stages.Ours != nil || c.stages.Theirs != nil {
+				content = append(append([]byte{}, c.stages.Ours...), c.stages.Theirs...)
+			}
+		case takeBase:
+			content = c.stages.Base
+		}
+		return content
+	}
+
+	var sb strings.Builder
+	for i, chunk := range c.chunks {
+		lines := chunk.Result()
+		if chunk.Conflict {
+			lines = c.picks[i].lines(chunk)
+		}
+		for _, line := range lines {
+			sb.WriteString(line)
+		}
+	}
+	return []byte(sb.String())
+}
+
+// fileDiff lays the file out with ours on the left and theirs on the right,
+// numbering merged lines by the result and heading unresolved regions
+func (c *conflictFile) fileDiff() diff.FileDiff {
+	file := diff.FileDiff{Name: c.stages.Path, IsBinary: c.binary}
+	c.rows = c.rows[:0]
+	number := 0
+
+	merged := func(lines []string) {
+		for _, text := range lines {
+			number++
+			line := diff.Line{Type: diff.Context, Content: strings.TrimSuffix(text, "\n"), Number: number}
+			file.LeftLines = append(file.LeftLines, line)
+			file.RightLines = append(file.RightLines, line)
+		}
+	}
+	shared := func(lines []string) {
+		for _, text := range lines {
+			line := diff.Line{Type: diff.Context, Content: strings.TrimSuffix(text, "\n")}
+			file.LeftLines = append(file.LeftLines, line)
+			file.RightLines = append(file.RightLines, line)
+		}
+	}
+	side := func(lines []string, typ diff.LineType, rows int) []diff.Line {
+		out := make([]diff.Line, rows)
+		for i := range out {
+			out[i] = diff.Line{Type: diff.Placeholder}
+			if i < len(lines) {
+				out[i] = diff.Line{Type: typ, Content: strings.TrimSuffix(lines[i], "\n")}
+			}
+		}
+		return out
+	}
+
+	for i, chunk := range c.chunks {
+		if !chunk.Conflict {
+			merged(chunk.Result())
+			continue
+		}
+
+		k := len(c.rows)
+		marker := "  "
+		if k == c.selected {
+			marker = "▶ "
+		}
+		header := diff.Line{Type: diff.Context, Content: fmt.Sprintf("%s── conflict %d/%d: %s", marker, k+1, len(c.regions), c.picks[i])}
+		c.rows = append(c.rows, len(file.LeftLines))
+		file.LeftLines = append(file.LeftLines, header)
+		file.RightLines = append(file.RightLines, header)
+
+		if pick := c.picks[i]; pick != unresolved {
+			merged(pick.lines(chunk))
+			continue
+		}
+		if k == c.selected {
+			// The base of the selected region is shown above the two sides,
+			// unnumbered, as it is not part of the result
+			label := "   base:"
+			if len(chunk.Base) == 0 {
+				label = "   base: (empty)"
+			}
+			shared(append([]string{label}, chunk.Base...))
+			shared([]string{"   ours | theirs:"})
+		}
+		rows := max(len(chunk.Ours), len(chunk.Theirs))
+		file.LeftLines = append(file.LeftLines, side(chunk.Ours, diff.Delete, rows)...)
+		file.RightLines = append(file.RightLines, side(chunk.Theirs, diff.Add, rows)...)
+		file.DelCount += len(chunk.Ours)
+		file.AddCount += len(chunk.Theirs)
+	}
+	return file
+}
+
+// openConflicts loads the unmerged files into conflict mode
+func (m *Model) openConflicts() tea.Cmd {
+	resolver, ok := m.repo.(parser.Resolver)
+	if !ok {
+		return m.notify(SeverityWarning, "This repository does not support resolving conflicts")
+	}
+	repo := m.repo
+	return m.startOperation("load conflicts", func(ctx context.Context) tea.Msg {
+		status, err := repo.Status(ctx)
+		if err != nil {
+			return conflictsMsg{err: err}
+		}
+		var files []*conflictFile
+		for _, entry := range status.Conflicts() {
+			stages, err := resolver.ConflictStages(ctx, entry)
+			if err != nil {
+				return conflictsMsg{err: err}
+			}
+			files = append(files, newConflictFile(entry, stages))
+		}
+		return conflictsMsg{status: status, files: files}
+	})
+}
+
+// applyConflicts shows the unmerged files in conflict mode
+func (m *Model) applyConflicts(msg conflictsMsg) tea.Cmd {
+	m.status = msg.status
+	if len(msg.files) == 0 {
+		return m.notify(SeverityInfo, "No merge conflicts")
+	}
+	conflicts := make(map[string]*conflictFile, len(msg.files))
+	files := make([]diff.FileDiff, len(msg.files))
+	for i, c := range msg.files {
+		conflicts[c.stages.Path] = c
+		files[i] = c.fileDiff()
+	}
+	m.showConflicts(conflicts, files)
+	return nil
+}
+
+// showConflicts shows files as the detached conflict view
+func (m *Model) showConflicts(conflicts map[string]*conflictFile, files []diff.FileDiff) {
+	m.showDetached("Conflicts", nil, "", files)
+	m.conflicts = conflicts
+	m.resize()
+}
+
+// selectedConflict returns the unmerged file under the selection
+func (m Model) selectedConflict() *conflictFile {
+	if m.selectedIdx >= len(m.visibleNodes) || m.visibleNodes[m.selectedIdx].File == nil {
+		return nil
+	}
+	return m.conflicts[m.visibleNodes[m.selectedIdx].File.Name]
+}
+
+// updateConflicts handles the keys of conflict mode; handled is false for
+// keys that keep their usual meaning
+func (m *Model) updateConflicts(msg tea.KeyMsg) (cmd tea.Cmd, handled bool) {
+	switch {
+	case key.Matches(msg, m.keys.TakeOurs):
+		return m.pickResolution(takeOurs), true
+	case key.Matches(msg, m.keys.TakeTheirs):
+		return m.pickResolution(takeTheirs), true
+	case key.Matches(msg, m.keys.TakeBoth):
+		return m.pickResolution(takeBoth), true
+	case key.Matches(msg, m.keys.TakeBase):
+		return m.pickResolution(takeBase), true
+	case key.Matches(msg, m.keys.NextConflict):
+		m.stepConflict(1)
+		return nil, true
+	case key.Matches(msg, m.keys.PrevConflict):
+		m.stepConflict(-1)
+		return nil, true
+	case key.Matches(msg, m.keys.WriteResolved):
+		return m.writeResolution(), true
+	}
+	return nil, false
+}
+
+// pickResolution resolves the selected region and moves on to the next
+// unresolved one
+func (m *Model) pickResolution(r resolution) tea.Cmd {
+	c := m.selectedConflict()
+	if c == nil || len(c.regions) == 0 {
+		return nil
+	}
+	if r == takeBoth && c.binary {
+		return m.notify(SeverityWarning, "Binary files cannot be combined")
+	}
+	c.picks[c.regions[c.selected]] = r
+	if next := c.nextUnresolved(); next >= 0 {
+		c.selected = next
+	}
+	m.updateConflictFile(c)
+	return nil
+}
+
+// stepConflict selects the next or previous region of the selected file
+func (m *Model) stepConflict(delta int) {
+	c := m.selectedConflict()
+	if c == nil || len(c.regions) == 0 {
+		return
+	}
+	c.selected = (c.selected + delta + len(c.regions)) % len(c.regions)
+	m.updateConflictFile(c)
+}
+
+// updateConflictFile redraws c and scrolls to its selected region
+func (m *Model) updateConflictFile(c *conflictFile) {
+	for i := range m.files {
+		if m.files[i].Name == c.stages.Path {
+			m.files[i] = c.fileDiff()
+		}
+	}
+	m.resize()
+	if c.selected < len(c.rows) {
+		row := max(c.rows[c.selected]-3, 0)
+		m.leftViewport.SetYOffset(row)
+		m.rightViewport.SetYOffset(row)
+	}
+}
+
+// writeResolution writes the selected file's resolution to the worktree and
+// marks it resolved
+func (m *Model) writeResolution() tea.Cmd {
+	c := m.selectedConflict()
+	if c == nil {
+		return nil
+	}
+	path := c.stages.Path
+	if left := c.unresolved(); left > 0 {
+		return m.notifyf(SeverityWarning, "%s has %d unresolved conflicts", path, left)
+	}
+	resolver, repo, content := m.repo.(parser.Resolver), m.repo, c.result()
+	return m.startOperation("resolve "+path, func(ctx context.Context) tea.Msg {
+		if err := resolver.Resolve(ctx, path, content); err != nil {
+			return conflictResolvedMsg{path: path, err: err}
+		}
+		status, err := repo.Status(ctx)
+		return conflictResolvedMsg{path: path, resolved: true, status: status, err: err}
+	})
+}
+
+// applyConflictResolved drops a resolved file from conflict mode, leaving it
+// once no files are left
+func (m *Model) applyConflictResolved(msg conflictResolvedMsg) tea.Cmd {
+	if msg.status != nil {
+		m.status = msg.status
+	}
+	delete(m.conflicts, msg.path)
+	if len(m.conflicts) == 0 {
+		return tea.Batch(m.notify(SeverityInfo, "All conflicts resolved"), m.leaveDetached())
+	}
+
+	var files []diff.FileDiff
+	for _, f := range m.files {
+		if f.Name != msg.path {
+			files = append(files, f)
+		}
+	}
+	m.showConflicts(m.conflicts, files)
+	return m.notify(SeverityInfo, "Resolved "+msg.path)
+}
+
+// conflictHeader is the key help and the selected file's progress
+func (m Model) conflictHeader() []string {
+	header := []string{"1: ours  2: theirs  3: both  4: base | ctrl+n/ctrl+p: next/previous conflict | w: write and mark resolved | esc: leave"}
+	c := m.selectedConflict()
+	switch {
+	case c == nil:
+	case len(c.regions) == 0:
+		header = append(header, fmt.Sprintf("%s (%s): merges cleanly, w marks it resolved", c.stages.Path, c.entry.ConflictDescription()))
+	default:
+		header = append(header, fmt.Sprintf("%s (%s): %d of %d conflicts left", c.stages.Path, c.entry.ConflictDescription(), c.unresolved(), len(c.regions)))
+	}
+	return header
+}
+
+// conflictNotice is the startup warning about unmerged files, or "" if there
+// are none
+func conflictNotice(status *parser.Status) string {
+	switch n := len(status.Conflicts()); n {
+	case 0:
+		return ""
+	case 1:
+		return "1 file has merge conflicts; press M to resolve it"
+	default:
+		return fmt.Sprintf("%d files have merge conflicts; press M to resolve them", n)
+	}
+}
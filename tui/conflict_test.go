@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+)
+
+// conflictRepo is a memrepo.Repo in the middle of a merge: a.txt was changed
+// on both sides and b.txt was deleted by them
+type conflictRepo struct {
+	*memrepo.Repo
+	resolved map[string][]byte
+}
+
+func (r conflictRepo) Status(ctx context.Context) (*parser.Status, error) {
+	records := map[string]string{
+		"a.txt": "u UU N... 100644 100644 100644 100644 1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 3333333333333333333333333333333333333333 a.txt",
+		"b.txt": "u UD N... 100644 100644 000000 100644 4444444444444444444444444444444444444444 5555555555555555555555555555555555555555 0000000000000000000000000000000000000000 b.txt",
+	}
+	var input string
+	for _, path := range []string{"a.txt", "b.txt"} {
+		if _, done := r.resolved[path]; !done {
+			input += records[path] + "\x00"
+		}
+	}
+	return parser.ParseStatus(input)
+}
+
+func (r conflictRepo) ConflictStages(ctx context.Context, entry parser.FileStatus) (*parser.ConflictStages, error) {
+	if entry.Path == "b.txt" {
+		return &parser.ConflictStages{Path: "b.txt", Base: []byte("b\n"), Ours: []byte("b\nmore\n")}, nil
+	}
+	return &parser.ConflictStages{
+		Path:   "a.txt",
+		Base:   []byte("one\ntwo\nthree\nfour\nfive\n"),
+		Ours:   []byte("ONE\ntwo\nthree\nfour\nfive ours\n"),
+		Theirs: []byte("one\ntwo\nthree\nfour\nfive theirs\n"),
+	}, nil
+}
+
+func (r conflictRepo) Resolve(ctx context.Context, path string, content []byte) error {
+	r.resolved[path] = content
+	return nil
+}
+
+func TestConflictFile_Result(t *testing.T) {
+	stages := &parser.ConflictStages{
+		Path:   "a.txt",
+		Base:   []byte("a\nb\nc\n"),
+		Ours:   []byte("a\nB1\nc\n"),
+		Theirs: []byte("a\nB2\nc\n"),
+	}
+	c := newConflictFile(parser.FileStatus{Path: "a.txt"}, stages)
+	if len(c.regions) != 1 || c.unresolved() != 1 {
+		t.Fatalf("regions %v, %d unresolved", c.regions, c.unresolved())
+	}
+
+	tests := []struct {
+		pick resolution
+		want string
+	}{
+		{takeOurs, "a\nB1\nc\n"},
+		{takeTheirs, "a\nB2\nc\n"},
+		{takeBoth, "a\nB1\nB2\nc\n"},
+		{takeBase, "a\nb\nc\n"},
+	}
+	for _, tt := range tests {
+		c.picks[c.regions[0]] = tt.pick
+		if got := string(c.result()); got != tt.want {
+			t.Errorf("%v: result = %q, want %q", tt.pick, got, tt.want)
+		}
+	}
+
+	// Taking the side that deleted the file deletes it
+	deleted := newConflictFile(parser.FileStatus{Path: "b.txt"}, &parser.ConflictStages{Path: "b.txt", Base: []byte("b\n"), Ours: []byte("b\n")})
+	deleted.picks[0] = takeTheirs
+	if !deleted.whole || deleted.result() != nil {
+		t.Errorf("expected a whole-file region resolving to a deletion")
+	}
+}
+
+func TestModel_ResolveConflicts(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n", "b.txt": "b\n"})
+	m := newTestModel(t, repo)
+	resolver := conflictRepo{Repo: repo, resolved: make(map[string][]byte)}
+	m.repo = resolver
+
+	m = pressKey(m, "M")
+	if m.conflicts == nil || len(m.files) != 2 {
+		t.Fatalf("expected conflict mode with 2 files, got %d files", len(m.files))
+	}
+	view := m.View()
+	if !strings.Contains(view, "Ours") || !strings.Contains(view, "Theirs") || !strings.Contains(view, "1 of 1 conflicts left") {
+		t.Fatal("expected the conflict view of a.txt")
+	}
+
+	if !strings.Contains(m.View(), "   base:") {
+		t.Error("expected the base of the selected region")
+	}
+
+	// The global keys still work, so git add failures can be read
+	m = pressKey(m, "o")
+	if m.bottom != BottomOutput {
+		t.Error("expected o to open the output panel in conflict mode")
+	}
+	m = pressKey(m, "o")
+
+	// Writing is refused while regions are unresolved
+	m = pressKey(m, "w")
+	if len(resolver.resolved) != 0 {
+		t.Fatal("wrote a file with unresolved conflicts")
+	}
+
+	// The clean change from ours is kept whatever the conflict resolves to
+	m = pressKey(m, "2")
+	if !strings.Contains(m.View(), "0 of 1 conflicts left") {
+		t.Error("expected the region to be resolved")
+	}
+	m = pressKey(m, "w")
+	if got := string(resolver.resolved["a.txt"]); got != "ONE\ntwo\nthree\nfour\nfive theirs\n" {
+		t.Fatalf("a.txt resolved to %q", got)
+	}
+	if len(m.files) != 1 || m.files[0].Name != "b.txt" {
+		t.Fatalf("expected only b.txt left, got %d files", len(m.files))
+	}
+
+	// Keeping the deletion removes the file and ends conflict mode
+	m = pressKey(m, "2")
+	m = pressKey(m, "w")
+	if content, ok := resolver.resolved["b.txt"]; !ok || content != nil {
+		t.Fatalf("b.txt resolved to %q", content)
+	}
+	if m.conflicts != nil || m.detached != nil || !m.sections {
+		t.Error("expected to be back in the working tree view")
+	}
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"strings"
+
+	"diff-tui/diff"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHeaderLines bounds the metadata shown above the diffs
+const maxHeaderLines = 8
+
+// detachedView is a fixed diff, like a stash or a commit, shown in place of
+// the normal view it goes back to
+type detachedView struct {
+	title    string   // File list title
+	header   []string // Metadata shown above the diffs
+	base     string   // Revision of the original side, for blame; empty if none
+	commit   string   // Commit shown, whose review notes are loaded; empty if none
+	sections bool
+	args     []string
+}
+
+// showDetached loads files into the file tree and diff panels; base is the
+// revision of their original side
+func (m *Model) showDetached(title string, header []string, base string, files []diff.FileDiff) {
+	if m.detached == nil {
+		m.detached = &detachedView{sections: m.sections, args: m.diffArgs}
+	}
+	m.conflicts = nil
+	m.history = nil
+	m.detached.title = title
+	m.detached.header = header
+	m.detached.base = base
+	m.detached.commit = ""
+	m.staleNotes()
+
+	m.sections = false
+	m.files = files
+	m.treeRoots = BuildTree(m.files, m.rootName)
+	m.visibleNodes = FlattenVisible(m.treeRoots)
+	m.selectFileNear(0)
+	m.resize()
+}
+
+// leaveDetached goes back to the view the detached diff replaced
+func (m *Model) leaveDetached() tea.Cmd {
+	m.restoreView()
+	return m.refreshDiff()
+}
+
+// restoreView forgets the detached diff; the caller reloads the view
+func (m *Model) restoreView() {
+	if m.detached == nil {
+		return
+	}
+	m.sections = m.detached.sections
+	m.diffArgs = m.detached.args
+	m.detached = nil
+	m.conflicts = nil
+	m.history = nil
+	m.resize()
+}
+
+// returnView is the view shown once any detached diff is left
+func (m *Model) returnView() (sections bool, args []string) {
+	if m.detached != nil {
+		return m.detached.sections, m.detached.args
+	}
+	return m.sections, m.diffArgs
+}
+
+// resize fits the diff panels to the space left by the header and bottom panel
+func (m *Model) resize() {
+	if m.ready {
+		m.updateViewportSizes()
+		m.updateDiffContent()
+	}
+}
+
+// headerLines is the metadata shown above the diffs: conflict mode's help, the
+// detached diff's, or the review step's
+func (m Model) headerLines() []string {
+	var header []string
+	switch {
+	case m.conflicts != nil:
+		header = m.conflictHeader()
+	case m.detached != nil:
+		header = m.detached.header
+	case m.review != nil && m.compare == nil:
+		header = m.review.header()
+	}
+	if len(header) > maxHeaderLines {
+		header = append(header[:maxHeaderLines-1:maxHeaderLines-1], "    ...")
+	}
+	return header
+}
+
+// headerHeight is the height of the metadata header, 0 when there is none
+func (m Model) headerHeight() int {
+	if n := len(m.headerLines()); n > 0 {
+		return n + 2
+	}
+	return 0
+}
+
+// renderHeader renders the metadata above the diffs
+func (m Model) renderHeader(width int) string {
+	header := m.headerLines()
+	lines := make([]string, len(header))
+	for i, line := range header {
+		lines[i] = truncate(line, width-2)
+	}
+	return PanelStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
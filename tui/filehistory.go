@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// breadcrumbSubjects is how many newer commits the history breadcrumb shows
+// before the one on screen
+const breadcrumbSubjects = 2
+
+// fileHistory is the history of one file, shown a commit at a time
+type fileHistory struct {
+	path      string                // Path the history was opened for
+	revisions []parser.FileRevision // Newest first
+	index     int
+}
+
+// fileHistoryMsg carries one commit of a file's history
+type fileHistoryMsg struct {
+	history *fileHistory
+	index   int
+	files   []diff.FileDiff
+	err     error
+}
+
+// openFileHistory loads the history of the selected file and shows its
+// newest commit
+func (m *Model) openFileHistory() tea.Cmd {
+	reader, ok := m.repo.(parser.FileHistoryReader)
+	if !ok {
+		return m.notify(SeverityWarning, "This repository does not support file history")
+	}
+	if m.selectedIdx >= len(m.visibleNodes) || m.visibleNodes[m.selectedIdx].File == nil {
+		return nil
+	}
+	path := m.visibleNodes[m.selectedIdx].File.Name
+	return m.startOperation("history "+path, func(ctx context.Context) tea.Msg {
+		revisions, err := reader.FileHistory(ctx, path)
+		if err != nil || len(revisions) == 0 {
+			return fileHistoryMsg{history: &fileHistory{path: path}, err: err}
+		}
+		msg := fileHistoryMsg{history: &fileHistory{path: path, revisions: revisions}}
+		msg.files, msg.err = reader.FileRevisionDiff(ctx, revisions[0])
+		return msg
+	})
+}
+
+// stepFileHistory shows the next older (delta 1) or newer (delta -1) commit
+// of the file
+func (m *Model) stepFileHistory(delta int) tea.Cmd {
+	h := m.history
+	reader, ok := m.repo.(parser.FileHistoryReader)
+	if h == nil || !ok {
+		return nil
+	}
+	index := h.index + delta
+	switch {
+	case index >= len(h.revisions):
+		return m.notify(SeverityInfo, "This is the commit that added "+h.path)
+	case index < 0:
+		return m.notify(SeverityInfo, "This is the newest commit of "+h.path)
+	}
+	rev := h.revisions[index]
+	return m.startOperation("show "+rev.ShortHash, func(ctx context.Context) tea.Msg {
+		files, err := reader.FileRevisionDiff(ctx, rev)
+		return fileHistoryMsg{history: h, index: index, files: files, err: err}
+	})
+}
+
+// applyFileHistory shows one commit's change to the file
+func (m *Model) applyFileHistory(msg fileHistoryMsg) tea.Cmd {
+	h := msg.history
+	if len(h.revisions) == 0 {
+		return m.notifyf(SeverityInfo, "%s has no committed history", h.path)
+	}
+	h.index = msg.index
+	rev := h.revisions[h.index]
+	var parent string
+	if len(rev.Parents) > 0 {
+		parent = rev.Parents[0]
+	}
+	m.showDetached(fmt.Sprintf("History %d/%d", h.index+1, len(h.revisions)), h.header(time.Now()), parent, msg.files)
+	m.detached.commit = rev.Hash
+	m.history = h
+	return nil
+}
+
+// header describes the commit shown, with a breadcrumb of the newer commits
+// leading to it
+func (h *fileHistory) header(now time.Time) []string {
+	rev := h.revisions[h.index]
+	lines := []string{
+		fmt.Sprintf("History of %s, commit %d of %d   [: older  ]: newer  esc: back", h.path, h.index+1, len(h.revisions)),
+		h.breadcrumb(),
+		fmt.Sprintf("%s %s <%s>, %s", rev.ShortHash, rev.Author, rev.Email, relativeTime(rev.Date, now)),
+	}
+	switch {
+	case rev.OldPath != "":
+		lines = append(lines, fmt.Sprintf("Renamed from %s to %s", rev.OldPath, rev.Path))
+	case rev.Path != h.path:
+		lines = append(lines, "Then named "+rev.Path)
+	}
+	return lines
+}
+
+// breadcrumb lists the subjects from the newest commit to the one shown
+func (h *fileHistory) breadcrumb() string {
+	start := max(h.index-breadcrumbSubjects, 0)
+	var crumbs []string
+	if start > 0 {
+		crumbs = append(crumbs, "...")
+	}
+	for i := start; i <= h.index; i++ {
+		subject := []rune(h.revisions[i].Subject)
+		if len(subject) > 30 {
+			subject = append(subject[:29], '~')
+		}
+		crumbs = append(crumbs, string(subject))
+	}
+	crumbs[len(crumbs)-1] = "▶ " + crumbs[len(crumbs)-1]
+	return strings.Join(crumbs, " › ")
+}
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyRepo is a memrepo.Repo where a.txt was added as old.txt and renamed
+type historyRepo struct {
+	*memrepo.Repo
+}
+
+func (r historyRepo) FileHistory(ctx context.Context, path string) ([]parser.FileRevision, error) {
+	return []parser.FileRevision{
+		{LogEntry: parser.LogEntry{Hash: "2222", ShortHash: "2222", Subject: "Rename old to a", Parents: []string{"1111"}}, Path: path, OldPath: "old.txt"},
+		{LogEntry: parser.LogEntry{Hash: "1111", ShortHash: "1111", Subject: "Add old"}, Path: "old.txt"},
+	}, nil
+}
+
+func (r historyRepo) FileRevisionDiff(ctx context.Context, rev parser.FileRevision) ([]diff.FileDiff, error) {
+	return []diff.FileDiff{{Name: rev.Path, RightLines: []diff.Line{{Type: diff.Add, Content: rev.Subject, Number: 1}}}}, nil
+}
+
+func TestModel_FileHistory(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	m := newTestModel(t, repo)
+	m.repo = historyRepo{repo}
+
+	m = pressKey(m, "F")
+	if m.history == nil || m.detached == nil || m.detached.title != "History 1/2" || m.detached.base != "1111" {
+		t.Fatal("expected the newest commit of a.txt")
+	}
+	if header := strings.Join(m.headerLines(), "\n"); !strings.Contains(header, "▶ Rename old to a") || !strings.Contains(header, "Renamed from old.txt to a.txt") {
+		t.Errorf("header = %q", header)
+	}
+
+	m = pressKey(m, "[")
+	if m.detached.title != "History 2/2" || m.visibleNodes[m.selectedIdx].File.Name != "old.txt" {
+		t.Fatal("expected the commit that added old.txt")
+	}
+	if header := strings.Join(m.headerLines(), "\n"); !strings.Contains(header, "Rename old to a › ▶ Add old") || !strings.Contains(header, "Then named old.txt") {
+		t.Errorf("header = %q", header)
+	}
+
+	// There is nothing older
+	m = pressKey(m, "[")
+	if m.detached.title != "History 2/2" {
+		t.Errorf("stepped past the oldest commit to %q", m.detached.title)
+	}
+
+	m = pressKey(m, "]")
+	if m.detached.title != "History 1/2" {
+		t.Errorf("title = %q", m.detached.title)
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.history != nil || m.detached != nil || !m.sections {
+		t.Error("expected esc to go back to the working tree")
+	}
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore matches the runes of pattern in order within text, ignoring case
+// and favouring consecutive, word-start and early matches
+func fuzzyScore(pattern, text string) (score int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	t := []rune(strings.ToLower(text))
+	pi, last := 0, -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 5
+		}
+		if ti == 0 || isWordSeparator(t[ti-1]) {
+			score += 3
+		}
+		if pi == 0 {
+			score -= min(ti, 10)
+		}
+		last = ti
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// fuzzyFilter returns the indexes of the texts matching pattern, best first;
+// ties keep their original order
+func fuzzyFilter(pattern string, texts []string) []int {
+	type match struct{ index, score int }
+	var matches []match
+	for i, text := range texts {
+		if score, ok := fuzzyScore(pattern, text); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.index
+	}
+	return indexes
+}
@@ -0,0 +1,39 @@
+package tui
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("fbr", "feature/bar"); !ok {
+		t.Error("expected a subsequence to match")
+	}
+	if _, ok := fuzzyScore("rbf", "feature/bar"); ok {
+		t.Error("expected out-of-order runes not to match")
+	}
+	if _, ok := fuzzyScore("MAIN", "origin/main"); !ok {
+		t.Error("expected matching to ignore case")
+	}
+
+	exact, _ := fuzzyScore("main", "main")
+	scattered, _ := fuzzyScore("main", "my-animation")
+	if exact <= scattered {
+		t.Errorf("consecutive match scored %d, scattered %d", exact, scattered)
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	texts := []string{"release/2.0", "main", "origin/main", "maintenance"}
+	got := fuzzyFilter("main", texts)
+	want := []int{1, 3, 2}
+	if len(got) != len(want) {
+		t.Fatalf("fuzzyFilter = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("fuzzyFilter = %v, want %v", got, want)
+		}
+	}
+
+	if all := fuzzyFilter("", texts); len(all) != len(texts) {
+		t.Errorf("empty pattern should keep everything, got %v", all)
+	}
+}
@@ -4,21 +4,52 @@ import "github.com/charmbracelet/bubbles/key"
 
 // KeyMap defines all key bindings
 type KeyMap struct {
-	Up           key.Binding
-	Down         key.Binding
-	Left         key.Binding
-	Right        key.Binding
-	Enter        key.Binding
-	Tab          key.Binding
-	ShiftTab     key.Binding
-	Quit         key.Binding
-	PageUp       key.Binding
-	PageDown     key.Binding
-	HalfPageUp   key.Binding
-	HalfPageDown key.Binding
-	SyncToggle   key.Binding
-	Stage        key.Binding
-	Commit       key.Binding
+	Up            key.Binding
+	Down          key.Binding
+	Left          key.Binding
+	Right         key.Binding
+	Enter         key.Binding
+	Tab           key.Binding
+	ShiftTab      key.Binding
+	Quit          key.Binding
+	PageUp        key.Binding
+	PageDown      key.Binding
+	HalfPageUp    key.Binding
+	HalfPageDown  key.Binding
+	SyncToggle    key.Binding
+	Stage         key.Binding
+	Commit        key.Binding
+	Cancel        key.Binding
+	OutputToggle  key.Binding
+	CommandLog    key.Binding
+	Notifications key.Binding
+	StashPanel    key.Binding
+	StashFile     key.Binding
+	LogPanel      key.Binding
+	RefPicker     key.Binding
+	SwapSides     key.Binding
+	ThreeDot      key.Binding
+	NextCommit    key.Binding
+	PrevCommit    key.Binding
+	ToggleReview  key.Binding
+	RangeDiff     key.Binding
+	Blame         key.Binding
+	FileHistory   key.Binding
+	OlderRevision key.Binding
+	NewerRevision key.Binding
+	ThreePane     key.Binding
+	ConflictMode  key.Binding
+	ToggleViewed  key.Binding
+	AddComment    key.Binding
+	Comments      key.Binding
+	Export        key.Binding
+	TakeOurs      key.Binding
+	TakeTheirs    key.Binding
+	TakeBoth      key.Binding
+	TakeBase      key.Binding
+	NextConflict  key.Binding
+	PrevConflict  key.Binding
+	WriteResolved key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -83,6 +114,130 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "commit"),
 	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel running command"),
+	),
+	OutputToggle: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "toggle output panel"),
+	),
+	CommandLog: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "toggle command log"),
+	),
+	Notifications: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "notifications"),
+	),
+	StashPanel: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "stashes"),
+	),
+	StashFile: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "stash selected"),
+	),
+	LogPanel: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "history"),
+	),
+	RefPicker: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "compare revisions"),
+	),
+	SwapSides: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "swap sides"),
+	),
+	ThreeDot: key.NewBinding(
+		key.WithKeys("."),
+		key.WithHelp(".", "two/three-dot"),
+	),
+	NextCommit: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next commit"),
+	),
+	PrevCommit: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous commit"),
+	),
+	ToggleReview: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "all commits/one commit"),
+	),
+	RangeDiff: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "range-diff pairs"),
+	),
+	Blame: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "blame original side"),
+	),
+	FileHistory: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "file history"),
+	),
+	OlderRevision: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "older commit of the file"),
+	),
+	NewerRevision: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "newer commit of the file"),
+	),
+	ThreePane: key.NewBinding(
+		key.WithKeys("3"),
+		key.WithHelp("3", "HEAD/index/worktree panes"),
+	),
+	ConflictMode: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "resolve conflicts"),
+	),
+	ToggleViewed: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "mark viewed"),
+	),
+	AddComment: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "comment on lines"),
+	),
+	Comments: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "comments"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export comments"),
+	),
+	TakeOurs: key.NewBinding(
+		key.WithKeys("1"),
+		key.WithHelp("1", "take ours"),
+	),
+	TakeTheirs: key.NewBinding(
+		key.WithKeys("2"),
+		key.WithHelp("2", "take theirs"),
+	),
+	TakeBoth: key.NewBinding(
+		key.WithKeys("3"),
+		key.WithHelp("3", "take both"),
+	),
+	TakeBase: key.NewBinding(
+		key.WithKeys("4"),
+		key.WithHelp("4", "take base"),
+	),
+	NextConflict: key.NewBinding(
+		key.WithKeys("ctrl+n"),
+		key.WithHelp("ctrl+n", "next conflict"),
+	),
+	PrevConflict: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "previous conflict"),
+	),
+	WriteResolved: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "write and mark resolved"),
+	),
 }
 
 // ShortHelp returns a short help string
@@ -96,5 +251,13 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.Up, k.Down, k.Left, k.Right, k.Enter},
 		{k.Tab, k.ShiftTab, k.PageUp, k.PageDown},
 		{k.HalfPageUp, k.HalfPageDown, k.SyncToggle, k.Stage, k.Commit, k.Quit},
+		{k.Cancel, k.OutputToggle, k.CommandLog, k.Notifications},
+		{k.StashPanel, k.StashFile, k.LogPanel},
+		{k.RefPicker, k.SwapSides, k.ThreeDot},
+		{k.NextCommit, k.PrevCommit, k.ToggleReview, k.RangeDiff},
+		{k.Blame, k.FileHistory, k.OlderRevision, k.NewerRevision},
+		{k.ThreePane, k.ConflictMode, k.ToggleViewed},
+		{k.AddComment, k.Comments, k.Export},
+		{k.TakeOurs, k.TakeTheirs, k.TakeBoth, k.TakeBase, k.NextConflict, k.PrevConflict, k.WriteResolved},
 	}
 }
@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// logPageSize is how many commits the log panel loads at a time
+	logPageSize = 100
+
+	// logPrefetch is how close to the end the selection gets before the
+	// next page is loaded
+	logPrefetch = 10
+)
+
+// logPageMsg carries a page of history for the log panel
+type logPageMsg struct {
+	query   string
+	skip    int
+	entries []parser.LogEntry
+	err     error
+}
+
+// commitDiffMsg carries a commit's changes to show in the file tree
+type commitDiffMsg struct {
+	entry parser.LogEntry
+	files []diff.FileDiff
+	path  string // File to select, if any
+	line  int    // Line of path's new side to scroll to, if any
+	err   error
+}
+
+// newLogSearch creates the log panel's search input
+func newLogSearch() textinput.Model {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search commit messages"
+	search.Width = commitModalWidth
+	return search
+}
+
+// logReader returns the repository's history support, if it has any
+func (m *Model) logReader() (parser.LogReader, bool) {
+	r, ok := m.repo.(parser.LogReader)
+	return r, ok
+}
+
+// openLogPanel shows the log panel and loads the first page of history
+func (m *Model) openLogPanel() tea.Cmd {
+	if _, ok := m.logReader(); !ok {
+		return m.notify(SeverityWarning, "This repository has no history to browse")
+	}
+	if m.logEntries != nil {
+		// Keep the loaded history and position from last time
+		m.logActive = true
+		return nil
+	}
+	if m.op != nil {
+		// The panel would wait for a page nobody loads; opening it again once
+		// the operation is done loads it
+		return m.notifyf(SeverityWarning, "Busy: %s is still running", m.op.name)
+	}
+	m.logActive = true
+	return m.loadLogPage(0)
+}
+
+// loadLogPage loads history for the current search, starting at skip
+func (m *Model) loadLogPage(skip int) tea.Cmd {
+	reader, ok := m.logReader()
+	if !ok {
+		return nil
+	}
+	query := m.logQuery
+	m.logLoading = m.op == nil // Otherwise startOperation only warns that it's busy
+	return m.startOperation("log", func(ctx context.Context) tea.Msg {
+		entries, err := reader.Log(ctx, parser.LogOptions{Skip: skip, Limit: logPageSize, Search: query})
+		return logPageMsg{query: query, skip: skip, entries: entries, err: err}
+	})
+}
+
+// applyLogPage adds a loaded page; pages for an outdated search are dropped
+func (m *Model) applyLogPage(msg logPageMsg) {
+	m.logLoading = false
+	if msg.query != m.logQuery {
+		return
+	}
+	if msg.skip == 0 {
+		m.logEntries = msg.entries
+		m.logSelected = 0
+	} else if msg.skip == len(m.logEntries) {
+		m.logEntries = append(m.logEntries, msg.entries...)
+	}
+	if m.logEntries == nil {
+		m.logEntries = []parser.LogEntry{}
+	}
+	m.logMore = len(msg.entries) == logPageSize
+}
+
+// updateLogPanel handles input while the log panel is open
+func (m Model) updateLogPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.logSearching {
+		switch keyMsg.String() {
+		case "enter":
+			m.logSearching = false
+			m.logSearch.Blur()
+			if query := strings.TrimSpace(m.logSearch.Value()); query != m.logQuery && m.op == nil {
+				m.logQuery = query
+				return m, m.loadLogPage(0)
+			}
+			return m, nil
+		case "esc":
+			m.logSearching = false
+			m.logSearch.Blur()
+			m.logSearch.SetValue(m.logQuery)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.logSearch, cmd = m.logSearch.Update(keyMsg)
+		return m, cmd
+	}
+
+	if m.op != nil && key.Matches(keyMsg, m.keys.Cancel) {
+		m.cancelOperation()
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel), key.Matches(keyMsg, m.keys.LogPanel):
+		m.logActive = false
+	case key.Matches(keyMsg, m.keys.Quit):
+		m.cancelOperation()
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Down):
+		return m, m.moveLogSelection(1)
+	case key.Matches(keyMsg, m.keys.Up):
+		return m, m.moveLogSelection(-1)
+	case key.Matches(keyMsg, m.keys.PageDown), key.Matches(keyMsg, m.keys.HalfPageDown):
+		return m, m.moveLogSelection(m.logVisibleRows())
+	case key.Matches(keyMsg, m.keys.PageUp), key.Matches(keyMsg, m.keys.HalfPageUp):
+		return m, m.moveLogSelection(-m.logVisibleRows())
+	case key.Matches(keyMsg, m.keys.Enter):
+		return m, m.showCommit()
+	case keyMsg.String() == "/":
+		m.logSearching = true
+		m.logSearch.SetValue(m.logQuery)
+		m.logSearch.CursorEnd()
+		return m, m.logSearch.Focus()
+	}
+	return m, nil
+}
+
+// moveLogSelection moves the selection and loads the next page when it gets
+// close to the end of what is loaded
+func (m *Model) moveLogSelection(delta int) tea.Cmd {
+	m.logSelected = min(max(m.logSelected+delta, 0), max(len(m.logEntries)-1, 0))
+	if m.logMore && !m.logLoading && m.op == nil && m.logSelected >= len(m.logEntries)-logPrefetch {
+		return m.loadLogPage(len(m.logEntries))
+	}
+	return nil
+}
+
+// showCommit loads the selected commit's changes into the file tree
+func (m *Model) showCommit() tea.Cmd {
+	reader, ok := m.logReader()
+	if !ok || m.logSelected >= len(m.logEntries) {
+		return nil
+	}
+	entry := m.logEntries[m.logSelected]
+	return m.startOperation("show "+entry.ShortHash, func(ctx context.Context) tea.Msg {
+		files, err := reader.CommitDiff(ctx, entry.Hash)
+		return commitDiffMsg{entry: entry, files: files, err: err}
+	})
+}
+
+// applyCommitDiff shows a commit's changes with its metadata in the header
+func (m *Model) applyCommitDiff(msg commitDiffMsg) {
+	m.logActive = false
+	var parent string
+	if len(msg.entry.Parents) > 0 {
+		parent = msg.entry.Parents[0]
+	}
+	m.showDetached("Commit "+msg.entry.ShortHash, commitHeader(msg.entry, time.Now()), parent, msg.files)
+	m.detached.commit = msg.entry.Hash
+	if msg.path != "" && m.selectFile(msg.path) {
+		m.updateDiffContent()
+		m.scrollToLine(msg.line)
+	}
+}
+
+// commitHeader describes a commit the way git show does
+func commitHeader(e parser.LogEntry, now time.Time) []string {
+	lines := []string{
+		"commit " + e.Hash,
+		fmt.Sprintf("Author: %s <%s>", e.Author, e.Email),
+		fmt.Sprintf("Date:   %s (%s)", e.Date.Format("Mon Jan 2 15:04:05 2006 -0700"), relativeTime(e.Date, now)),
+		"",
+		"    " + e.Subject,
+	}
+	if e.Body != "" {
+		lines = append(lines, "")
+		for _, line := range strings.Split(e.Body, "\n") {
+			lines = append(lines, "    "+line)
+		}
+	}
+	return lines
+}
+
+// logVisibleRows is how many commits fit in the log panel
+func (m Model) logVisibleRows() int {
+	return max(m.height-14, 1)
+}
+
+// renderLogPanel renders the commit list over the main view
+func (m Model) renderLogPanel() string {
+	titleText := "History"
+	if m.logQuery != "" {
+		titleText = fmt.Sprintf("History matching %q", m.logQuery)
+	}
+	title := ModalTitleStyle.Render(titleText)
+	width := commitModalWidth + 40
+
+	var lines []string
+	now := time.Now()
+	for i, e := range m.logEntries {
+		author := []rune(e.Author)
+		if len(author) > 16 {
+			author = append(author[:15], '~')
+		}
+		line := fmt.Sprintf("%-8s %-16s %-15s %s", e.ShortHash, string(author), relativeTime(e.Date, now), e.Subject)
+		line = truncate(line, width)
+		if i == m.logSelected {
+			line = FileItemSelectedStyle.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	switch {
+	case m.logEntries == nil || m.logLoading && len(m.logEntries) == 0:
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("Loading...")}
+	case len(m.logEntries) == 0:
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No commits")}
+	case m.logMore:
+		lines = append(lines, ModalHelpStyle.MarginTop(0).Render("..."))
+	}
+
+	// Keep the selection in view
+	visible := m.logVisibleRows()
+	start := max(m.logSelected-visible+1, 0)
+	end := min(start+visible, len(lines))
+	body := lipgloss.NewStyle().Width(width).Render(strings.Join(lines[start:end], "\n"))
+
+	parts := []string{title, body}
+	if m.logSearching {
+		parts = append(parts, lipgloss.NewStyle().MarginTop(1).Render(m.logSearch.View()))
+	}
+	help := "enter: show commit | /: search | G/Esc: close"
+	if m.op != nil {
+		help = m.operationStatus()
+	}
+	parts = append(parts, ModalHelpStyle.Render(help))
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newHistoryRepo returns a repository with n commits, each changing a.txt
+func newHistoryRepo(t *testing.T, n int) *memrepo.Repo {
+	t.Helper()
+	ctx := context.Background()
+	repo := memrepo.New(map[string]string{"a.txt": "0\n"})
+	for i := 1; i <= n; i++ {
+		repo.WriteFile("a.txt", fmt.Sprintf("%d\n", i))
+		if err := repo.StageFile(ctx, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Commit(ctx, parser.CommitOptions{Message: fmt.Sprintf("Change %d\n\nBody of %d", i, i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func typeText(m Model, text string) Model {
+	for _, r := range text {
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestModel_LogShowsCommit(t *testing.T) {
+	m := newTestModel(t, newHistoryRepo(t, 3))
+
+	m = pressKey(m, "G")
+	if !m.logActive || len(m.logEntries) != 3 || m.logEntries[0].Subject != "Change 3" {
+		t.Fatalf("expected three commits, newest first, got %+v", m.logEntries)
+	}
+	if !strings.Contains(m.View(), "Change 2") {
+		t.Error("expected the log panel to list the commits")
+	}
+
+	m = pressKey(m, "j")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.logActive || m.detached == nil || len(m.files) != 1 {
+		t.Fatalf("expected the commit's diff in the file tree, got %+v", m.files)
+	}
+	view := m.View()
+	for _, want := range []string{"Commit 0000002", "Author: Demo User <demo@example.com>", "Change 2", "Body of 2"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.detached != nil || !m.sections {
+		t.Error("expected esc to go back to the working tree")
+	}
+}
+
+func TestModel_LogSearch(t *testing.T) {
+	m := newTestModel(t, newHistoryRepo(t, 12))
+	m = pressKey(m, "G")
+
+	m = pressKey(m, "/")
+	m = typeText(m, "change 1")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Change 1, 10, 11 and 12
+	if m.logQuery != "change 1" || len(m.logEntries) != 4 {
+		t.Fatalf("search for %q found %d commits", m.logQuery, len(m.logEntries))
+	}
+	if !strings.Contains(m.View(), `History matching "change 1"`) {
+		t.Error("expected the title to show the search")
+	}
+}
+
+func TestModel_LogPagesLazily(t *testing.T) {
+	m := newTestModel(t, newHistoryRepo(t, logPageSize+5))
+	m = pressKey(m, "G")
+	if len(m.logEntries) != logPageSize || !m.logMore {
+		t.Fatalf("expected one page, got %d entries", len(m.logEntries))
+	}
+
+	m.logSelected = logPageSize - logPrefetch - 1
+	m = pressKey(m, "j")
+	if len(m.logEntries) != logPageSize+5 || m.logMore {
+		t.Errorf("expected the next page to load, got %d entries", len(m.logEntries))
+	}
+}
+
+func TestCommitHeader(t *testing.T) {
+	date := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	got := commitHeader(parser.LogEntry{
+		Hash: "abc", Author: "Ada", Email: "ada@example.com", Date: date, Subject: "Fix", Body: "Because",
+	}, date.Add(time.Hour))
+
+	want := []string{
+		"commit abc",
+		"Author: Ada <ada@example.com>",
+		"Date:   Fri May 10 12:00:00 2024 +0000 (1 hour ago)",
+		"",
+		"    Fix",
+		"",
+		"    Because",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commitHeader =\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestModel_LogPanelBusy(t *testing.T) {
+	m := newTestModel(t, newHistoryRepo(t, 1))
+	m.op = &operation{name: "commit"}
+
+	m = pressKey(m, "G")
+	if m.logActive {
+		t.Error("expected the panel to stay closed while an operation runs")
+	}
+	if len(m.toasts) == 0 || !strings.Contains(m.toasts[len(m.toasts)-1].Text, "Busy: commit") {
+		t.Error("expected a busy notice")
+	}
+
+	m.op = nil
+	m = pressKey(m, "G")
+	if !m.logActive || len(m.logEntries) != 1 {
+		t.Error("expected the panel to load once nothing runs")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("Überprüfung der Änderungen", 12); got != "Überprüfung~" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate("short", 12); got != "short" {
+		t.Errorf("truncate = %q", got)
+	}
+}
@@ -5,10 +5,17 @@ import (
 	"fmt"
 	"strings"
 
+	"diff-tui/comments"
+	"diff-tui/commitlint"
+	"diff-tui/config"
 	"diff-tui/diff"
 	"diff-tui/parser"
+	"diff-tui/viewed"
+	"diff-tui/watch"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,6 +28,7 @@ const (
 	FocusFileList FocusedPanel = iota
 	FocusLeftDiff
 	FocusRightDiff
+	FocusBottomPanel // Output or command log, when shown
 )
 
 // main application model
@@ -32,12 +40,13 @@ type Model struct {
 	keys    KeyMap
 
 	files        []diff.FileDiff
-	treeRoots    []*TreeNode   // Root nodes of file tree
-	visibleNodes []*TreeNode   // Flattened visible nodes for navigation
-	selectedIdx  int           // Index in visibleNodes
-	rootName     string        // Name of the root folder (displayed in tree)
+	treeRoots    []*TreeNode // Root nodes of file tree
+	visibleNodes []*TreeNode // Flattened visible nodes for navigation
+	selectedIdx  int         // Index in visibleNodes
+	rootName     string      // Name of the root folder (displayed in tree)
 
 	leftViewport  viewport.Model
+	indexViewport viewport.Model // Middle panel of the three-pane view
 	rightViewport viewport.Model
 
 	syncScroll bool
@@ -45,18 +54,119 @@ type Model struct {
 	ready bool
 
 	// Git operations
-	gitRunner   *parser.GitRunner
-	diffArgs    []string        // Original diff args for refresh
-	stagedFiles map[string]bool // Track staged status by filepath
+	repo     parser.Repository
+	diffArgs []string       // Original diff args for refresh
+	status   *parser.Status // Per-path index/worktree status
+
+	// Working-tree view: staged and unstaged groups, each with its own diff
+	sections bool
+	staged   *diff.Result
+	unstaged *diff.Result
 
 	// Commit modal state
 	commitModalActive bool
-	commitInput       textinput.Model
+	commitSubject     textinput.Model
+	commitBody        textarea.Model
+	commitBodyFocused bool
+	commitOpts        parser.CommitOptions // Toggles; Message is filled in on commit
 	commitError       string
+	commitLint        *commitlint.Linter
+	commitIssues      []commitlint.Issue // Lint results for the current message
+	amendPrefill      string             // HEAD's message as prefilled for an amend
+
+	// Background operations and their output
+	op             *operation // Running operation, nil when idle
+	nextOpID       int
+	spinner        spinner.Model
+	output         []string // Output panel lines, oldest first
+	outputOpenLine bool     // Last output line is still being written
+
+	// Panel below the file list and diffs
+	bottom       BottomPanel
+	bottomScroll int // Lines scrolled up from the end
+
+	// Notifications: timed toasts plus a history view
+	notifications       []Notification // History, oldest first
+	nextNotificationID  int
+	toasts              []Notification // Shown on screen until they expire
+	unread              []Severity     // Severities added since the history was last opened
+	notificationsActive bool
+	notificationsScroll int
+
+	// Stash panel, and the stash shown in the file tree
+	stashActive   bool
+	stashEntries  []parser.StashEntry
+	stashSelected int
+	stashConfirm  string // Action waiting for y: "apply", "pop" or "drop"
+
+	// Log panel; entries load a page at a time
+	logActive    bool
+	logEntries   []parser.LogEntry // nil until loaded
+	logSelected  int
+	logSearch    textinput.Model
+	logSearching bool   // Search input has focus
+	logQuery     string // Search the entries were loaded for
+	logMore      bool   // The last page was full, so there may be more
+	logLoading   bool
+
+	// Ref picker, and the comparison it loaded
+	picker  refPicker
+	compare *comparison
+
+	// Branch review started with diff-tui review <base>
+	review *review
+
+	// Range-diff panel, when started with diff-tui range-diff
+	rangeDiff *rangeDiff
+
+	// Blame annotations on the original side, by revision and path
+	blameOn   bool
+	blames    map[string][]parser.BlameLine
+	blameLoad backgroundLoad
+
+	// HEAD, index and worktree side by side, by path
+	threePane    bool
+	threeWay     map[string]*parser.ThreeWayFile
+	threeWayLoad backgroundLoad
+
+	// History of one file, shown a commit at a time
+	history *fileHistory
+
+	// Unmerged files in conflict mode, by path; nil otherwise
+	conflicts map[string]*conflictFile
+
+	// A stash or commit diff shown instead of the normal view
+	detached *detachedView
+
+	// Files marked as read, and whether marking closes their directory
+	viewed         *viewed.Store
+	collapseViewed bool
+
+	// Review comments, the lines picked for a new one, and the comment panel
+	comments         *comments.Store
+	lineSelect       *lineSelection
+	commentInput     textarea.Model
+	commentDraft     *comments.Comment // Set while the comment input is open
+	commentWriting   bool              // The draft is being written to notes
+	commentsActive   bool
+	commentsSelected int
+	commentsExpanded bool
+	commentsConfirm  bool // Deleting the selected comment waits for y
+
+	// Review notes of the commit shown, and the name new ones are signed with
+	notes      *commitNotes
+	notesLoad  backgroundLoad
+	noteAuthor string
+
+	// Polls for changes made outside the viewer, when started with --watch
+	watcher      *watch.Watcher
+	watchCtx     context.Context // Cancelled by Close
+	stopWatch    context.CancelFunc
+	watchPending bool // A change is waiting for the running operation
 }
 
 // creates a new TUI model with the given files
-func NewModel(files []diff.FileDiff, gitRunner *parser.GitRunner, diffArgs []string, rootName string) Model {
+func NewModel(files []diff.FileDiff, repo parser.Repository, diffArgs []string, rootName string) Model {
 	treeRoots := BuildTree(files, rootName)
 	visibleNodes := FlattenVisible(treeRoots)
 
@@ -69,97 +179,259 @@ func NewModel(files []diff.FileDiff, gitRunner *parser.GitRunner, diffArgs []str
 		}
 	}
 
-	// Initialize commit input
-	ti := textinput.New()
-	ti.Placeholder = "Enter commit message..."
-	ti.CharLimit = 200
-	ti.Width = 50
-
-	// Initialize staged files map
-	stagedFiles := make(map[string]bool)
-
-	// Load currently staged files
-	if gitRunner != nil {
-		ctx := context.Background()
-		staged, err := gitRunner.GetStagedFiles(ctx)
-		if err == nil {
-			for _, f := range staged {
-				stagedFiles[f] = true
-			}
-		}
+	// Initialize commit inputs
+	subject, body := newCommitInputs()
+
+	// Load the current index/worktree status
+	var status *parser.Status
+	var statusErr, viewedErr, commentsErr error
+	viewedStore, commentStore := viewed.NewMemory(), comments.NewMemory()
+	if repo != nil {
+		status, statusErr = repo.Status(context.Background())
+		viewedStore, viewedErr = loadViewed(context.Background(), repo)
+		commentStore, commentsErr = loadComments(context.Background(), repo)
 	}
 
-	return Model{
-		files:        files,
-		treeRoots:    treeRoots,
-		visibleNodes: visibleNodes,
-		selectedIdx:  selectedIdx,
-		rootName:     rootName,
-		keys:         DefaultKeyMap,
-		syncScroll:   true,
-		gitRunner:    gitRunner,
-		diffArgs:     diffArgs,
-		stagedFiles:  stagedFiles,
-		commitInput:  ti,
+	m := Model{
+		files:         files,
+		treeRoots:     treeRoots,
+		visibleNodes:  visibleNodes,
+		selectedIdx:   selectedIdx,
+		rootName:      rootName,
+		keys:          DefaultKeyMap,
+		syncScroll:    true,
+		repo:          repo,
+		diffArgs:      diffArgs,
+		status:        status,
+		commitSubject: subject,
+		commitBody:    body,
+		spinner:       spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		logSearch:     newLogSearch(),
+		picker:        refPicker{query: newRefQuery()},
+		viewed:        viewedStore,
+		comments:      commentStore,
+		commentInput:  newCommentInput(),
+	}
+	// Init starts the toasts' timers
+	if statusErr != nil {
+		m.notify(SeverityError, "Could not read status: "+statusErr.Error())
 	}
+	if viewedErr != nil {
+		m.notify(SeverityWarning, "Could not load viewed marks: "+viewedErr.Error())
+	}
+	if commentsErr != nil {
+		m.notify(SeverityWarning, "Could not load comments: "+commentsErr.Error())
+	}
+	if _, ok := repo.(parser.Resolver); ok && conflictNotice(status) != "" {
+		m.notify(SeverityWarning, conflictNotice(status))
+	}
+	return m
+}
+
+// NewWorkingTreeModel creates a TUI model showing staged and unstaged changes
+// as two groups of the file tree
+func NewWorkingTreeModel(wt *parser.WorkingTree, repo parser.Repository, rootName string) Model {
+	m := NewModel(nil, repo, nil, rootName)
+	m.sections = true
+	m.setWorkingTree(wt)
+	m.selectFileNear(0)
+	return m
+}
+
+// WithConfig applies the settings from the repository's config file
+func (m Model) WithConfig(cfg *config.Config) Model {
+	m.commitLint = cfg.Commit.Lint.Linter()
+	m.collapseViewed = cfg.Viewed.Collapse
+	return m
 }
 
 // implements tea.Model
 func (m Model) Init() tea.Cmd {
-	return nil
+	// Toasts raised while the model was built
+	var cmds []tea.Cmd
+	for _, t := range m.toasts {
+		cmds = append(cmds, toastTimer(t))
+	}
+	cmds = append(cmds, waitForChange(m.watchCtx, m.watcher))
+	return tea.Batch(cmds...)
 }
 
 // implements tea.Model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
-	// Handle commit modal input first
-	if m.commitModalActive {
-		switch msg := msg.(type) {
-		case tea.KeyMsg:
-			switch msg.String() {
-			case "enter":
-				// Execute commit
-				if m.commitInput.Value() != "" {
-					m.executeCommit()
-				}
-				return m, nil
-			case "esc":
-				// Close modal
-				m.closeCommitModal()
-				return m, nil
-			default:
-				// Pass to text input
-				var cmd tea.Cmd
-				m.commitInput, cmd = m.commitInput.Update(msg)
-				return m, cmd
-			}
+	// Background operations report back whatever is on screen
+	if cmd, handled := m.updateOperation(msg); handled {
+		return m, cmd
+	}
+	if tick, ok := msg.(spinner.TickMsg); ok {
+		if m.op == nil {
+			return m, nil
 		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(tick)
+		return m, cmd
+	}
+	if expired, ok := msg.(toastExpiredMsg); ok {
+		m.expireToast(expired.id)
 		return m, nil
 	}
+	if changed, ok := msg.(watchMsg); ok {
+		return m, m.handleWatch(changed)
+	}
+	if loaded, ok := msg.(notesLoadedMsg); ok {
+		return m, m.handleNotesLoaded(loaded)
+	}
+	if blamed, ok := msg.(blameMsg); ok {
+		return m, m.handleBlame(blamed)
+	}
+	if read, ok := msg.(threeWayMsg); ok {
+		return m, m.handleThreeWay(read)
+	}
+
+	// Handle modal input first
+	if m.commitModalActive {
+		return m.updateCommitModal(msg)
+	}
+	if m.commentDraft != nil {
+		return m.updateCommentInput(msg)
+	}
+	if m.commentsActive {
+		return m.updateCommentsPanel(msg)
+	}
+	if m.notificationsActive {
+		return m.updateNotifications(msg)
+	}
+	if m.stashActive {
+		return m.updateStashPanel(msg)
+	}
+	if m.logActive {
+		return m.updateLogPanel(msg)
+	}
+	if m.picker.active {
+		return m.updateRefPicker(msg)
+	}
+	if m.rangeDiff != nil && m.rangeDiff.active {
+		return m.updateRangeDiff(msg)
+	}
+
+	// Conflict mode takes over some keys
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.conflicts != nil {
+		if cmd, handled := m.updateConflicts(keyMsg); handled {
+			return m, cmd
+		}
+	}
+
+	// So does picking lines for a comment
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.lineSelect != nil {
+		if cmd, handled := m.updateLineSelect(keyMsg); handled {
+			return m, cmd
+		}
+	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Quit):
+			m.cancelOperation()
 			return m, tea.Quit
 
+		case key.Matches(msg, m.keys.Cancel):
+			if !m.cancelOperation() && m.detached != nil {
+				cmds = append(cmds, m.leaveDetached())
+			}
+
+		case key.Matches(msg, m.keys.OutputToggle):
+			m.toggleBottomPanel(BottomOutput)
+
+		case key.Matches(msg, m.keys.CommandLog):
+			m.toggleBottomPanel(BottomCommandLog)
+
+		case key.Matches(msg, m.keys.Notifications):
+			m.openNotifications()
+
+		case key.Matches(msg, m.keys.StashPanel):
+			cmds = append(cmds, m.openStashPanel())
+
+		case key.Matches(msg, m.keys.LogPanel):
+			cmds = append(cmds, m.openLogPanel())
+
+		case key.Matches(msg, m.keys.RefPicker):
+			cmds = append(cmds, m.openRefPicker())
+
+		case key.Matches(msg, m.keys.SwapSides):
+			cmds = append(cmds, m.swapComparison())
+
+		case key.Matches(msg, m.keys.ThreeDot):
+			cmds = append(cmds, m.toggleThreeDot())
+
+		case key.Matches(msg, m.keys.NextCommit):
+			cmds = append(cmds, m.stepReview(1))
+
+		case key.Matches(msg, m.keys.PrevCommit):
+			cmds = append(cmds, m.stepReview(-1))
+
+		case key.Matches(msg, m.keys.ToggleReview):
+			cmds = append(cmds, m.toggleReview())
+
+		case key.Matches(msg, m.keys.RangeDiff):
+			cmds = append(cmds, m.toggleRangeDiff())
+
+		case key.Matches(msg, m.keys.Blame):
+			cmds = append(cmds, m.toggleBlame())
+
+		case key.Matches(msg, m.keys.FileHistory):
+			if m.focused == FocusFileList {
+				cmds = append(cmds, m.openFileHistory())
+			}
+
+		case key.Matches(msg, m.keys.OlderRevision):
+			cmds = append(cmds, m.stepFileHistory(1))
+
+		case key.Matches(msg, m.keys.NewerRevision):
+			cmds = append(cmds, m.stepFileHistory(-1))
+
+		case key.Matches(msg, m.keys.ThreePane):
+			cmds = append(cmds, m.toggleThreePane())
+
+		case key.Matches(msg, m.keys.ConflictMode):
+			cmds = append(cmds, m.openConflicts())
+
+		case key.Matches(msg, m.keys.ToggleViewed):
+			if m.focused == FocusFileList {
+				cmds = append(cmds, m.toggleViewed())
+			}
+
+		case key.Matches(msg, m.keys.AddComment):
+			cmds = append(cmds, m.startLineSelect())
+
+		case key.Matches(msg, m.keys.Comments):
+			cmds = append(cmds, m.openComments())
+
+		case key.Matches(msg, m.keys.Export):
+			cmds = append(cmds, m.exportComments())
+
+		case key.Matches(msg, m.keys.StashFile):
+			if m.focused == FocusFileList {
+				cmds = append(cmds, m.stashSelectedFiles())
+			}
+
 		case key.Matches(msg, m.keys.Tab):
-			m.focused = (m.focused + 1) % 3
+			m.focused = (m.focused + 1) % m.panelCount()
 
 		case key.Matches(msg, m.keys.ShiftTab):
-			m.focused = (m.focused + 2) % 3
+			m.focused = (m.focused + m.panelCount() - 1) % m.panelCount()
 
 		case key.Matches(msg, m.keys.SyncToggle):
 			m.syncScroll = !m.syncScroll
 
 		case key.Matches(msg, m.keys.Stage):
-			if m.focused == FocusFileList {
-				m.toggleStaging()
+			if m.focused == FocusFileList && m.detached == nil {
+				cmds = append(cmds, m.toggleStaging())
 			}
 
 		case key.Matches(msg, m.keys.Commit):
-			if m.focused == FocusFileList && m.hasStagedFiles() {
+			if m.focused == FocusFileList && m.repo != nil && m.detached == nil {
 				m.openCommitModal()
 			}
 
@@ -196,6 +468,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Enter):
 			if m.focused == FocusFileList {
 				m.handleTreeEnter()
+			} else if m.focused == FocusLeftDiff && m.blameOn {
+				cmds = append(cmds, m.showBlamedCommit())
 			}
 
 		case key.Matches(msg, m.keys.PageUp):
@@ -219,6 +493,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Blame, the three-pane view and review notes follow the selection
+		cmds = append(cmds, m.loadBlame(), m.loadThreeWay(), m.loadNotes())
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -231,21 +508,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) scrollUp(lines int) {
+	if m.focused == FocusBottomPanel {
+		m.scrollBottom(lines)
+		return
+	}
 	if m.focused == FocusLeftDiff || m.syncScroll {
 		m.leftViewport.SetYOffset(max(0, m.leftViewport.YOffset-lines))
 	}
 	if m.focused == FocusRightDiff || m.syncScroll {
 		m.rightViewport.SetYOffset(max(0, m.rightViewport.YOffset-lines))
 	}
+	m.indexViewport.SetYOffset(m.leftViewport.YOffset)
 }
 
 func (m *Model) scrollDown(lines int) {
+	if m.focused == FocusBottomPanel {
+		m.scrollBottom(-lines)
+		return
+	}
 	if m.focused == FocusLeftDiff || m.syncScroll {
 		m.leftViewport.SetYOffset(min(m.leftViewport.TotalLineCount()-m.leftViewport.Height, m.leftViewport.YOffset+lines))
 	}
 	if m.focused == FocusRightDiff || m.syncScroll {
 		m.rightViewport.SetYOffset(min(m.rightViewport.TotalLineCount()-m.rightViewport.Height, m.rightViewport.YOffset+lines))
 	}
+	m.indexViewport.SetYOffset(m.leftViewport.YOffset)
 }
 
 // handleTreeLeft collapses directory or goes to parent
@@ -256,8 +543,8 @@ func (m *Model) handleTreeLeft() {
 
 	node := m.visibleNodes[m.selectedIdx]
 
-	if node.IsDirectory() && node.Expanded {
-		// Collapse directory
+	if node.IsExpandable() && node.Expanded {
+		// Collapse directory or submodule
 		node.ToggleExpanded()
 		m.refreshVisibleNodes()
 	} else if node.Parent != nil {
@@ -274,7 +561,7 @@ func (m *Model) handleTreeRight() {
 
 	node := m.visibleNodes[m.selectedIdx]
 
-	if node.IsDirectory() && !node.Expanded {
+	if node.IsExpandable() && !node.Expanded {
 		node.ToggleExpanded()
 		m.refreshVisibleNodes()
 	}
@@ -288,7 +575,7 @@ func (m *Model) handleTreeEnter() {
 
 	node := m.visibleNodes[m.selectedIdx]
 
-	if node.IsDirectory() {
+	if node.IsExpandable() {
 		node.ToggleExpanded()
 		m.refreshVisibleNodes()
 	}
@@ -325,17 +612,26 @@ func (m *Model) selectNode(target *TreeNode) {
 	}
 }
 
+// panelWidths returns the width of the file list and of each diff panel
+func (m Model) panelWidths() (fileListWidth, diffPanelWidth int) {
+	// total width minus borders (2 chars per panel)
+	panels := 2
+	if m.threePaneView() {
+		panels = 3
+	}
+	availableWidth := m.width - 2*(panels+1)
+	fileListWidth = availableWidth * 20 / 100
+	return fileListWidth, (availableWidth - fileListWidth) / panels
+}
+
 func (m *Model) updateViewportSizes() {
-	// calculate panel widths
-	// total width minus borders (2 chars per panel = 6 total)
-	availableWidth := m.width - 6
-	fileListWidth := availableWidth * 20 / 100
-	diffPanelWidth := (availableWidth - fileListWidth) / 2
+	_, diffPanelWidth := m.panelWidths()
 
 	// height minus borders and title
-	panelHeight := m.height - 4
+	panelHeight := m.mainHeight() - 4
 
 	m.leftViewport = viewport.New(diffPanelWidth-2, panelHeight)
+	m.indexViewport = viewport.New(diffPanelWidth-2, panelHeight)
 	m.rightViewport = viewport.New(diffPanelWidth-2, panelHeight)
 }
 
@@ -356,20 +652,46 @@ func (m *Model) updateDiffContent() {
 	file := node.File
 
 	// calculate available width for content
-	availableWidth := m.width - 6
-	fileListWidth := availableWidth * 20 / 100
-	diffPanelWidth := (availableWidth - fileListWidth) / 2
+	_, diffPanelWidth := m.panelWidths()
 	contentWidth := diffPanelWidth - 8 // Account for line numbers and padding
 
-	m.leftViewport.SetContent(m.renderDiffLines(file.LeftLines, contentWidth, true))
+	if m.threePaneView() {
+		m.setThreeWayContent(contentWidth)
+		return
+	}
+
+	leftWidth := contentWidth
+	if m.selectedBlame() != nil {
+		leftWidth = max(contentWidth-blameGutterWidth, 1)
+	}
+	m.leftViewport.SetContent(m.renderDiffLines(file.LeftLines, leftWidth, true))
 	m.rightViewport.SetContent(m.renderDiffLines(file.RightLines, contentWidth, false))
 }
 
 func (m *Model) renderDiffLines(lines []diff.Line, width int, isLeft bool) string {
 	var sb strings.Builder
 
+	// Blame annotates the original side
+	var blame []parser.BlameLine
+	if isLeft {
+		blame = m.selectedBlame()
+	}
+
+	// Commented lines are marked; lines picked for a comment are highlighted
+	commented := m.commentedLines(isLeft)
+	selFrom, selTo, selecting := m.selectedRows(isLeft)
+
 	for i, line := range lines {
-		lineNum := fmt.Sprintf("%4d ", i+1)
+		if blame != nil {
+			sb.WriteString(blameGutter(blame, line))
+		}
+		lineNum := "     "
+		if line.Number > 0 {
+			lineNum = fmt.Sprintf("%4d ", line.Number)
+			if commented[line.Number] {
+				lineNum = fmt.Sprintf("%4d%s", line.Number, CommentMarker)
+			}
+		}
 
 		// Determine styles based on line type
 		var baseStyle, highlightStyle lipgloss.Style
@@ -402,6 +724,9 @@ func (m *Model) renderDiffLines(lines []diff.Line, width int, isLeft bool) strin
 		}
 
 		// Render line number
+		if selecting && i >= selFrom && i <= selTo {
+			numStyle = LineNumSelectedStyle
+		}
 		sb.WriteString(numStyle.Render(lineNum))
 
 		// Render content - with segments if available (word-level diff)
@@ -464,38 +789,79 @@ func (m Model) View() string {
 	}
 
 	// Calculate panel widths
-	availableWidth := m.width - 6
-	fileListWidth := availableWidth * 20 / 100
-	diffPanelWidth := (availableWidth - fileListWidth) / 2
+	fileListWidth, diffPanelWidth := m.panelWidths()
 
 	// Panel height
-	panelHeight := m.height - 2
+	panelHeight := m.mainHeight() - 2
 
 	// Render panels
-	leftPanel := m.renderFileListPanel(fileListWidth, panelHeight)
-	middlePanel := m.renderDiffPanel("Original", m.leftViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusLeftDiff)
-	rightPanel := m.renderDiffPanel("Modified", m.rightViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusRightDiff)
+	panels := []string{m.renderFileListPanel(fileListWidth, panelHeight)}
+	if m.threePaneView() {
+		panels = append(panels,
+			m.renderDiffPanel("HEAD", m.leftViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusLeftDiff),
+			m.renderDiffPanel("Index", m.indexViewport.View(), diffPanelWidth, panelHeight, false),
+			m.renderDiffPanel("Worktree", m.rightViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusRightDiff))
+	} else {
+		originalTitle, modifiedTitle := "Original", "Modified"
+		switch {
+		case m.conflicts != nil:
+			originalTitle, modifiedTitle = "Ours", "Theirs"
+		case m.blameOn:
+			originalTitle = "Original (blame)"
+		}
+		panels = append(panels,
+			m.renderDiffPanel(originalTitle, m.leftViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusLeftDiff),
+			m.renderDiffPanel(modifiedTitle, m.rightViewport.View(), diffPanelWidth, panelHeight, m.focused == FocusRightDiff))
+	}
 
 	// Join panels horizontally
-	main := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, middlePanel, rightPanel)
+	main := lipgloss.JoinHorizontal(lipgloss.Top, panels...)
+	if m.headerHeight() > 0 {
+		main = lipgloss.JoinVertical(lipgloss.Left, m.renderHeader(m.width-2), main)
+	}
+	if h := m.bottomHeight(); h > 0 {
+		main = lipgloss.JoinVertical(lipgloss.Left, main, m.renderBottomPanel(m.width-2, h-2))
+	}
 
-	// Overlay commit modal if active
+	// Overlay modals if active
 	if m.commitModalActive {
-		return m.renderCommitModal(main)
+		main = m.renderCommitModal(main)
+	} else if m.commentDraft != nil {
+		main = m.renderCommentInput()
+	} else if m.commentsActive {
+		main = m.renderCommentsPanel()
+	} else if m.notificationsActive {
+		main = m.renderNotifications()
+	} else if m.stashActive {
+		main = m.renderStashPanel()
+	} else if m.logActive {
+		main = m.renderLogPanel()
+	} else if m.picker.active {
+		main = m.renderRefPicker()
+	} else if m.rangeDiff != nil && m.rangeDiff.active {
+		main = m.renderRangeDiff()
 	}
 
-	return main
+	return m.renderToasts(main)
 }
 
 func (m Model) renderFileListPanel(width, height int) string {
 	isFocused := m.focused == FocusFileList
 
 	// Title
+	titleText := "Files"
+	if m.detached != nil {
+		titleText = m.detached.title
+	} else if m.compare != nil {
+		titleText = m.compare.String()
+	} else if m.review != nil {
+		titleText = m.review.title()
+	}
 	var title string
 	if isFocused {
-		title = TitleStyle.Render("Files")
+		title = TitleStyle.Render(titleText)
 	} else {
-		title = TitleInactiveStyle.Render("Files")
+		title = TitleInactiveStyle.Render(titleText)
 	}
 	title = lipgloss.PlaceHorizontal(width-2, lipgloss.Left, title)
 
@@ -522,6 +888,20 @@ func (m Model) renderFileListPanel(width, height int) string {
 		syncStatus = "sync: off"
 	}
 	statusLine := HelpStyle.Render(fmt.Sprintf(" %s | q: quit", syncStatus))
+	if m.detached != nil {
+		statusLine = HelpStyle.Render(fmt.Sprintf(" %s | esc: back", syncStatus))
+	} else if m.review != nil {
+		statusLine = HelpStyle.Render(fmt.Sprintf(" %s | n/p: commit | t: all", syncStatus))
+	}
+	if progress := m.viewedProgress(); progress != "" {
+		statusLine += HelpStyle.Render(" | " + progress)
+	}
+	if status := m.operationStatus(); status != "" {
+		statusLine = HelpStyle.Render(" " + status)
+	}
+	if indicator := m.notificationIndicator(); indicator != "" {
+		statusLine += " " + indicator
+	}
 
 	// Build panel content
 	panelContent := lipgloss.JoinVertical(lipgloss.Left,
@@ -559,11 +939,23 @@ func (m Model) renderTreeNode(node *TreeNode, width int, isSelected bool) string
 		} else {
 			sb.WriteString(CollapsedIndicator + " ")
 		}
-		sb.WriteString(node.Name + "/")
+		if node.IsSectionRoot() {
+			sb.WriteString(node.Name)
+		} else {
+			sb.WriteString(node.Name + "/")
+		}
 	} else {
-		// File: show status, name, and counts
-		status := m.getFileStatus(node, isSelected)
+		// File: show status, name, and counts; a viewed file is dimmed
+		status := m.getFileStatus(node, isSelected || m.isViewed(node))
 		sb.WriteString(status + " ")
+		if node.IsExpandable() {
+			// A submodule with its own changes below it
+			if node.Expanded {
+				sb.WriteString(ExpandedIndicator + " ")
+			} else {
+				sb.WriteString(CollapsedIndicator + " ")
+			}
+		}
 		sb.WriteString(node.Name)
 
 		if node.File != nil {
@@ -572,48 +964,81 @@ func (m Model) renderTreeNode(node *TreeNode, width int, isSelected bool) string
 		}
 	}
 
+	if m.isViewed(node) {
+		sb.WriteString(" " + ViewedMark)
+	}
+
 	lineContent := sb.String()
 
 	if isSelected {
 		return FileItemSelectedStyle.Width(width).Render(lineContent)
 	}
+	if m.isViewed(node) {
+		return FileItemViewedStyle.Width(width).Render(lineContent)
+	}
 	return FileItemStyle.Width(width).Render(lineContent)
 }
 
-// getFileStatus returns the status indicator for a file
+// getFileStatus returns the two-column XY status indicator for a file:
+// X is the staged (index) state and Y the unstaged (worktree) state
 func (m Model) getFileStatus(node *TreeNode, isSelected bool) string {
 	if node.File == nil {
 		return "  "
 	}
 
-	// Check if file is staged
-	isStaged := m.stagedFiles[node.File.Name]
+	st, known := m.status.Get(node.File.Name)
+	if !known {
+		// Not in git status (e.g. viewing a past commit): derive it from the diff
+		return m.getDiffStatus(node.File, isSelected)
+	}
+
+	x := statusColumn(st.Index)
+	y := statusColumn(st.Worktree)
 
 	// When selected, return plain text (no ANSI codes) so selection style applies uniformly
 	if isSelected {
-		if isStaged {
-			return "S "
-		}
-		if node.File.IsNew {
-			return "??"
-		} else if node.File.IsDeleted {
-			return "D "
-		}
-		return "M "
+		return x + y
 	}
 
 	// When not selected, use colored styles
-	if isStaged {
-		return StatusStagedStyle.Render("S ")
+	switch {
+	case st.IsConflicted():
+		return StatusConflictStyle.Render(x + y)
+	case st.IsUntracked():
+		return StatusNewStyle.Render(x + y)
 	}
-	if node.File.IsNew {
-		return StatusNewStyle.Render("??")
-	} else if node.File.IsDeleted {
-		return StatusDeletedStyle.Render("D ")
+	return StatusStagedStyle.Render(x) + StatusModifiedStyle.Render(y)
+}
+
+// getDiffStatus returns a status indicator derived from the file diff alone
+func (m Model) getDiffStatus(file *diff.FileDiff, isSelected bool) string {
+	var status string
+	var style lipgloss.Style
+
+	switch {
+	case file.IsUntracked:
+		status, style = "??", StatusNewStyle
+	case file.IsNew:
+		status, style = "A ", StatusNewStyle
+	case file.IsDeleted:
+		status, style = "D ", StatusDeletedStyle
+	default:
+		status, style = "M ", StatusModifiedStyle
 	}
-	return StatusModifiedStyle.Render("M ")
+
+	if isSelected {
+		return status
+	}
+	return style.Render(status)
 }
 
+// statusColumn renders one porcelain status column the way git status --short does
+func statusColumn(code parser.StatusCode) string {
+	if code == parser.StatusUnmodified || code == 0 {
+		return " "
+	}
+	return string(rune(code))
+}
 
 func (m Model) renderDiffPanel(title string, content string, width, height int, isFocused bool) string {
 	// Title
@@ -660,155 +1085,154 @@ func (m Model) renderDiffPanel(title string, content string, width, height int,
 		Render(panelContent)
 }
 
-// toggleStaging toggles the staging status of the selected file
-func (m *Model) toggleStaging() {
+// toggleStaging toggles the staging status of the selected file in the background
+func (m *Model) toggleStaging() tea.Cmd {
 	if len(m.visibleNodes) == 0 || m.selectedIdx >= len(m.visibleNodes) {
-		return
+		return nil
 	}
 
 	node := m.visibleNodes[m.selectedIdx]
-	if node.File == nil || m.gitRunner == nil {
-		return
+	if node.File == nil || m.repo == nil {
+		return nil
+	}
+	if sub := node.Submodule(); sub != nil {
+		return m.notifyf(SeverityWarning, "%s is inside submodule %s; stage the submodule instead", node.File.Name, sub.File.Name)
 	}
 
 	filepath := node.File.Name
-	ctx := context.Background()
+	repo := m.repo
+
+	// In the grouped view the section decides the direction; both groups are
+	// reloaded so the content moves across
+	if node.Section != SectionNone {
+		unstage := node.Section == SectionStaged
+		sections, args := m.sections, m.diffArgs
+		return m.startOperation(stagingVerb(unstage)+" "+filepath, func(ctx context.Context) tea.Msg {
+			var err error
+			if unstage {
+				err = repo.UnstageFile(ctx, filepath)
+			} else {
+				err = repo.StageFile(ctx, filepath)
+			}
+			if err != nil {
+				return refreshedMsg{err: err}
+			}
+			msg := loadRefresh(ctx, repo, sections, args)
+			msg.done = stagedNotice(filepath, !unstage)
+			return msg
+		})
+	}
 
-	if m.stagedFiles[filepath] {
-		// Unstage the file
-		err := m.gitRunner.UnstageFile(ctx, filepath)
-		if err == nil {
-			delete(m.stagedFiles, filepath)
+	// Anything left in the worktree gets staged; a fully staged file is unstaged
+	st, known := m.status.Get(filepath)
+	unstage := known && st.IsStaged() && !st.HasUnstagedChanges()
+	return m.startOperation(stagingVerb(unstage)+" "+filepath, func(ctx context.Context) tea.Msg {
+		msg := stagedMsg{path: filepath, staged: !unstage}
+		if unstage {
+			msg.err = repo.UnstageFile(ctx, filepath)
+		} else {
+			msg.err = repo.StageFile(ctx, filepath)
 		}
-	} else {
-		// Stage the file
-		err := m.gitRunner.StageFile(ctx, filepath)
-		if err == nil {
-			m.stagedFiles[filepath] = true
+		if msg.err == nil {
+			msg.status, msg.err = repo.Status(ctx)
 		}
-	}
+		return msg
+	})
 }
 
-// hasStagedFiles returns true if there are any staged files
-func (m *Model) hasStagedFiles() bool {
-	return len(m.stagedFiles) > 0
-}
-
-// openCommitModal opens the commit message modal
-func (m *Model) openCommitModal() {
-	m.commitModalActive = true
-	m.commitError = ""
-	m.commitInput.SetValue("")
-	m.commitInput.Focus()
-}
-
-// closeCommitModal closes the commit message modal
-func (m *Model) closeCommitModal() {
-	m.commitModalActive = false
-	m.commitError = ""
-	m.commitInput.Blur()
-}
-
-// executeCommit executes the git commit with the entered message
-func (m *Model) executeCommit() {
-	if m.gitRunner == nil {
-		m.commitError = "Git runner not available"
-		return
+func stagingVerb(unstage bool) string {
+	if unstage {
+		return "unstage"
 	}
+	return "stage"
+}
 
-	message := m.commitInput.Value()
-	if message == "" {
-		m.commitError = "Commit message cannot be empty"
-		return
+// applyStaged updates the flat view after a file was staged or unstaged
+func (m *Model) applyStaged(msg stagedMsg) {
+	if msg.status != nil {
+		m.status = msg.status
 	}
-
-	ctx := context.Background()
-	err := m.gitRunner.Commit(ctx, message)
-	if err != nil {
-		m.commitError = err.Error()
-		return
+	for i := range m.files {
+		file := &m.files[i]
+		if file.Name != msg.path {
+			continue
+		}
+		if msg.staged {
+			// An untracked file becomes a normal new file
+			file.IsUntracked = false
+		} else if msg.err == nil && file.IsNew && parser.IsWorkingTreeDiff(m.diffArgs) {
+			// A new file goes back to being untracked
+			file.IsUntracked = true
+		}
 	}
+}
 
-	// Close modal and refresh diff
-	m.closeCommitModal()
-	m.refreshDiff()
+// hasStagedFiles returns true if there are any staged files
+func (m *Model) hasStagedFiles() bool {
+	return m.status.HasStaged()
 }
 
-// refreshDiff re-runs git diff and rebuilds the file tree
-func (m *Model) refreshDiff() {
-	if m.gitRunner == nil {
-		return
+// refreshDiff re-runs git diff and reloads the status in the background
+func (m *Model) refreshDiff() tea.Cmd {
+	if m.repo == nil {
+		return nil
 	}
+	repo, sections, args := m.repo, m.sections, m.diffArgs
+	return m.startOperation("refresh", func(ctx context.Context) tea.Msg {
+		return loadRefresh(ctx, repo, sections, args)
+	})
+}
 
-	ctx := context.Background()
-
-	// Re-run git diff
-	diffOutput, err := m.gitRunner.RunDiff(ctx, m.diffArgs...)
-	if err != nil {
+// applyRefresh rebuilds the file tree from reloaded data
+func (m *Model) applyRefresh(msg refreshedMsg) {
+	if msg.err != nil {
 		return
 	}
 
-	// Re-parse the diff
-	result, err := parser.ParseString(diffOutput)
-	if err != nil {
-		return
+	state := m.saveTreeState()
+	if m.sections {
+		m.setWorkingTree(msg.wt)
+	} else {
+		m.files = msg.files
+		m.treeRoots = BuildTree(m.files, m.rootName)
 	}
+	m.status = msg.status
+	m.blames = nil // The index or HEAD may have moved
+	m.blameLoad.reset()
+	m.threeWay = nil
+	m.threeWayLoad.reset()
+	m.lineSelect = nil // The rows may have moved
+	m.staleNotes()     // So may the notes, with a fetch
+
+	// Keep collapsed directories, the selection and the scroll positions
+	m.restoreTreeState(state)
+	m.updateDiffContent()
+	m.restoreScroll(state)
+}
 
-	// Update the model with new files
-	m.files = result.Files
-	m.treeRoots = BuildTree(m.files, m.rootName)
+// setWorkingTree replaces both groups of the grouped view
+func (m *Model) setWorkingTree(wt *parser.WorkingTree) {
+	m.staged = wt.Staged
+	m.unstaged = wt.Unstaged
+	m.treeRoots = BuildSectionTree(m.staged.Files, m.unstaged.Files)
 	m.visibleNodes = FlattenVisible(m.treeRoots)
+}
 
-	// Reset selection to first file
-	m.selectedIdx = 0
-	for i, node := range m.visibleNodes {
-		if node.IsFile() {
+// selectFileNear selects the first file at or after idx, falling back to the
+// closest file before it
+func (m *Model) selectFileNear(idx int) {
+	idx = min(max(idx, 0), max(len(m.visibleNodes)-1, 0))
+	for i := idx; i < len(m.visibleNodes); i++ {
+		if m.visibleNodes[i].IsFile() {
 			m.selectedIdx = i
-			break
+			return
 		}
 	}
-
-	// Reload staged files
-	m.stagedFiles = make(map[string]bool)
-	staged, err := m.gitRunner.GetStagedFiles(ctx)
-	if err == nil {
-		for _, f := range staged {
-			m.stagedFiles[f] = true
+	for i := idx - 1; i >= 0; i-- {
+		if m.visibleNodes[i].IsFile() {
+			m.selectedIdx = i
+			return
 		}
 	}
-
-	// Update diff content
-	m.updateDiffContent()
-}
-
-// renderCommitModal renders the commit message modal overlay
-func (m Model) renderCommitModal(background string) string {
-	// Modal title
-	title := ModalTitleStyle.Render("Commit Message")
-
-	// Text input
-	input := m.commitInput.View()
-
-	// Error message if any
-	var errorMsg string
-	if m.commitError != "" {
-		errorMsg = ModalErrorStyle.Render(m.commitError)
-	}
-
-	// Help text
-	help := ModalHelpStyle.Render("Enter: commit | Esc: cancel")
-
-	// Build modal content
-	var modalContent string
-	if errorMsg != "" {
-		modalContent = lipgloss.JoinVertical(lipgloss.Left, title, input, errorMsg, help)
-	} else {
-		modalContent = lipgloss.JoinVertical(lipgloss.Left, title, input, help)
-	}
-
-	// Style and size the modal
-	modal := ModalStyle.Width(60).Render(modalContent)
-
-	// Center modal on screen
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+	m.selectedIdx = idx
 }
@@ -0,0 +1,373 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"diff-tui/config"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel builds a sized working-tree model backed by an in-memory repository
+func newTestModel(t *testing.T, repo *memrepo.Repo) Model {
+	t.Helper()
+
+	wt, err := repo.WorkingTree(context.Background())
+	if err != nil {
+		t.Fatalf("WorkingTree failed: %v", err)
+	}
+
+	m := NewWorkingTreeModel(wt, repo, "test")
+	return update(m, tea.WindowSizeMsg{Width: 120, Height: 40})
+}
+
+// update feeds msg to the model and runs the resulting commands to completion,
+// so background operations have finished when it returns
+func update(m Model, msg tea.Msg) Model {
+	next, cmd := m.Update(msg)
+	return runCmd(next.(Model), cmd)
+}
+
+func runCmd(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = runCmd(m, c)
+		}
+	case spinner.TickMsg:
+		// Ticks repeat while an operation runs; the tests don't need them
+	default:
+		m = update(m, msg)
+	}
+	return m
+}
+
+func pressKey(m Model, k string) Model {
+	if k == " " {
+		return update(m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	}
+	return update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+}
+
+func TestModel_StagingMovesFileBetweenSections(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+
+	m := newTestModel(t, repo)
+
+	node := m.visibleNodes[m.selectedIdx]
+	if node.Path != "a.txt" || node.Section != SectionUnstaged {
+		t.Fatalf("expected a.txt selected in unstaged section, got %s (%d)", node.Path, node.Section)
+	}
+
+	m = pressKey(m, " ")
+
+	if len(m.staged.Files) != 1 || len(m.unstaged.Files) != 0 {
+		t.Fatalf("expected file to move to staged, got %d staged/%d unstaged", len(m.staged.Files), len(m.unstaged.Files))
+	}
+	if !m.hasStagedFiles() {
+		t.Error("expected staged files after staging")
+	}
+
+	node = m.visibleNodes[m.selectedIdx]
+	if node.Section != SectionStaged {
+		t.Fatalf("expected selection to follow into the staged section, got %d", node.Section)
+	}
+
+	m = pressKey(m, " ")
+
+	if len(m.staged.Files) != 0 || len(m.unstaged.Files) != 1 {
+		t.Errorf("expected file back in unstaged, got %d staged/%d unstaged", len(m.staged.Files), len(m.unstaged.Files))
+	}
+}
+
+func TestModel_PartiallyStagedFileInBothSections(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	repo.StageFile(context.Background(), "a.txt")
+	repo.WriteFile("a.txt", "one\ntwo\nthree\n")
+
+	m := newTestModel(t, repo)
+
+	if len(m.staged.Files) != 1 || len(m.unstaged.Files) != 1 {
+		t.Fatalf("expected file in both sections, got %d staged/%d unstaged", len(m.staged.Files), len(m.unstaged.Files))
+	}
+
+	st, ok := m.status.Get("a.txt")
+	if !ok || !st.IsPartiallyStaged() {
+		t.Errorf("expected partially staged status, got %q", st.XY())
+	}
+}
+
+func TestModel_Commit(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	repo.StageFile(context.Background(), "a.txt")
+
+	m := newTestModel(t, repo)
+
+	m = pressKey(m, "c")
+	if !m.commitModalActive {
+		t.Fatal("expected commit modal to open")
+	}
+
+	m = pressKey(m, "change a")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.commitModalActive {
+		t.Fatalf("expected modal to close, error: %s", m.commitError)
+	}
+	if commits := repo.Commits(); len(commits) != 1 || commits[0].Message != "change a" {
+		t.Errorf("unexpected commits: %+v", commits)
+	}
+	if len(m.staged.Files) != 0 {
+		t.Errorf("expected nothing staged after commit, got %d", len(m.staged.Files))
+	}
+}
+
+func TestModel_CommitWithBodyAndAmend(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	repo.StageFile(context.Background(), "a.txt")
+
+	m := newTestModel(t, repo)
+
+	m = pressKey(m, "c")
+	m = pressKey(m, "subject")
+	m = update(m, tea.KeyMsg{Type: tea.KeyTab})
+	m = pressKey(m, "first line")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = pressKey(m, "second line")
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+
+	if m.commitModalActive {
+		t.Fatalf("expected modal to close, error: %s", m.commitError)
+	}
+	want := "subject\n\nfirst line\nsecond line"
+	if commits := repo.Commits(); len(commits) != 1 || commits[0].Message != want {
+		t.Fatalf("unexpected commits: %+v", commits)
+	}
+
+	// Amend prefills HEAD's message and needs nothing staged
+	m = pressKey(m, "c")
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m = next.(Model); m.op == nil || m.commitSubject.Value() != "" {
+		t.Fatal("expected HEAD's message to be read in the background")
+	}
+	m = runCmd(m, cmd)
+	if !m.commitOpts.Amend || m.commitSubject.Value() != "subject" {
+		t.Fatalf("expected amend prefilled with HEAD's subject, got %q", m.commitSubject.Value())
+	}
+
+	// Turning amend off drops the untouched prefill again
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.commitOpts.Amend || m.commitSubject.Value() != "" {
+		t.Fatalf("expected the prefill to be dropped, got %q", m.commitSubject.Value())
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+
+	if m.commitModalActive {
+		t.Fatalf("expected modal to close, error: %s", m.commitError)
+	}
+	commits := repo.Commits()
+	if len(commits) != 1 || commits[0].Message != want+"\n\nSigned-off-by: "+memrepo.Committer {
+		t.Errorf("unexpected commits after amend: %+v", commits)
+	}
+}
+
+func TestModel_CommitNothingStaged(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+
+	m := newTestModel(t, repo)
+	m = pressKey(m, "c")
+	m = pressKey(m, "msg")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if !m.commitModalActive || m.commitError == "" {
+		t.Error("expected the modal to stay open with an error")
+	}
+	if len(repo.Commits()) != 0 {
+		t.Error("expected no commit")
+	}
+}
+
+func TestModel_CommitLintBlocksCommit(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	repo.StageFile(context.Background(), "a.txt")
+
+	cfg := &config.Config{}
+	cfg.Commit.Lint.Conventional = &config.ConventionalConfig{}
+	m := newTestModel(t, repo).WithConfig(cfg)
+
+	m = pressKey(m, "c")
+	m = pressKey(m, "change a")
+	if len(m.commitIssues) == 0 {
+		t.Fatal("expected live lint issues while typing")
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.commitModalActive || len(repo.Commits()) != 0 {
+		t.Fatal("expected lint errors to block the commit")
+	}
+
+	m.setCommitMessage("fix: change a")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.commitModalActive {
+		t.Fatalf("expected commit to succeed, error: %s", m.commitError)
+	}
+}
+
+func TestModel_EditorTemplate(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	repo.StageFile(context.Background(), "a.txt")
+	template := "feat: \n\n# Explain why\n"
+
+	m := newTestModel(t, repo)
+	m = pressKey(m, "c")
+	if got := m.commitTemplate(template); !strings.HasPrefix(got, template) {
+		t.Errorf("template not prefilled: %q", got)
+	}
+
+	// An unedited template aborts, an edited one commits
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	os.WriteFile(path, []byte(m.commitTemplate(template)), 0o600)
+	m = runCmd(m, m.finishEditor(editorFinishedMsg{path: path, template: template}))
+	if !m.commitModalActive || len(repo.Commits()) != 0 {
+		t.Fatal("expected an unedited template to abort the commit")
+	}
+
+	path = filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	os.WriteFile(path, []byte("feat: add b\n\n# Explain why\n"), 0o600)
+	m = runCmd(m, m.finishEditor(editorFinishedMsg{path: path, template: template}))
+	if commits := repo.Commits(); len(commits) != 1 || commits[0].Message != "feat: add b" {
+		t.Errorf("unexpected commits: %+v (error: %s)", commits, m.commitError)
+	}
+}
+
+func TestModel_CommitLintsRecordedMessage(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "two\n")
+	repo.StageFile(context.Background(), "a.txt")
+
+	cfg := &config.Config{}
+	cfg.Commit.Lint.BlankLineAfterSubject = true
+	cfg.Commit.Lint.BodyMaxLineLength = 10
+	cfg.Commit.Lint.Warnings = []string{"blank-line-after-subject"}
+	m := newTestModel(t, repo).WithConfig(cfg)
+
+	// Trailing whitespace of a typed message is cleaned up before linting
+	m = pressKey(m, "c")
+	m.setCommitMessage("add a\n\nshort          \nbody")
+	if len(m.commitIssues) != 0 {
+		t.Fatalf("expected no issues for trailing whitespace, got %+v", m.commitIssues)
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if commits := repo.Commits(); len(commits) != 1 || commits[0].Message != "add a\n\nshort\nbody" {
+		t.Fatalf("unexpected commits: %+v (error: %s)", commits, m.commitError)
+	}
+
+	// The editor's message is committed as written, so that is what is linted
+	repo.WriteFile("a.txt", "three\n")
+	repo.StageFile(context.Background(), "a.txt")
+	m = runCmd(m, m.refreshDiff())
+	m = pressKey(m, "c")
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	os.WriteFile(path, []byte("add b\nno gap\n# a comment far longer than the limit\n"), 0o600)
+	m = runCmd(m, m.finishEditor(editorFinishedMsg{path: path}))
+	if len(m.commitIssues) != 1 || m.commitIssues[0].Rule != "blank-line-after-subject" {
+		t.Errorf("expected only the missing blank line flagged, got %+v", m.commitIssues)
+	}
+	if commits := repo.Commits(); len(commits) != 2 || commits[1].Message != "add b\nno gap" {
+		t.Errorf("unexpected commits: %+v (error: %s)", commits, m.commitError)
+	}
+}
+
+func TestModel_CancelOperation(t *testing.T) {
+	m := newTestModel(t, memrepo.New(map[string]string{"a.txt": "one\n"}))
+
+	started := make(chan struct{})
+	cmd := m.startOperation("slow", func(ctx context.Context) tea.Msg {
+		close(started)
+		<-ctx.Done()
+		return refreshedMsg{err: ctx.Err()}
+	})
+
+	done := make(chan Model)
+	go func() { done <- runCmd(m, cmd) }()
+
+	<-started
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = <-done
+
+	if m.op != nil {
+		t.Error("expected the operation to be finished")
+	}
+	if last := m.output[len(m.output)-1]; last != "cancelled" {
+		t.Errorf("expected cancellation in the output, got %q", last)
+	}
+
+	m = pressKey(m, "o")
+	if view := m.View(); !strings.Contains(view, "Output") || !strings.Contains(view, "$ slow") {
+		t.Error("expected the output panel to show the operation")
+	}
+}
+
+func TestModel_AppendOutput(t *testing.T) {
+	var m Model
+	m.appendOutputLine("$ commit")
+	m.appendOutput("running hook")
+	m.appendOutput("... ok\nsecond\n")
+	m.appendOutput("third\r\n")
+
+	want := []string{"$ commit", "running hook... ok", "second", "third"}
+	if strings.Join(m.output, "|") != strings.Join(want, "|") {
+		t.Errorf("output = %q, want %q", m.output, want)
+	}
+}
+
+// loggedRepo is a memrepo.Repo with a command log, like GitRunner
+type loggedRepo struct {
+	*memrepo.Repo
+	log *parser.CommandLog
+}
+
+func (r loggedRepo) CommandLog() *parser.CommandLog { return r.log }
+
+func TestModel_CommandLogPanel(t *testing.T) {
+	repo := loggedRepo{Repo: memrepo.New(map[string]string{"a.txt": "one\n"}), log: parser.NewCommandLog(10)}
+	repo.log.Add(parser.CommandRecord{Args: []string{"status", "--porcelain=v2"}})
+	repo.log.Add(parser.CommandRecord{Args: []string{"add", "missing.txt"}, ExitCode: 128,
+		Stderr: "fatal: pathspec did not match", Err: errors.New("exit status 128")})
+
+	m := newTestModel(t, repo.Repo)
+	m.repo = repo
+	m = pressKey(m, "L")
+
+	view := m.View()
+	for _, want := range []string{"Command Log", "git status --porcelain=v2", "exit 128", "fatal: pathspec did not match"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("command log panel missing %q", want)
+		}
+	}
+
+	m = pressKey(m, "L")
+	if strings.Contains(m.View(), "Command Log") {
+		t.Error("expected L to hide the command log")
+	}
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"diff-tui/comments"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commitNotes are the review comments kept in the git notes of a commit
+type commitNotes struct {
+	commit string
+	store  *comments.Store
+	stale  bool // Shown until they are read again, as they may have been fetched
+}
+
+// notesMsg carries the review notes of a commit, loaded or just written
+type notesMsg struct {
+	commit   string
+	comments []comments.Comment
+	author   string // user.name, for the comments written next
+	done     string // Success notification, if the notes were changed
+	err      error
+}
+
+// notesLoadedMsg carries notes read in the background; seq numbers the load
+type notesLoadedMsg struct {
+	notesMsg
+	seq int
+}
+
+// viewedCommit returns the commit shown on its own, whose review comments
+// are its notes; "" when the view is not a single commit
+func (m Model) viewedCommit() string {
+	if _, ok := m.repo.(parser.NotesKeeper); !ok {
+		return ""
+	}
+	switch {
+	case m.detached != nil:
+		return m.detached.commit
+	case m.review != nil && m.review.index >= 0 && m.compare == nil:
+		return m.review.Commits[m.review.index].Hash
+	}
+	return ""
+}
+
+// commentStore returns the notes of the commit shown, nil while loading, or
+// the store in the git directory
+func (m Model) commentStore() (store *comments.Store, commit string) {
+	commit = m.viewedCommit()
+	if commit == "" {
+		return m.comments, ""
+	}
+	if m.notes != nil && m.notes.commit == commit {
+		return m.notes.store, commit
+	}
+	return nil, commit
+}
+
+// loadNotes reads the review notes of the commit shown in the background,
+// unless they are loaded already
+func (m *Model) loadNotes() tea.Cmd {
+	keeper, ok := m.repo.(parser.NotesKeeper)
+	store, commit := m.commentStore()
+	if !ok || commit == "" || (store != nil && !m.notes.stale) || m.notesLoad.loading(commit) {
+		return nil
+	}
+	seq := m.notesLoad.start(commit)
+	author := m.noteAuthor
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg := notesLoadedMsg{notesMsg: notesMsg{commit: commit, author: author}, seq: seq}
+		if author == "" {
+			if msg.author, msg.err = keeper.UserName(ctx); msg.err != nil {
+				return msg
+			}
+		}
+		var text string
+		if text, msg.err = keeper.Note(ctx, parser.ReviewNotesRef, commit); msg.err == nil {
+			msg.comments, msg.err = comments.ParseNote(text)
+		}
+		return msg
+	}
+}
+
+// handleNotesLoaded shows notes read in the background, unless another commit
+// is shown by now or the notes were written since
+func (m *Model) handleNotesLoaded(msg notesLoadedMsg) tea.Cmd {
+	if !m.notesLoad.finish(msg.seq) || msg.commit != m.viewedCommit() {
+		return nil
+	}
+	m.applyNotes(msg.notesMsg)
+	if msg.err != nil {
+		return m.notifyf(SeverityError, "Reading the notes of %.7s failed: %v", msg.commit, msg.err)
+	}
+	return nil
+}
+
+// staleNotes has the notes read again, dropping a read already under way
+func (m *Model) staleNotes() {
+	if m.notes != nil {
+		m.notes.stale = true
+	}
+	m.notesLoad.reset()
+}
+
+// editNotes applies edit to the commit's notes as they are in the notes ref,
+// so that notes fetched since they were loaded are kept, and writes them back
+func (m *Model) editNotes(commit string, edit func(*comments.Store), done string) tea.Cmd {
+	keeper := m.repo.(parser.NotesKeeper)
+	return m.startOperation(fmt.Sprintf("notes add %.7s", commit), func(ctx context.Context) tea.Msg {
+		msg := notesMsg{commit: commit, done: done}
+		text, err := keeper.Note(ctx, parser.ReviewNotesRef, commit)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		list, err := comments.ParseNote(text)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		store := comments.NewMemory()
+		for _, c := range list {
+			store.Add(c)
+		}
+		edit(store)
+		msg.err = keeper.SetNote(ctx, parser.ReviewNotesRef, commit, comments.FormatNote(store.All()))
+		msg.comments = store.All()
+		return msg
+	})
+}
+
+// applyNotes shows loaded or written notes; on failure the ones shown stay and
+// are not read again
+func (m *Model) applyNotes(msg notesMsg) {
+	if msg.author != "" {
+		m.noteAuthor = msg.author
+	}
+	if msg.err != nil {
+		if m.notes == nil || m.notes.commit != msg.commit {
+			m.notes = &commitNotes{commit: msg.commit, store: comments.NewMemory()}
+		}
+		m.notes.stale = false
+		return
+	}
+	store := comments.NewMemory()
+	for _, c := range msg.comments {
+		store.Add(c)
+	}
+	m.notes = &commitNotes{commit: msg.commit, store: store}
+
+	if n := len(store.All()); m.commentsActive && n == 0 {
+		m.commentsActive = false
+	} else {
+		m.commentsSelected = max(min(m.commentsSelected, n-1), 0)
+	}
+	m.updateDiffContent()
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"diff-tui/comments"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// notesRepo is a memrepo.Repo that keeps notes in memory, by ref and commit
+type notesRepo struct {
+	*memrepo.Repo
+	notes map[string]string
+}
+
+func (r notesRepo) Note(ctx context.Context, ref, commit string) (string, error) {
+	return r.notes[ref+" "+commit], nil
+}
+
+func (r notesRepo) SetNote(ctx context.Context, ref, commit, text string) error {
+	if text == "" {
+		delete(r.notes, ref+" "+commit)
+	} else {
+		r.notes[ref+" "+commit] = text
+	}
+	return nil
+}
+
+func (notesRepo) UserName(context.Context) (string, error) {
+	return "Grace", nil
+}
+
+// failingNotesRepo is a notesRepo whose notes cannot be written
+type failingNotesRepo struct {
+	notesRepo
+}
+
+func (failingNotesRepo) SetNote(context.Context, string, string, string) error {
+	return errors.New("cannot lock ref")
+}
+
+func TestModel_CommitNotes(t *testing.T) {
+	repo := newHistoryRepo(t, 2)
+	entries, err := repo.Log(context.Background(), parser.LogOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := parser.ReviewNotesRef + " " + entries[0].Hash
+	notes := notesRepo{Repo: repo, notes: map[string]string{
+		key: comments.FormatNote([]comments.Comment{{Path: "a.txt", Side: comments.New, Start: 1, End: 1, Body: "From a teammate", Author: "Ada"}}),
+	}}
+	m := newTestModel(t, repo)
+	m.repo = notes
+
+	// Opening a commit loads its notes
+	m = pressKey(m, "G")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.viewedCommit() != entries[0].Hash || m.notes == nil {
+		t.Fatal("expected the commit's notes to be loaded")
+	}
+	if !strings.Contains(m.rightViewport.View(), "1"+CommentMarker) {
+		t.Error("expected the teammate's comment to be marked")
+	}
+	if strings.Contains(strings.Join(m.output, "\n"), "notes show") {
+		t.Error("expected notes to be read without an operation")
+	}
+
+	// Reading them again does not wait for, or hold up, git actions
+	m.staleNotes()
+	m.op = &operation{name: "commit"}
+	cmd := m.loadNotes()
+	if cmd == nil {
+		t.Fatal("expected notes to be read while an operation runs")
+	}
+	m.op = nil
+	m = runCmd(m, cmd)
+	if m.notes.stale {
+		t.Error("expected the notes to be read again")
+	}
+	m = pressKey(m, "V")
+	if !strings.Contains(m.View(), "Review notes of 0000002 (1)") {
+		t.Fatal("expected the comment panel to list the notes")
+	}
+	m = pressKey(m, "V")
+
+	// New comments go to the notes, signed
+	m = pressKey(m, "tab")
+	m = pressKey(m, "tab")
+	m = pressKey(m, "a")
+	m = pressKey(m, "a")
+	m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Agreed")})
+
+	// The text is kept if the note cannot be written
+	m.repo = failingNotesRepo{notes}
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.commentDraft == nil || m.commentInput.Value() != "Agreed" {
+		t.Fatal("expected the comment input to stay open after a failed write")
+	}
+	m.repo = notes
+	m = update(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if m.commentDraft != nil {
+		t.Error("expected the comment input to close once written")
+	}
+	written, err := comments.ParseNote(notes.notes[key])
+	if err != nil || len(written) != 2 || written[1].Body != "Agreed" || written[1].Author != "Grace" {
+		t.Fatalf("expected the comment in the note, got %+v, %v", written, err)
+	}
+	if len(m.comments.All()) != 0 {
+		t.Error("expected the local comments to be left alone")
+	}
+
+	// A fetch brings in more, which a watched change shows
+	notes.notes[key] += comments.FormatNote([]comments.Comment{{Path: "a.txt", Side: comments.Old, Start: 1, End: 1, Body: "Fetched"}})
+	m = update(m, watchMsg{})
+	if store, _ := m.commentStore(); len(store.All()) != 3 {
+		t.Fatalf("expected the fetched comment, got %+v", store.All())
+	}
+
+	// Deleting rewrites the note
+	m = pressKey(m, "V")
+	m = pressKey(m, "d")
+	m = pressKey(m, "y")
+	if written, _ := comments.ParseNote(notes.notes[key]); len(written) != 2 || written[0].Body == "Fetched" {
+		t.Errorf("expected the first comment deleted from the note, got %+v", written)
+	}
+
+	// Back in the working tree, comments are local again
+	m = pressKey(m, "V")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewedCommit() != "" {
+		t.Fatal("expected to leave the commit")
+	}
+	if store, commit := m.commentStore(); commit != "" || store != m.comments {
+		t.Error("expected the local comments outside a commit")
+	}
+}
@@ -0,0 +1,230 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// maxNotifications bounds the notification history
+	maxNotifications = 100
+
+	// maxToasts is how many toasts are shown at once; older ones are dropped
+	maxToasts = 3
+
+	toastWidth = 48
+)
+
+// toastTick schedules toast expiry; tests replace it to keep toasts up
+var toastTick = tea.Tick
+
+// Severity is the importance of a notification
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "info"
+}
+
+// toastDuration is how long a toast of this severity stays on screen
+func (s Severity) toastDuration() time.Duration {
+	switch s {
+	case SeverityWarning:
+		return 5 * time.Second
+	case SeverityError:
+		return 8 * time.Second
+	}
+	return 3 * time.Second
+}
+
+func (s Severity) color() lipgloss.Color {
+	switch s {
+	case SeverityWarning:
+		return NotifyWarningColor
+	case SeverityError:
+		return NotifyErrorColor
+	}
+	return NotifyInfoColor
+}
+
+// Notification is one message reported to the user
+type Notification struct {
+	ID       int
+	Severity Severity
+	Text     string
+	Time     time.Time
+}
+
+// toastExpiredMsg removes a toast once its time is up
+type toastExpiredMsg struct {
+	id int
+}
+
+// notify records a notification and shows it as a toast; the returned command
+// hides the toast again
+func (m *Model) notify(severity Severity, text string) tea.Cmd {
+	m.nextNotificationID++
+	n := Notification{ID: m.nextNotificationID, Severity: severity, Text: text, Time: time.Now()}
+
+	m.notifications = append(m.notifications, n)
+	if len(m.notifications) > maxNotifications {
+		m.notifications = m.notifications[len(m.notifications)-maxNotifications:]
+	}
+	m.unread = append(m.unread, severity)
+
+	m.toasts = append(m.toasts, n)
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+	return toastTimer(n)
+}
+
+func (m *Model) notifyf(severity Severity, format string, args ...any) tea.Cmd {
+	return m.notify(severity, fmt.Sprintf(format, args...))
+}
+
+func toastTimer(n Notification) tea.Cmd {
+	return toastTick(n.Severity.toastDuration(), func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: n.ID}
+	})
+}
+
+// expireToast hides the toast with the given id
+func (m *Model) expireToast(id int) {
+	for i, t := range m.toasts {
+		if t.ID == id {
+			m.toasts = append(m.toasts[:i:i], m.toasts[i+1:]...)
+			return
+		}
+	}
+}
+
+// unreadSeverity is the most severe notification not yet seen in the history
+func (m Model) unreadSeverity() Severity {
+	worst := SeverityInfo
+	for _, s := range m.unread {
+		worst = max(worst, s)
+	}
+	return worst
+}
+
+// notificationIndicator is the status-line marker for unread notifications
+func (m Model) notificationIndicator() string {
+	if len(m.unread) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(m.unreadSeverity().color()).
+		Render(fmt.Sprintf("● %d", len(m.unread)))
+}
+
+// openNotifications shows the notification history and marks it read
+func (m *Model) openNotifications() {
+	m.notificationsActive = true
+	m.notificationsScroll = 0
+	m.unread = nil
+}
+
+// updateNotifications handles input while the notification history is open
+func (m Model) updateNotifications(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel), key.Matches(keyMsg, m.keys.Notifications):
+		m.notificationsActive = false
+	case key.Matches(keyMsg, m.keys.Quit):
+		m.cancelOperation()
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Down):
+		m.notificationsScroll = min(m.notificationsScroll+1, max(len(m.notifications)-1, 0))
+	case key.Matches(keyMsg, m.keys.Up):
+		m.notificationsScroll = max(m.notificationsScroll-1, 0)
+	}
+	return m, nil
+}
+
+// renderToasts overlays the visible toasts on the bottom right of background
+func (m Model) renderToasts(background string) string {
+	if len(m.toasts) == 0 {
+		return background
+	}
+
+	boxes := make([]string, 0, len(m.toasts))
+	for _, t := range m.toasts {
+		boxes = append(boxes, ToastStyle.
+			BorderForeground(t.Severity.color()).
+			Width(toastWidth).
+			Render(t.Text))
+	}
+	return overlay(background, lipgloss.JoinVertical(lipgloss.Right, boxes...), m.width)
+}
+
+// overlay draws box over the bottom right corner of background, leaving a
+// line for the panel borders below it
+func overlay(background, box string, width int) string {
+	lines := strings.Split(background, "\n")
+	boxLines := strings.Split(box, "\n")
+	boxWidth := lipgloss.Width(box)
+
+	col := max(width-boxWidth-2, 0)
+	top := max(len(lines)-len(boxLines)-2, 0)
+	for i, boxLine := range boxLines {
+		row := top + i
+		if row >= len(lines) {
+			break
+		}
+		line := lines[row]
+		left := ansi.Truncate(line, col, "")
+		if pad := col - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(line, col+ansi.StringWidth(boxLine), "")
+		lines[row] = left + boxLine + right
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderNotifications renders the notification history, newest first
+func (m Model) renderNotifications() string {
+	title := ModalTitleStyle.Render("Notifications")
+
+	var lines []string
+	for i := len(m.notifications) - 1; i >= 0; i-- {
+		n := m.notifications[i]
+		label := lipgloss.NewStyle().Foreground(n.Severity.color()).Render(fmt.Sprintf("%-7s", n.Severity))
+		lines = append(lines, fmt.Sprintf("%s %s %s", n.Time.Format("15:04:05"), label, n.Text))
+	}
+	if len(lines) == 0 {
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No notifications yet")}
+	}
+
+	visible := max(m.height-12, 1)
+	start := min(m.notificationsScroll, max(len(lines)-1, 0))
+	end := min(start+visible, len(lines))
+
+	body := lipgloss.NewStyle().Width(commitModalWidth + 20).Render(strings.Join(lines[start:end], "\n"))
+	help := ModalHelpStyle.Render("j/k: scroll | N/Esc: close")
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, body, help))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"diff-tui/memrepo"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func init() {
+	// Toasts stay up in tests; expiry is driven by toastExpiredMsg directly
+	toastTick = func(time.Duration, func(time.Time) tea.Msg) tea.Cmd { return nil }
+}
+
+// stageFailingRepo is a memrepo.Repo whose StageFile always fails
+type stageFailingRepo struct {
+	*memrepo.Repo
+}
+
+func (stageFailingRepo) StageFile(context.Context, string) error {
+	return errors.New("index.lock exists")
+}
+
+func TestModel_NotifiesStaging(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	m := newTestModel(t, repo)
+
+	m = pressKey(m, " ")
+
+	if len(m.toasts) != 1 || m.toasts[0].Text != "Staged a.txt" || m.toasts[0].Severity != SeverityInfo {
+		t.Fatalf("toasts = %+v", m.toasts)
+	}
+	if !strings.Contains(m.View(), "Staged a.txt") {
+		t.Error("expected the toast on screen")
+	}
+
+	m = update(m, toastExpiredMsg{id: m.toasts[0].ID})
+	if len(m.toasts) != 0 {
+		t.Errorf("expected the toast to expire, got %+v", m.toasts)
+	}
+	if len(m.notifications) != 1 {
+		t.Errorf("expected the notification in the history, got %d", len(m.notifications))
+	}
+}
+
+func TestModel_NotifiesErrors(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	m := newTestModel(t, repo)
+	m.repo = stageFailingRepo{repo}
+
+	m = pressKey(m, " ")
+
+	if len(m.toasts) != 1 || m.toasts[0].Severity != SeverityError ||
+		!strings.Contains(m.toasts[0].Text, "index.lock exists") {
+		t.Fatalf("toasts = %+v", m.toasts)
+	}
+	if m.unreadSeverity() != SeverityError || !strings.Contains(m.notificationIndicator(), "1") {
+		t.Errorf("expected an error indicator, got %q", m.notificationIndicator())
+	}
+
+	m = pressKey(m, "N")
+	if !m.notificationsActive || !strings.Contains(m.View(), "Notifications") {
+		t.Fatal("expected N to open the notification history")
+	}
+	if m.notificationIndicator() != "" {
+		t.Error("expected opening the history to mark notifications read")
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.notificationsActive {
+		t.Error("expected esc to close the history")
+	}
+}
+
+func TestModel_NotifiesCommit(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	m := newTestModel(t, repo)
+	m = pressKey(m, " ")
+
+	m = pressKey(m, "c")
+	m.commitSubject.SetValue("Add two")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	last := m.notifications[len(m.notifications)-1]
+	if last.Text != "Committed: Add two" {
+		t.Errorf("last notification = %q", last.Text)
+	}
+}
+
+func TestModel_NotificationHistoryIsBounded(t *testing.T) {
+	var m Model
+	for i := 0; i < maxNotifications+5; i++ {
+		m.notify(SeverityInfo, "note")
+	}
+	if len(m.notifications) != maxNotifications || len(m.toasts) != maxToasts {
+		t.Errorf("got %d notifications, %d toasts", len(m.notifications), len(m.toasts))
+	}
+}
@@ -0,0 +1,402 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxOutputLines bounds the output panel's scrollback
+const maxOutputLines = 500
+
+// operation is the one git command running in the background, streaming its
+// output into the output panel
+type operation struct {
+	id      int
+	name    string
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// backgroundLoad tracks data loaded for display outside the operation slot;
+// only the latest load counts
+type backgroundLoad struct {
+	key string // What is being loaded; empty when nothing is
+	seq int
+}
+
+// start records a load of key and returns its number for finish
+func (l *backgroundLoad) start(key string) int {
+	l.key = key
+	l.seq++
+	return l.seq
+}
+
+// loading reports whether key is being loaded
+func (l backgroundLoad) loading(key string) bool {
+	return l.key != "" && l.key == key
+}
+
+// finish reports whether load seq is still the latest, and if so ends it
+func (l *backgroundLoad) finish(seq int) bool {
+	if seq != l.seq {
+		return false
+	}
+	l.key = ""
+	return true
+}
+
+// reset forgets the load in progress, whose result will be dropped
+func (l *backgroundLoad) reset() {
+	l.key = ""
+	l.seq++
+}
+
+// opOutputMsg carries a chunk of output written by a running operation
+type opOutputMsg struct {
+	id     int
+	text   string
+	output <-chan string // Read again for the next chunk
+}
+
+// opDoneMsg wraps the result message of a finished operation
+type opDoneMsg struct {
+	id     int
+	result tea.Msg
+}
+
+// refreshedMsg carries freshly loaded diff and status data
+type refreshedMsg struct {
+	files  []diff.FileDiff     // Flat view
+	wt     *parser.WorkingTree // Grouped view
+	status *parser.Status
+	done   string // Success notification, if the refresh followed a change
+	err    error
+}
+
+// stagedMsg reports a staging change in the flat view, where the file list is kept
+type stagedMsg struct {
+	path   string
+	staged bool
+	status *parser.Status
+	err    error
+}
+
+// committedMsg reports the result of a commit, plus the data reloaded after it
+type committedMsg struct {
+	refresh refreshedMsg
+	opts    parser.CommitOptions
+	err     error
+}
+
+// chanWriter forwards writes to a channel so output can reach Update
+type chanWriter chan<- string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+// startOperation runs fn in the background with a cancellable context that
+// streams its git output, unless another operation is running; fn's message
+// comes back in an opDoneMsg
+func (m *Model) startOperation(name string, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	if m.op != nil {
+		return m.notifyf(SeverityWarning, "Busy: %s is still running", m.op.name)
+	}
+
+	m.nextOpID++
+	id := m.nextOpID
+	ctx, cancel := context.WithCancel(context.Background())
+	m.op = &operation{id: id, name: name, started: time.Now(), cancel: cancel}
+	m.appendOutputLine("$ " + name)
+
+	output := make(chan string, 64)
+	ctx = parser.WithOutput(ctx, chanWriter(output))
+
+	run := func() tea.Msg {
+		defer close(output)
+		defer cancel()
+		return opDoneMsg{id: id, result: fn(ctx)}
+	}
+
+	return tea.Batch(run, waitForOutput(id, output), m.spinner.Tick)
+}
+
+// waitForOutput delivers the next chunk of output; it ends when the channel closes
+func waitForOutput(id int, output <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		text, ok := <-output
+		if !ok {
+			return nil
+		}
+		return opOutputMsg{id: id, text: text, output: output}
+	}
+}
+
+// cancelOperation stops the running operation, if any
+func (m *Model) cancelOperation() bool {
+	if m.op == nil {
+		return false
+	}
+	m.op.cancel()
+	return true
+}
+
+// updateOperation handles operation lifecycle messages; handled reports
+// whether msg was one of them
+func (m *Model) updateOperation(msg tea.Msg) (cmd tea.Cmd, handled bool) {
+	switch msg := msg.(type) {
+	case opOutputMsg:
+		m.appendOutput(msg.text)
+		return waitForOutput(msg.id, msg.output), true
+
+	case opDoneMsg:
+		if m.op == nil || m.op.id != msg.id {
+			return nil, true
+		}
+		name := m.op.name
+		m.op = nil
+		cmd := m.handleResult(name, msg.result)
+		return tea.Batch(cmd, m.refreshWatched(), m.loadNotes()), true
+	}
+	return nil, false
+}
+
+// handleResult applies the result of a finished operation and reports it
+func (m *Model) handleResult(name string, msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case refreshedMsg:
+		m.applyRefresh(msg)
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		if msg.done != "" {
+			return tea.Batch(m.notify(SeverityInfo, msg.done), m.loadBlame(), m.loadThreeWay())
+		}
+		return tea.Batch(m.loadBlame(), m.loadThreeWay())
+
+	case stagedMsg:
+		m.applyStaged(msg)
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		return m.notify(SeverityInfo, stagedNotice(msg.path, msg.staged))
+
+	case committedMsg:
+		if msg.err != nil {
+			m.commitError = msg.err.Error()
+			return m.reportError(name, msg.err)
+		}
+		m.closeCommitModal()
+		m.logEntries = nil // Reloaded with the new commit when the log is opened
+		m.applyRefresh(msg.refresh)
+		if msg.refresh.err != nil {
+			return m.reportError("refresh", msg.refresh.err)
+		}
+		return m.notify(SeverityInfo, committedNotice(msg.opts))
+
+	case headMessageMsg:
+		m.applyHeadMessage(msg)
+
+	case commitTemplateMsg:
+		if !m.commitModalActive {
+			return nil
+		}
+		return m.launchEditor(msg.template)
+
+	case stashListMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		m.stashEntries = msg.entries
+		m.stashSelected = min(m.stashSelected, max(len(m.stashEntries)-1, 0))
+
+	case stashDiffMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		m.applyStashDiff(msg)
+
+	case logPageMsg:
+		if msg.err != nil {
+			m.logLoading = false
+			if m.logEntries == nil {
+				// Nothing to show; opening the panel again retries
+				m.logActive = false
+			}
+			return m.reportError(name, msg.err)
+		}
+		m.applyLogPage(msg)
+
+	case commitDiffMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		m.applyCommitDiff(msg)
+
+	case refCandidatesMsg:
+		if msg.err != nil {
+			m.closeRefPicker()
+			return m.reportError(name, msg.err)
+		}
+		m.applyRefCandidates(msg)
+
+	case reviewStepMsg:
+		if msg.refresh.err != nil {
+			return m.reportError(name, msg.refresh.err)
+		}
+		m.applyReviewStep(msg)
+
+	case interdiffMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		return m.applyInterdiff(msg)
+
+	case notesMsg:
+		// A read that started before the notes were written is out of date
+		m.notesLoad.reset()
+		m.applyNotes(msg)
+		if m.commentWriting {
+			// A comment's input is kept open, with its text, if it failed
+			m.commentWriting = false
+			if msg.err == nil {
+				m.closeCommentInput()
+			}
+		}
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		if msg.done != "" {
+			return m.notify(SeverityInfo, msg.done)
+		}
+
+	case fileHistoryMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		return m.applyFileHistory(msg)
+
+	case conflictsMsg:
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		return m.applyConflicts(msg)
+
+	case conflictResolvedMsg:
+		if !msg.resolved {
+			return m.reportError(name, msg.err)
+		}
+		cmd := m.applyConflictResolved(msg)
+		if msg.err != nil {
+			return tea.Batch(cmd, m.reportError("status", msg.err))
+		}
+		return cmd
+
+	case stashDoneMsg:
+		m.applyStashDone(msg)
+		if msg.err != nil {
+			return m.reportError(name, msg.err)
+		}
+		return m.notify(SeverityInfo, msg.done)
+	}
+	return nil
+}
+
+// reportError notifies about a failed or cancelled operation and writes it to
+// the output panel
+func (m *Model) reportError(name string, err error) tea.Cmd {
+	if errors.Is(err, context.Canceled) {
+		m.appendOutputLine("cancelled")
+		return m.notifyf(SeverityWarning, "%s cancelled", name)
+	}
+	m.appendOutputLine("error: " + err.Error())
+	return m.notifyf(SeverityError, "%s failed: %v", name, err)
+}
+
+// stagedNotice is the success notification for staging or unstaging path
+func stagedNotice(path string, staged bool) string {
+	if staged {
+		return "Staged " + path
+	}
+	return "Unstaged " + path
+}
+
+// committedNotice is the success notification for a commit
+func committedNotice(opts parser.CommitOptions) string {
+	subject, _ := parser.SplitCommitMessage(opts.Message)
+	if opts.Amend {
+		return "Amended commit: " + subject
+	}
+	return "Committed: " + subject
+}
+
+// appendOutput adds streamed text to the output panel, keeping the last
+// maxOutputLines; a chunk without a trailing newline is continued by the next
+func (m *Model) appendOutput(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	if m.outputOpenLine && len(m.output) > 0 {
+		m.output[len(m.output)-1] += lines[0]
+		lines = lines[1:]
+	}
+	m.outputOpenLine = !strings.HasSuffix(text, "\n")
+	if !m.outputOpenLine {
+		lines = lines[:len(lines)-1]
+	}
+
+	m.output = append(m.output, lines...)
+	if len(m.output) > maxOutputLines {
+		m.output = m.output[len(m.output)-maxOutputLines:]
+	}
+}
+
+// appendOutputLine adds a complete line of our own to the output panel
+func (m *Model) appendOutputLine(line string) {
+	m.outputOpenLine = false
+	m.appendOutput(line + "\n")
+}
+
+// loadRefresh reads everything a refresh needs; it runs off the UI goroutine
+func loadRefresh(ctx context.Context, repo parser.Repository, sections bool, args []string) refreshedMsg {
+	var msg refreshedMsg
+	if sections {
+		msg.wt, msg.err = repo.WorkingTree(ctx)
+	} else if parser.IsWorkingTreeDiff(args) {
+		// The working-tree view also lists untracked files
+		var wt *parser.WorkingTree
+		if wt, msg.err = repo.WorkingTree(ctx); msg.err == nil {
+			msg.files = wt.Unstaged.Files
+		}
+	} else {
+		// An empty diff is valid here (e.g. everything was committed)
+		msg.files, msg.err = repo.Diff(ctx, args...)
+	}
+	if msg.err != nil {
+		return msg
+	}
+
+	msg.status, msg.err = repo.Status(ctx)
+	return msg
+}
+
+// operationStatus describes the running operation for the status line
+func (m Model) operationStatus() string {
+	if m.op == nil {
+		return ""
+	}
+	elapsed := time.Since(m.op.started).Truncate(time.Second)
+	return fmt.Sprintf("%s %s %s (esc: cancel)", m.spinner.View(), m.op.name, elapsed)
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// rangeDiff is the range-diff panel: the commits of two versions of a series,
+// paired up by git range-diff
+type rangeDiff struct {
+	args     []string // git range-diff args, for the panel title
+	pairs    []parser.RangePair
+	selected int
+	active   bool
+}
+
+// interdiffMsg carries the diff-of-diffs of a range-diff pair
+type interdiffMsg struct {
+	index int
+	files []diff.FileDiff
+	err   error
+}
+
+// WithRangeDiff opens the range-diff panel with the pairs of args
+func (m Model) WithRangeDiff(args []string, pairs []parser.RangePair) Model {
+	m.rangeDiff = &rangeDiff{args: args, pairs: pairs, active: true}
+	return m
+}
+
+// toggleRangeDiff shows or hides the range-diff panel
+func (m *Model) toggleRangeDiff() tea.Cmd {
+	if m.rangeDiff == nil {
+		return m.notify(SeverityInfo, "Compare two series with diff-tui range-diff <old> <new>")
+	}
+	m.rangeDiff.active = !m.rangeDiff.active
+	return nil
+}
+
+// updateRangeDiff handles input while the range-diff panel is open
+func (m Model) updateRangeDiff(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.op != nil && key.Matches(keyMsg, m.keys.Cancel) {
+		m.cancelOperation()
+		return m, nil
+	}
+
+	r := m.rangeDiff
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel), key.Matches(keyMsg, m.keys.RangeDiff):
+		r.active = false
+	case key.Matches(keyMsg, m.keys.Quit):
+		m.cancelOperation()
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Down):
+		r.selected = min(r.selected+1, max(len(r.pairs)-1, 0))
+	case key.Matches(keyMsg, m.keys.Up):
+		r.selected = max(r.selected-1, 0)
+	case key.Matches(keyMsg, m.keys.Enter):
+		return m, m.showInterdiff()
+	}
+	return m, nil
+}
+
+// showInterdiff loads the selected pair's diff-of-diffs
+func (m *Model) showInterdiff() tea.Cmd {
+	differ, ok := m.repo.(parser.RangeDiffer)
+	if !ok || m.rangeDiff.selected >= len(m.rangeDiff.pairs) {
+		return nil
+	}
+	index := m.rangeDiff.selected
+	pair := m.rangeDiff.pairs[index]
+	return m.startOperation("interdiff "+pairLabel(pair), func(ctx context.Context) tea.Msg {
+		files, err := differ.Interdiff(ctx, pair.Old, pair.New)
+		return interdiffMsg{index: index, files: files, err: err}
+	})
+}
+
+// applyInterdiff shows a pair's diff-of-diffs, old patch on the left and new
+// patch on the right
+func (m *Model) applyInterdiff(msg interdiffMsg) tea.Cmd {
+	pair := m.rangeDiff.pairs[msg.index]
+	if len(msg.files) == 0 {
+		return m.notifyf(SeverityInfo, "%s is unchanged", pairLabel(pair))
+	}
+	m.rangeDiff.active = false
+	header := []string{
+		fmt.Sprintf("%s  %s", pairLabel(pair), pair.Subject),
+		"Original: the old version's patch   Modified: the new version's patch",
+	}
+	m.showDetached(fmt.Sprintf("Pair %d/%d", msg.index+1, len(m.rangeDiff.pairs)), header, "", msg.files)
+	return nil
+}
+
+// pairLabel names a pair the way git range-diff lists it
+func pairLabel(p parser.RangePair) string {
+	side := func(index int, hash string) string {
+		if hash == "" {
+			return "-: -------"
+		}
+		return fmt.Sprintf("%d: %s", index, hash)
+	}
+	return fmt.Sprintf("%s %c %s", side(p.OldIndex, p.Old), p.Status, side(p.NewIndex, p.New))
+}
+
+// rangeStatusStyle colors a pair's status like the file list colors files
+func rangeStatusStyle(status parser.RangeStatus) lipgloss.Style {
+	switch status {
+	case parser.RangeChanged:
+		return StatusModifiedStyle
+	case parser.RangeAdded:
+		return StatusNewStyle
+	case parser.RangeRemoved:
+		return StatusDeletedStyle
+	}
+	return lipgloss.NewStyle()
+}
+
+// renderRangeDiff renders the commit pairs over the main view
+func (m Model) renderRangeDiff() string {
+	r := m.rangeDiff
+	title := ModalTitleStyle.Render("Range-diff " + strings.Join(r.args, " "))
+	width := commitModalWidth + 20
+
+	var lines []string
+	for i, p := range r.pairs {
+		line := fmt.Sprintf("%-24s %s", pairLabel(p), p.Subject)
+		line = truncate(line, width)
+		if i == r.selected {
+			line = FileItemSelectedStyle.Width(width).Render(line)
+		} else {
+			line = rangeStatusStyle(p.Status).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No commits")}
+	}
+
+	// Keep the selection in view
+	visible := max(m.height-12, 1)
+	start := max(r.selected-visible+1, 0)
+	end := min(start+visible, len(lines))
+	body := lipgloss.NewStyle().Width(width).Render(strings.Join(lines[start:end], "\n"))
+
+	help := "enter: diff of the patches | =: same  !: changed  <: removed  >: added | R/Esc: close"
+	if m.op != nil {
+		help = m.operationStatus()
+	}
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, body, ModalHelpStyle.Render(help)))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rangeRepo is a memrepo.Repo whose interdiffs are made up from the hashes
+type rangeRepo struct {
+	*memrepo.Repo
+}
+
+func (rangeRepo) RangeDiff(context.Context, ...string) ([]parser.RangePair, error) {
+	return nil, nil
+}
+
+func (rangeRepo) Interdiff(ctx context.Context, oldCommit, newCommit string) ([]diff.FileDiff, error) {
+	if oldCommit == "aaaaaaa" && newCommit == "bbbbbbb" {
+		return nil, nil // Same patch
+	}
+	return parser.InterdiffPatches(
+		map[string]string{"f.go": "-x\n+" + oldCommit + "\n"},
+		map[string]string{"f.go": "-x\n+" + newCommit + "\n"},
+	), nil
+}
+
+func TestModel_RangeDiffPanel(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "a\n"})
+	repo.WriteFile("a.txt", "b\n")
+	m := newTestModel(t, repo)
+	m.repo = rangeRepo{Repo: repo}
+	m = m.WithRangeDiff([]string{"main", "v1", "v2"}, []parser.RangePair{
+		{OldIndex: 1, Old: "aaaaaaa", NewIndex: 1, New: "bbbbbbb", Status: parser.RangeEqual, Subject: "Same"},
+		{OldIndex: 2, Old: "ccccccc", NewIndex: 2, New: "ddddddd", Status: parser.RangeChanged, Subject: "Reworked"},
+	})
+
+	if view := m.View(); !strings.Contains(view, "Range-diff main v1 v2") || !strings.Contains(view, "2: ccccccc ! 2: ddddddd") {
+		t.Fatal("expected the pairs panel")
+	}
+
+	// An unchanged pair has nothing to show
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detached != nil || !m.rangeDiff.active {
+		t.Fatal("expected to stay in the panel for an unchanged pair")
+	}
+
+	m = pressKey(m, "j")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detached == nil || m.rangeDiff.active {
+		t.Fatal("expected the interdiff in the main view")
+	}
+	if len(m.files) != 1 || m.files[0].Name != "f.go" || m.files[0].AddCount != 1 {
+		t.Fatalf("files = %+v", m.files)
+	}
+	if !strings.Contains(m.View(), "Pair 2/2") {
+		t.Error("expected the pair in the file list title")
+	}
+
+	// Esc goes back to the working tree, R reopens the pairs
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.detached != nil {
+		t.Fatal("expected esc to leave the interdiff")
+	}
+	m = pressKey(m, "R")
+	if !m.rangeDiff.active || m.rangeDiff.selected != 1 {
+		t.Error("expected R to reopen the panel at the same pair")
+	}
+}
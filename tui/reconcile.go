@@ -0,0 +1,154 @@
+package tui
+
+import "diff-tui/diff"
+
+// nodeKey identifies a tree node across rebuilds of the tree
+type nodeKey struct {
+	section Section
+	path    string
+	dir     bool
+}
+
+func keyOf(n *TreeNode) nodeKey {
+	return nodeKey{section: n.Section, path: n.Path, dir: n.IsDirectory()}
+}
+
+// scrollAnchor is the diffs' scroll position by line number: the first
+// numbered line from the top, preferring the original side, and the rows above
+type scrollAnchor struct {
+	number int  // 0 if the pane showed no numbered line
+	right  bool // number is a line of the new side
+	above  int
+	offset int // Row offset, used when the line is not found again
+	skew   int // How far the right pane is scrolled past the left one
+}
+
+// treeState is what a refresh keeps of the file tree: the collapsed nodes, the
+// selected node with the files around it in case it is gone, and where the
+// diffs were scrolled to
+type treeState struct {
+	collapsed  map[nodeKey]bool
+	selected   nodeKey
+	neighbours []nodeKey // Visible files, nearest to the selected node first
+	index      int
+	scroll     scrollAnchor
+}
+
+// saveTreeState records the tree's state before it is rebuilt
+func (m *Model) saveTreeState() treeState {
+	s := treeState{collapsed: make(map[nodeKey]bool), index: m.selectedIdx}
+	var walk func(nodes []*TreeNode)
+	walk = func(nodes []*TreeNode) {
+		for _, n := range nodes {
+			if n.IsExpandable() && !n.Expanded {
+				s.collapsed[keyOf(n)] = true
+			}
+			walk(n.Children)
+		}
+	}
+	walk(m.treeRoots)
+
+	if m.selectedIdx >= len(m.visibleNodes) {
+		return s
+	}
+	node := m.visibleNodes[m.selectedIdx]
+	s.selected = keyOf(node)
+
+	// Following files come first at the same distance, so that staging files
+	// one after the other moves down the list
+	for d := 1; d < len(m.visibleNodes); d++ {
+		for _, i := range []int{m.selectedIdx + d, m.selectedIdx - d} {
+			if i >= 0 && i < len(m.visibleNodes) && m.visibleNodes[i].IsFile() {
+				s.neighbours = append(s.neighbours, keyOf(m.visibleNodes[i]))
+			}
+		}
+	}
+
+	if node.File != nil && !m.threePaneView() {
+		s.scroll = anchorAt(node.File, m.leftViewport.YOffset)
+		s.scroll.skew = m.rightViewport.YOffset - m.leftViewport.YOffset
+	}
+	return s
+}
+
+// restoreTreeState applies a saved state to the rebuilt tree and selects the
+// same node, or the nearest file that is still there
+func (m *Model) restoreTreeState(s treeState) {
+	var walk func(nodes []*TreeNode)
+	walk = func(nodes []*TreeNode) {
+		for _, n := range nodes {
+			if s.collapsed[keyOf(n)] {
+				n.Expanded = false
+			}
+			walk(n.Children)
+		}
+	}
+	walk(m.treeRoots)
+	m.visibleNodes = FlattenVisible(m.treeRoots)
+
+	index := make(map[nodeKey]int, len(m.visibleNodes))
+	for i, n := range m.visibleNodes {
+		index[keyOf(n)] = i
+	}
+	for _, key := range append([]nodeKey{s.selected}, s.neighbours...) {
+		if i, ok := index[key]; ok {
+			m.selectedIdx = i
+			return
+		}
+	}
+	m.selectFileNear(s.index)
+}
+
+// restoreScroll scrolls the diffs back to the saved lines if the same file is
+// still selected; call it once the new content is set
+func (m *Model) restoreScroll(s treeState) {
+	if m.selectedIdx >= len(m.visibleNodes) || m.threePaneView() {
+		return
+	}
+	node := m.visibleNodes[m.selectedIdx]
+	if node.File == nil || keyOf(node) != s.selected {
+		m.leftViewport.GotoTop()
+		m.rightViewport.GotoTop()
+		return
+	}
+	row := s.scroll.rowIn(node.File)
+	m.leftViewport.SetYOffset(row)
+	m.rightViewport.SetYOffset(row + s.scroll.skew)
+}
+
+// anchorAt anchors a diff scrolled to offset on its first numbered line
+func anchorAt(file *diff.FileDiff, offset int) scrollAnchor {
+	a := scrollAnchor{offset: offset}
+	for _, right := range []bool{false, true} {
+		lines := side(file, right)
+		for i := offset; i < len(lines); i++ {
+			if lines[i].Number > 0 {
+				a.number, a.right, a.above = lines[i].Number, right, i-offset
+				return a
+			}
+		}
+	}
+	return a
+}
+
+// rowIn returns the row offset that puts the anchored line, or the first one
+// after it, where it was in the pane
+func (a scrollAnchor) rowIn(file *diff.FileDiff) int {
+	if a.number == 0 {
+		return a.offset
+	}
+	for i, line := range side(file, a.right) {
+		if line.Number >= a.number {
+			return max(i-a.above, 0)
+		}
+	}
+	return a.offset
+}
+
+// side returns the rows of the new (right) or original side of a diff
+func side(file *diff.FileDiff, right bool) []diff.Line {
+	if right {
+		return file.RightLines
+	}
+	return file.LeftLines
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"diff-tui/memrepo"
+)
+
+func TestModel_RefreshKeepsTreeState(t *testing.T) {
+	repo := memrepo.New(map[string]string{"docs/x.md": "x\n", "src/a.go": "a\n", "src/b.go": "b\n", "src/c.go": "c\n"})
+	repo.WriteFile("docs/x.md", "x2\n")
+	repo.WriteFile("src/a.go", "a2\n")
+	repo.WriteFile("src/b.go", "b2\n")
+	repo.WriteFile("src/c.go", "c2\n")
+	m := newTestModel(t, repo)
+
+	m.visibleNodes[findNode(t, m, "docs")].ToggleExpanded()
+	m.refreshVisibleNodes()
+	m.selectedIdx = findNode(t, m, "src/b.go")
+
+	repo.WriteFile("src/a.go", "a3\n")
+	m = runCmd(m, m.refreshDiff())
+	if got := m.visibleNodes[m.selectedIdx].Path; got != "src/b.go" {
+		t.Errorf("expected src/b.go still selected, got %s", got)
+	}
+	for _, n := range m.visibleNodes {
+		if n.Path == "docs/x.md" {
+			t.Error("expected docs to stay collapsed")
+		}
+	}
+
+	// Staging the selected file moves on to the next one
+	m = pressKey(m, " ")
+	if got := m.visibleNodes[m.selectedIdx]; got.Path != "src/c.go" || got.Section != SectionUnstaged {
+		t.Errorf("expected the next unstaged file selected, got %s", got.Path)
+	}
+}
+
+func TestModel_RefreshKeepsScrollLine(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 200; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	original := strings.Join(lines, "\n") + "\n"
+	edited := func(extra int) string {
+		changed := append([]string(nil), lines...)
+		changed[4] = strings.Repeat("new\n", extra) + "changed 5"
+		for i := 149; i < 199; i++ {
+			changed[i] = fmt.Sprintf("changed %d", i+1)
+		}
+		return strings.Join(changed, "\n") + "\n"
+	}
+
+	repo := memrepo.New(map[string]string{"big.txt": original})
+	repo.WriteFile("big.txt", edited(0))
+	m := newTestModel(t, repo)
+	m.selectedIdx = findNode(t, m, "big.txt")
+	m.updateDiffContent()
+
+	// Scroll to the second hunk
+	file := m.visibleNodes[m.selectedIdx].File
+	row := -1
+	for i, line := range file.LeftLines {
+		if line.Number == 147 {
+			row = i
+			break
+		}
+	}
+	if row < 0 {
+		t.Fatal("expected line 147 in the diff")
+	}
+	m.leftViewport.SetYOffset(row)
+	m.rightViewport.SetYOffset(row)
+
+	// Growing the first hunk pushes the second one down
+	repo.WriteFile("big.txt", edited(5))
+	m = runCmd(m, m.refreshDiff())
+	file = m.visibleNodes[m.selectedIdx].File
+	if top := file.LeftLines[m.leftViewport.YOffset]; top.Number != 147 {
+		t.Errorf("expected line 147 at the top, got %d", top.Number)
+	}
+	if m.rightViewport.YOffset != m.leftViewport.YOffset {
+		t.Error("expected the panes to stay in step")
+	}
+}
@@ -0,0 +1,371 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// recentCommits is how many commits the ref picker offers besides refs
+	recentCommits = 20
+
+	// headAncestors is how many HEAD~N entries the ref picker offers
+	headAncestors = 5
+)
+
+// comparison is a diff between two revisions picked in the ref picker
+type comparison struct {
+	base     string
+	target   string // Empty compares with the working tree
+	threeDot bool   // Diff from the merge base of base and target
+}
+
+// Args returns the git diff args for the comparison
+func (c comparison) Args() []string {
+	switch {
+	case c.target == "" && c.threeDot:
+		return []string{"--merge-base", c.base}
+	case c.target == "":
+		return []string{c.base}
+	case c.threeDot:
+		return []string{c.base + "..." + c.target}
+	}
+	return []string{c.base, c.target}
+}
+
+// String names the comparison the way the args read
+func (c comparison) String() string {
+	target := c.target
+	if target == "" {
+		target = "working tree"
+	}
+	if c.threeDot {
+		return c.base + "..." + target
+	}
+	return c.base + ".." + target
+}
+
+// swapped flips base and target; the working tree can only be a target, so
+// such a comparison is returned unchanged
+func (c comparison) swapped() comparison {
+	if c.target == "" {
+		return c
+	}
+	c.base, c.target = c.target, c.base
+	return c
+}
+
+// refCandidate is one entry of the ref picker
+type refCandidate struct {
+	rev    string // Passed to git; empty is the working tree
+	kind   string
+	detail string
+	back   bool // Leaves the comparison for the staged and unstaged view
+}
+
+// label is what the picker shows and fuzzy search matches
+func (c refCandidate) label() string {
+	if c.back {
+		return "back to working tree"
+	}
+	if c.rev == "" {
+		return "working tree"
+	}
+	return c.rev
+}
+
+// refCandidatesMsg carries what the ref picker can offer
+type refCandidatesMsg struct {
+	candidates []refCandidate
+	err        error
+}
+
+// refPicker is the state of the ref picker modal
+type refPicker struct {
+	active     bool
+	cmp        comparison
+	editTarget bool // Picking the target; otherwise the base
+	query      textinput.Model
+	candidates []refCandidate
+	matches    []int // Indexes into candidates, best match first
+	selected   int   // Index into matches
+}
+
+// newRefQuery creates the ref picker's search input
+func newRefQuery() textinput.Model {
+	query := textinput.New()
+	query.Prompt = "> "
+	query.Placeholder = "branch, tag or commit"
+	query.Width = commitModalWidth
+	return query
+}
+
+// openRefPicker opens the picker, starting from the current comparison
+func (m *Model) openRefPicker() tea.Cmd {
+	refs, hasRefs := m.repo.(parser.RefLister)
+	history, hasHistory := m.repo.(parser.LogReader)
+	if !hasRefs && !hasHistory {
+		return m.notify(SeverityWarning, "This repository has no revisions to compare")
+	}
+
+	m.picker.active = true
+	m.picker.cmp = comparison{base: "HEAD"}
+	if m.compare != nil {
+		m.picker.cmp = *m.compare
+	}
+	m.picker.editTarget = false
+	m.picker.query.SetValue("")
+	m.picker.selected = 0
+	focus := m.picker.query.Focus()
+	back := m.compare != nil && m.review == nil
+
+	return tea.Batch(focus, m.startOperation("list refs", func(ctx context.Context) tea.Msg {
+		var msg refCandidatesMsg
+		if back {
+			msg.candidates = append(msg.candidates, refCandidate{kind: "view", detail: "staged and unstaged changes", back: true})
+		}
+		msg.candidates = append(msg.candidates, refCandidate{rev: "", kind: "worktree"}, refCandidate{rev: "HEAD", kind: "head"})
+		for i := 1; i <= headAncestors; i++ {
+			msg.candidates = append(msg.candidates, refCandidate{rev: fmt.Sprintf("HEAD~%d", i), kind: "head"})
+		}
+		if hasRefs {
+			list, err := refs.Refs(ctx)
+			if err != nil {
+				msg.err = err
+				return msg
+			}
+			for _, ref := range list {
+				msg.candidates = append(msg.candidates, refCandidate{rev: ref.Name, kind: ref.Kind.String(), detail: ref.Subject})
+			}
+		}
+		if hasHistory {
+			commits, err := history.Log(ctx, parser.LogOptions{Limit: recentCommits})
+			if err != nil {
+				msg.err = err
+				return msg
+			}
+			for _, c := range commits {
+				msg.candidates = append(msg.candidates, refCandidate{rev: c.ShortHash, kind: "commit", detail: c.Subject})
+			}
+		}
+		return msg
+	}))
+}
+
+// applyRefCandidates fills the picker once the candidates are loaded
+func (m *Model) applyRefCandidates(msg refCandidatesMsg) {
+	m.picker.candidates = msg.candidates
+	m.filterRefs()
+}
+
+// filterRefs re-runs the fuzzy search; the working tree is only a target,
+// while going back to it can be picked from either field
+func (m *Model) filterRefs() {
+	texts := make([]string, len(m.picker.candidates))
+	for i, c := range m.picker.candidates {
+		texts[i] = c.label() + " " + c.detail
+	}
+
+	m.picker.matches = nil
+	for _, i := range fuzzyFilter(m.picker.query.Value(), texts) {
+		if c := m.picker.candidates[i]; c.rev == "" && !c.back && !m.picker.editTarget {
+			continue
+		}
+		m.picker.matches = append(m.picker.matches, i)
+	}
+	m.picker.selected = min(m.picker.selected, max(len(m.picker.matches)-1, 0))
+}
+
+// updateRefPicker handles input while the ref picker is open
+func (m Model) updateRefPicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.op != nil && keyMsg.String() == "esc" {
+		m.cancelOperation()
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		m.closeRefPicker()
+		return m, nil
+	case "down", "ctrl+n", "ctrl+j":
+		m.picker.selected = min(m.picker.selected+1, max(len(m.picker.matches)-1, 0))
+		return m, nil
+	case "up", "ctrl+p", "ctrl+k":
+		m.picker.selected = max(m.picker.selected-1, 0)
+		return m, nil
+	case "tab", "shift+tab":
+		m.setPickerField(!m.picker.editTarget)
+		return m, nil
+	case "ctrl+x":
+		m.picker.cmp = m.picker.cmp.swapped()
+		return m, nil
+	case "ctrl+t":
+		m.picker.cmp.threeDot = !m.picker.cmp.threeDot
+		return m, nil
+	case "ctrl+s":
+		return m, m.applyComparison(m.picker.cmp)
+	case "enter":
+		return m, m.pickRef()
+	}
+
+	var cmd tea.Cmd
+	before := m.picker.query.Value()
+	m.picker.query, cmd = m.picker.query.Update(keyMsg)
+	if m.picker.query.Value() != before {
+		m.picker.selected = 0
+		m.filterRefs()
+	}
+	return m, cmd
+}
+
+// setPickerField switches between picking the base and the target
+func (m *Model) setPickerField(target bool) {
+	m.picker.editTarget = target
+	m.picker.query.SetValue("")
+	m.picker.selected = 0
+	m.filterRefs()
+}
+
+// pickRef fills the current field with the selected candidate, or the typed
+// text if nothing matches, and loads the comparison once both are set
+func (m *Model) pickRef() tea.Cmd {
+	var rev string
+	switch query := strings.TrimSpace(m.picker.query.Value()); {
+	case m.picker.selected < len(m.picker.matches):
+		c := m.picker.candidates[m.picker.matches[m.picker.selected]]
+		if c.back {
+			return m.leaveComparison()
+		}
+		rev = c.rev
+	case query != "":
+		rev = query
+	default:
+		return nil
+	}
+
+	if !m.picker.editTarget {
+		m.picker.cmp.base = rev
+		m.setPickerField(true)
+		return nil
+	}
+	m.picker.cmp.target = rev
+	return m.applyComparison(m.picker.cmp)
+}
+
+// applyComparison reloads the diff for cmp without restarting
+func (m *Model) applyComparison(cmp comparison) tea.Cmd {
+	if cmp.base == "" {
+		return nil
+	}
+	m.closeRefPicker()
+	m.restoreView()
+	m.compare = &cmp
+	m.sections = false
+	m.diffArgs = cmp.Args()
+	return m.refreshDiff()
+}
+
+// leaveComparison goes back to the staged and unstaged changes of the working
+// tree
+func (m *Model) leaveComparison() tea.Cmd {
+	m.closeRefPicker()
+	m.restoreView()
+	m.compare = nil
+	m.sections = true
+	m.diffArgs = nil
+	return m.refreshDiff()
+}
+
+// swapComparison flips the sides of the current comparison
+func (m *Model) swapComparison() tea.Cmd {
+	if m.compare == nil {
+		return m.notify(SeverityInfo, "Pick two revisions with r first")
+	}
+	if m.compare.target == "" {
+		return m.notify(SeverityWarning, "The working tree can only be the target")
+	}
+	return m.applyComparison(m.compare.swapped())
+}
+
+// toggleThreeDot switches the current comparison between two and three dots
+func (m *Model) toggleThreeDot() tea.Cmd {
+	if m.compare == nil {
+		return m.notify(SeverityInfo, "Pick two revisions with r first")
+	}
+	cmp := *m.compare
+	cmp.threeDot = !cmp.threeDot
+	return m.applyComparison(cmp)
+}
+
+func (m *Model) closeRefPicker() {
+	m.picker.active = false
+	m.picker.query.Blur()
+}
+
+// renderRefPicker renders the ref picker over the main view
+func (m Model) renderRefPicker() string {
+	width := commitModalWidth + 20
+	title := ModalTitleStyle.Render("Compare " + m.picker.cmp.String())
+
+	field := func(label, value string, active bool) string {
+		text := fmt.Sprintf("%s: %s", label, value)
+		if active {
+			return FileItemSelectedStyle.Render(text)
+		}
+		return text
+	}
+	target := m.picker.cmp.target
+	if target == "" {
+		target = "working tree"
+	}
+	mode := "two-dot"
+	if m.picker.cmp.threeDot {
+		mode = "three-dot (from merge base)"
+	}
+	fields := strings.Join([]string{
+		field("Base", m.picker.cmp.base, !m.picker.editTarget),
+		field("Target", target, m.picker.editTarget),
+		"Mode: " + mode,
+	}, "   ")
+
+	var lines []string
+	for i, idx := range m.picker.matches {
+		c := m.picker.candidates[idx]
+		line := fmt.Sprintf("%-8s %-24s %s", c.kind, c.label(), c.detail)
+		line = truncate(line, width)
+		if i == m.picker.selected {
+			line = FileItemSelectedStyle.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if query := strings.TrimSpace(m.picker.query.Value()); len(lines) == 0 && query != "" {
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No matches; Enter uses " + query + " as typed")}
+	} else if len(lines) == 0 {
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No matches")}
+	}
+	visible := max(m.height-16, 1)
+	start := max(m.picker.selected-visible+1, 0)
+	end := min(start+visible, len(lines))
+	list := lipgloss.NewStyle().Width(width).Render(strings.Join(lines[start:end], "\n"))
+
+	help := "Enter: pick | Tab: base/target | Ctrl+s: compare | Esc: close\n" +
+		"Ctrl+x: swap sides | Ctrl+t: two-dot/three-dot"
+	if m.op != nil {
+		help = m.operationStatus()
+	}
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left,
+		title, fields, lipgloss.NewStyle().MarginTop(1).Render(m.picker.query.View()), list, ModalHelpStyle.Render(help)))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refsRepo is a memrepo.Repo with branches that records the diffs asked for
+type refsRepo struct {
+	*memrepo.Repo
+	diffs *[][]string
+}
+
+func (r refsRepo) Refs(context.Context) ([]parser.Ref, error) {
+	return []parser.Ref{
+		{Name: "main", Kind: parser.RefBranch, Subject: "Tip of main"},
+		{Name: "feature/login", Kind: parser.RefBranch, Subject: "Add login"},
+		{Name: "v1.0", Kind: parser.RefTag, Subject: "Release"},
+	}, nil
+}
+
+func (r refsRepo) Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error) {
+	*r.diffs = append(*r.diffs, args)
+	return []diff.FileDiff{parser.DiffContents("a.txt", []byte("a\n"), []byte("b\n"), parser.DefaultContextLines)}, nil
+}
+
+func newRefsTestModel(t *testing.T) (Model, *[][]string) {
+	t.Helper()
+	diffs := &[][]string{}
+	repo := memrepo.New(map[string]string{"a.txt": "a\n"})
+	m := newTestModel(t, repo)
+	m.repo = refsRepo{Repo: repo, diffs: diffs}
+	return m, diffs
+}
+
+func lastDiff(diffs *[][]string) string {
+	if len(*diffs) == 0 {
+		return ""
+	}
+	return strings.Join((*diffs)[len(*diffs)-1], " ")
+}
+
+func TestModel_RefPickerComparesTwoRevisions(t *testing.T) {
+	m, diffs := newRefsTestModel(t)
+
+	m = pressKey(m, "r")
+	if !m.picker.active || len(m.picker.matches) == 0 {
+		t.Fatal("expected the ref picker with candidates")
+	}
+
+	// Fuzzy search for the base, then the target
+	m = typeText(m, "fealog")
+	if c := m.picker.candidates[m.picker.matches[0]]; c.rev != "feature/login" {
+		t.Fatalf("expected feature/login first, got %q", c.rev)
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.picker.cmp.base != "feature/login" || !m.picker.editTarget {
+		t.Fatalf("expected the base picked and the target next, got %+v", m.picker.cmp)
+	}
+
+	m = typeText(m, "main")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.picker.active {
+		t.Fatal("expected picking the target to close the picker")
+	}
+	if got := lastDiff(diffs); got != "feature/login main" {
+		t.Errorf("diff args = %q", got)
+	}
+	if !strings.Contains(m.View(), "feature/login..main") {
+		t.Error("expected the comparison in the file list title")
+	}
+
+	// Swap sides, then switch to three-dot
+	m = pressKey(m, "x")
+	if got := lastDiff(diffs); got != "main feature/login" {
+		t.Errorf("swapped diff args = %q", got)
+	}
+	m = pressKey(m, ".")
+	if got := lastDiff(diffs); got != "main...feature/login" {
+		t.Errorf("three-dot diff args = %q", got)
+	}
+}
+
+func TestModel_RefPickerWorkingTreeTarget(t *testing.T) {
+	m, diffs := newRefsTestModel(t)
+
+	m = pressKey(m, "r")
+	m = typeText(m, "v1")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	// The working tree is only offered as a target
+	m = typeText(m, "working")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := lastDiff(diffs); got != "v1.0" {
+		t.Errorf("diff args = %q", got)
+	}
+
+	m = pressKey(m, "x")
+	if len(m.notifications) == 0 || m.notifications[len(m.notifications)-1].Severity != SeverityWarning {
+		t.Error("expected a warning when swapping with the working tree")
+	}
+}
+
+func TestModel_RefPickerTypedRevisionAndBack(t *testing.T) {
+	m, diffs := newRefsTestModel(t)
+
+	// A revision outside the candidates is used as typed
+	m = pressKey(m, "r")
+	m = typeText(m, "0123abc")
+	if len(m.picker.matches) != 0 {
+		t.Fatalf("expected no matches, got %d", len(m.picker.matches))
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(m, "main")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := lastDiff(diffs); got != "0123abc main" {
+		t.Fatalf("diff args = %q", got)
+	}
+
+	// The picker then leads back to the working tree sections
+	m = pressKey(m, "r")
+	m = typeText(m, "back")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.picker.active || m.compare != nil || !m.sections || m.diffArgs != nil {
+		t.Errorf("expected the working tree sections back, compare %v", m.compare)
+	}
+}
+
+func TestComparisonArgs(t *testing.T) {
+	tests := []struct {
+		cmp  comparison
+		want string
+	}{
+		{comparison{base: "a", target: "b"}, "a b"},
+		{comparison{base: "a", target: "b", threeDot: true}, "a...b"},
+		{comparison{base: "a"}, "a"},
+		{comparison{base: "a", threeDot: true}, "--merge-base a"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.cmp.Args(), " "); got != tt.want {
+			t.Errorf("%v.Args() = %q, want %q", tt.cmp, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// review is a branch under review, shown as a whole or a commit at a time
+type review struct {
+	*parser.Review
+	index int // Commit shown; -1 is the cumulative diff
+	last  int // Commit t goes back to from the cumulative diff
+}
+
+// reviewStepMsg carries the diff of a review step and the file to keep selected
+type reviewStepMsg struct {
+	index   int
+	path    string
+	refresh refreshedMsg
+}
+
+// NewReviewModel creates a TUI model for reviewing a branch, starting with
+// the cumulative diff in files
+func NewReviewModel(r *parser.Review, files []diff.FileDiff, repo parser.Repository, rootName string) Model {
+	m := NewModel(files, repo, r.Args(), rootName)
+	m.review = &review{Review: r, index: -1}
+	return m
+}
+
+// args returns the git diff args of step index
+func (r *review) args(index int) []string {
+	if index < 0 {
+		return r.Args()
+	}
+	return parser.CommitArgs(r.Commits[index])
+}
+
+// title names the step shown, for the file list; the header has the rest
+func (r *review) title() string {
+	if r.index < 0 {
+		return "Review: all"
+	}
+	return fmt.Sprintf("Review: %d/%d", r.index+1, len(r.Commits))
+}
+
+// header describes the step shown: the branch's commits, or one commit
+func (r *review) header() []string {
+	if r.index >= 0 {
+		return commitHeader(r.Commits[r.index], time.Now())
+	}
+	lines := []string{fmt.Sprintf("%s since %s (merge base %.7s)", plural(len(r.Commits), "commit"), r.Base, r.MergeBase)}
+	for _, c := range r.Commits {
+		lines = append(lines, fmt.Sprintf("  %s %s", c.ShortHash, c.Subject))
+	}
+	return lines
+}
+
+// stepReview moves to the next or previous commit of the review; from the
+// cumulative diff, next starts at the first commit and previous at the last
+func (m *Model) stepReview(delta int) tea.Cmd {
+	if m.review == nil {
+		return m.notify(SeverityInfo, "Start a review with diff-tui review <base>")
+	}
+	index := m.review.index + delta
+	if m.review.index < 0 && delta < 0 {
+		index = len(m.review.Commits) - 1
+	}
+	index = min(max(index, 0), len(m.review.Commits)-1)
+	if index == m.review.index {
+		return nil
+	}
+	return m.loadReviewStep(index)
+}
+
+// toggleReview switches between the cumulative diff and the last commit viewed
+func (m *Model) toggleReview() tea.Cmd {
+	if m.review == nil {
+		return m.notify(SeverityInfo, "Start a review with diff-tui review <base>")
+	}
+	if m.review.index >= 0 {
+		return m.loadReviewStep(-1)
+	}
+	return m.loadReviewStep(m.review.last)
+}
+
+// loadReviewStep loads the diff of step index in the background
+func (m *Model) loadReviewStep(index int) tea.Cmd {
+	var path string
+	if m.selectedIdx < len(m.visibleNodes) && m.visibleNodes[m.selectedIdx].File != nil {
+		path = m.visibleNodes[m.selectedIdx].File.Name
+	}
+	name := "load all commits"
+	if index >= 0 {
+		name = "load " + m.review.Commits[index].ShortHash
+	}
+	repo, args := m.repo, m.review.args(index)
+	return m.startOperation(name, func(ctx context.Context) tea.Msg {
+		return reviewStepMsg{index: index, path: path, refresh: loadRefresh(ctx, repo, false, args)}
+	})
+}
+
+// applyReviewStep shows a loaded step, keeping the same file selected when
+// the step changes it
+func (m *Model) applyReviewStep(msg reviewStepMsg) {
+	m.restoreView()
+	m.compare = nil
+	m.sections = false
+	m.review.index = msg.index
+	if msg.index >= 0 {
+		m.review.last = msg.index
+	}
+	m.diffArgs = m.review.args(msg.index)
+	m.applyRefresh(msg.refresh)
+	if !m.selectFile(msg.path) {
+		m.selectFileNear(0)
+	}
+	m.resize()
+}
+
+// selectFile selects the file named path if it is in the tree, expanding the
+// directories above it
+func (m *Model) selectFile(path string) bool {
+	var target *TreeNode
+	var find func(nodes []*TreeNode)
+	find = func(nodes []*TreeNode) {
+		for _, n := range nodes {
+			if target != nil {
+				return
+			}
+			if n.File != nil && n.File.Name == path {
+				target = n
+				return
+			}
+			find(n.Children)
+		}
+	}
+	find(m.treeRoots)
+	if target == nil {
+		return false
+	}
+
+	for p := target.Parent; p != nil; p = p.Parent {
+		p.Expanded = true
+	}
+	m.visibleNodes = FlattenVisible(m.treeRoots)
+	m.selectNode(target)
+	return true
+}
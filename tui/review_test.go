@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewRepo answers diffs of a made-up review by their args
+type reviewRepo struct {
+	*memrepo.Repo
+	diffs map[string][]string // Files changed, by diff args
+}
+
+func (r reviewRepo) Diff(ctx context.Context, args ...string) ([]diff.FileDiff, error) {
+	var files []diff.FileDiff
+	for _, name := range r.diffs[strings.Join(args, " ")] {
+		files = append(files, parser.DiffContents(name, []byte("a\n"), []byte("b\n"), parser.DefaultContextLines))
+	}
+	return files, nil
+}
+
+func newReviewTestModel(t *testing.T) Model {
+	t.Helper()
+	review := &parser.Review{
+		Base:      "main",
+		MergeBase: "base",
+		Head:      "c2",
+		Commits: []parser.LogEntry{
+			{Hash: "c1", ShortHash: "c1", Parents: []string{"base"}, Subject: "Add parser"},
+			{Hash: "c2", ShortHash: "c2", Parents: []string{"c1"}, Subject: "Fix parser"},
+		},
+	}
+	repo := reviewRepo{Repo: memrepo.New(nil), diffs: map[string][]string{
+		"base c2": {"a.go", "b.go", "c.go"},
+		"base c1": {"a.go", "b.go"},
+		"c1 c2":   {"c.go"},
+	}}
+	files, _ := repo.Diff(context.Background(), review.Args()...)
+	m := NewReviewModel(review, files, repo, "repo")
+	return update(m, tea.WindowSizeMsg{Width: 120, Height: 40})
+}
+
+func selectedName(m Model) string {
+	if node := m.visibleNodes[m.selectedIdx]; node.File != nil {
+		return node.File.Name
+	}
+	return ""
+}
+
+func TestModel_ReviewStepsThroughCommits(t *testing.T) {
+	m := newReviewTestModel(t)
+	if !strings.Contains(m.View(), "2 commits since main") {
+		t.Fatal("expected the cumulative diff first")
+	}
+
+	// Select b.go, which the first commit also changes
+	m.selectFile("b.go")
+	m = pressKey(m, "n")
+	if m.review.index != 0 || len(m.files) != 2 || selectedName(m) != "b.go" {
+		t.Fatalf("index %d, %d files, selected %q", m.review.index, len(m.files), selectedName(m))
+	}
+	if view := m.View(); !strings.Contains(view, "Review: 1/2") || !strings.Contains(view, "Add parser") {
+		t.Error("expected the step in the title and the commit's message in the header")
+	}
+
+	// The second commit does not change b.go
+	m = pressKey(m, "n")
+	if m.review.index != 1 || selectedName(m) != "c.go" {
+		t.Fatalf("index %d, selected %q", m.review.index, selectedName(m))
+	}
+	m = pressKey(m, "n")
+	if m.review.index != 1 {
+		t.Error("expected next to stop at the last commit")
+	}
+
+	// t goes to the cumulative diff and back to the commit
+	m = pressKey(m, "t")
+	if m.review.index != -1 || len(m.files) != 3 || selectedName(m) != "c.go" {
+		t.Fatalf("index %d, %d files, selected %q", m.review.index, len(m.files), selectedName(m))
+	}
+	m = pressKey(m, "t")
+	if m.review.index != 1 {
+		t.Errorf("expected t to return to commit 2, got %d", m.review.index)
+	}
+
+	m = pressKey(m, "p")
+	if m.review.index != 0 || strings.Join(m.diffArgs, " ") != "base c1" {
+		t.Errorf("index %d, args %v", m.review.index, m.diffArgs)
+	}
+}
+
+func TestModel_ReviewKeysOutsideReview(t *testing.T) {
+	m := newTestModel(t, memrepo.New(map[string]string{"a.txt": "a\n"}))
+	m = pressKey(m, "n")
+	if len(m.notifications) == 0 || !strings.Contains(m.notifications[len(m.notifications)-1].Text, "diff-tui review") {
+		t.Error("expected a hint about the review command")
+	}
+}
+
+// findNode returns the visible node at path in the unstaged section
+func findNode(t *testing.T, m Model, path string) int {
+	t.Helper()
+	for i, n := range m.visibleNodes {
+		if n.Path == path && n.Section == SectionUnstaged {
+			return i
+		}
+	}
+	t.Fatalf("%s is not visible", path)
+	return -1
+}
+
+func TestModel_SelectFileInCollapsedDirectory(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "a\n", "pkg/b.go": "b\n"})
+	repo.WriteFile("a.txt", "A\n")
+	repo.WriteFile("pkg/b.go", "B\n")
+	m := newTestModel(t, repo)
+
+	m.selectedIdx = findNode(t, m, "pkg")
+	m.visibleNodes[m.selectedIdx].ToggleExpanded()
+	m.visibleNodes = FlattenVisible(m.treeRoots)
+	m.selectedIdx = findNode(t, m, "a.txt")
+
+	if !m.selectFile("pkg/b.go") || selectedName(m) != "pkg/b.go" {
+		t.Fatalf("expected pkg/b.go selected, got %q", selectedName(m))
+	}
+	if m.selectFile("missing.go") {
+		t.Error("expected no match for a file outside the diff")
+	}
+}
@@ -0,0 +1,334 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"diff-tui/diff"
+	"diff-tui/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stashListMsg carries the stash entries for the stash panel
+type stashListMsg struct {
+	entries []parser.StashEntry
+	err     error
+}
+
+// stashDiffMsg carries the changes of a stash entry to show in the file tree
+type stashDiffMsg struct {
+	entry parser.StashEntry
+	files []diff.FileDiff
+	err   error
+}
+
+// stashDoneMsg reports a stash push, apply, pop or drop, with the stash list
+// and working tree reloaded even on failure, as a conflicted pop changes files
+type stashDoneMsg struct {
+	done    string // Success notification
+	entries []parser.StashEntry
+	refresh *refreshedMsg // nil if cancelled before reloading
+	err     error
+}
+
+// stasher returns the repository's stash support, if it has any
+func (m *Model) stasher() (parser.Stasher, bool) {
+	s, ok := m.repo.(parser.Stasher)
+	return s, ok
+}
+
+// openStashPanel shows the stash panel and loads the entries
+func (m *Model) openStashPanel() tea.Cmd {
+	stasher, ok := m.stasher()
+	if !ok {
+		return m.notify(SeverityWarning, "This repository does not support stashes")
+	}
+
+	m.stashActive = true
+	m.stashConfirm = ""
+	return m.startOperation("stash list", func(ctx context.Context) tea.Msg {
+		entries, err := stasher.StashList(ctx)
+		return stashListMsg{entries: entries, err: err}
+	})
+}
+
+// updateStashPanel handles input while the stash panel is open
+func (m Model) updateStashPanel(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.op != nil {
+		if key.Matches(keyMsg, m.keys.Cancel) {
+			m.cancelOperation()
+		}
+		return m, nil
+	}
+
+	// An apply, pop or drop waits for y
+	if m.stashConfirm != "" {
+		action := m.stashConfirm
+		m.stashConfirm = ""
+		if keyMsg.String() == "y" {
+			return m, m.runStashAction(action)
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Cancel), key.Matches(keyMsg, m.keys.StashPanel):
+		m.stashActive = false
+	case key.Matches(keyMsg, m.keys.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, m.keys.Down):
+		m.stashSelected = min(m.stashSelected+1, max(len(m.stashEntries)-1, 0))
+	case key.Matches(keyMsg, m.keys.Up):
+		m.stashSelected = max(m.stashSelected-1, 0)
+	case key.Matches(keyMsg, m.keys.Enter):
+		return m, m.showStash()
+	default:
+		if action, ok := stashActions[keyMsg.String()]; ok && len(m.stashEntries) > 0 {
+			m.stashConfirm = action
+		}
+	}
+	return m, nil
+}
+
+// stashActions maps the stash panel keys to the actions they confirm
+var stashActions = map[string]string{"a": "apply", "p": "pop", "d": "drop"}
+
+// selectedStash returns the highlighted stash entry
+func (m *Model) selectedStash() (parser.StashEntry, bool) {
+	if m.stashSelected >= len(m.stashEntries) {
+		return parser.StashEntry{}, false
+	}
+	return m.stashEntries[m.stashSelected], true
+}
+
+// showStash loads the selected stash's changes into the file tree
+func (m *Model) showStash() tea.Cmd {
+	stasher, ok := m.stasher()
+	entry, selected := m.selectedStash()
+	if !ok || !selected {
+		return nil
+	}
+	return m.startOperation("stash show "+entry.Ref, func(ctx context.Context) tea.Msg {
+		files, err := stasher.StashDiff(ctx, entry.Ref)
+		return stashDiffMsg{entry: entry, files: files, err: err}
+	})
+}
+
+// applyStashDiff shows a stash's changes in the file tree
+func (m *Model) applyStashDiff(msg stashDiffMsg) {
+	m.stashActive = false
+	header := []string{fmt.Sprintf("%s  %s  (%s)", msg.entry.Ref, msg.entry.Message, relativeTime(msg.entry.Date, time.Now()))}
+	m.showDetached("Stash "+msg.entry.Ref, header, msg.entry.Ref+"^1", msg.files)
+}
+
+// runStashAction applies, pops or drops the selected stash entry
+func (m *Model) runStashAction(action string) tea.Cmd {
+	stasher, ok := m.stasher()
+	entry, selected := m.selectedStash()
+	if !ok || !selected {
+		return nil
+	}
+
+	var run func(context.Context, string) error
+	var done string
+	switch action {
+	case "apply":
+		run, done = stasher.StashApply, "Applied "+entry.Ref
+	case "pop":
+		run, done = stasher.StashPop, "Popped "+entry.Ref
+	case "drop":
+		run, done = stasher.StashDrop, "Dropped "+entry.Ref
+	default:
+		return nil
+	}
+
+	return m.startStashOperation("stash "+action+" "+entry.Ref, done, func(ctx context.Context) error {
+		return run(ctx, entry.Ref)
+	})
+}
+
+// stashSelectedFiles stashes the selected file or directory; from the
+// Unstaged group only the unstaged changes, which rules out untracked files
+func (m *Model) stashSelectedFiles() tea.Cmd {
+	stasher, ok := m.stasher()
+	if !ok {
+		return m.notify(SeverityWarning, "This repository does not support stashes")
+	}
+	if m.detached != nil || m.selectedIdx >= len(m.visibleNodes) {
+		return nil
+	}
+
+	node := m.visibleNodes[m.selectedIdx]
+	var opts parser.StashPushOptions
+	untracked, tracked := false, false
+	for _, file := range nodeFiles(node) {
+		opts.Paths = append(opts.Paths, file.Name)
+		untracked = untracked || file.IsUntracked
+		tracked = tracked || !file.IsUntracked
+	}
+	if len(opts.Paths) == 0 {
+		return nil
+	}
+	if node.Section == SectionUnstaged && untracked && tracked {
+		return m.notifyf(SeverityWarning, "%s mixes untracked and changed files; stash them one at a time", node.Path)
+	}
+	opts.IncludeUntracked = untracked
+	opts.WorktreeOnly = node.Section == SectionUnstaged && !untracked
+
+	done := "Stashed " + opts.Paths[0]
+	if len(opts.Paths) > 1 {
+		done = fmt.Sprintf("Stashed %d files", len(opts.Paths))
+	}
+	return m.startStashOperation("stash push "+strings.Join(opts.Paths, " "), done, func(ctx context.Context) error {
+		return stasher.StashPush(ctx, opts)
+	})
+}
+
+// stashSelectedHunks stashes the unstaged hunks touching the lines picked in
+// a diff panel
+func (m *Model) stashSelectedHunks() tea.Cmd {
+	stasher, ok := m.stasher()
+	if !ok {
+		return m.notify(SeverityWarning, "This repository does not support stashes")
+	}
+	file := m.commentFile()
+	if file == nil {
+		return nil
+	}
+	if m.visibleNodes[m.selectedIdx].Section != SectionUnstaged || file.IsUntracked {
+		return m.notify(SeverityInfo, "Only unstaged changes to tracked files can be stashed by hunk")
+	}
+
+	sel := m.lineSelect
+	from, to := sel.rows()
+	hunks := &parser.HunkSelection{New: sel.right}
+	for _, line := range side(file, sel.right)[from : to+1] {
+		if line.Number == 0 {
+			continue
+		}
+		if hunks.Start == 0 {
+			hunks.Start = line.Number
+		}
+		hunks.End = line.Number
+	}
+	m.lineSelect = nil
+	m.updateDiffContent()
+
+	opts := parser.StashPushOptions{Paths: []string{file.Name}, WorktreeOnly: true, Hunks: hunks}
+	return m.startStashOperation("stash push "+file.Name, "Stashed hunks of "+file.Name, func(ctx context.Context) error {
+		return stasher.StashPush(ctx, opts)
+	})
+}
+
+// startStashOperation runs a stash command, then reloads the stash list and
+// the view the user will return to
+func (m *Model) startStashOperation(name, done string, run func(context.Context) error) tea.Cmd {
+	stasher, _ := m.stasher()
+	repo := m.repo
+	sections, args := m.returnView()
+
+	return m.startOperation(name, func(ctx context.Context) tea.Msg {
+		msg := stashDoneMsg{done: done, err: run(ctx)}
+		if ctx.Err() != nil {
+			return msg
+		}
+		msg.entries, _ = stasher.StashList(ctx)
+		refresh := loadRefresh(ctx, repo, sections, args)
+		msg.refresh = &refresh
+		return msg
+	})
+}
+
+// applyStashDone updates the stash list and leaves a detached view, since the
+// entry it shows may be gone
+func (m *Model) applyStashDone(msg stashDoneMsg) {
+	if msg.refresh == nil {
+		return
+	}
+	m.stashEntries = msg.entries
+	m.stashSelected = min(m.stashSelected, max(len(m.stashEntries)-1, 0))
+	m.restoreView()
+	m.applyRefresh(*msg.refresh)
+}
+
+// nodeFiles returns the file of a file node, or every file below a directory
+func nodeFiles(node *TreeNode) []*diff.FileDiff {
+	if node.File != nil {
+		return []*diff.FileDiff{node.File}
+	}
+	var files []*diff.FileDiff
+	for _, child := range node.Children {
+		files = append(files, nodeFiles(child)...)
+	}
+	return files
+}
+
+// relativeTime describes t the way git log --date=relative does, roughly
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	}
+	return t.Format("2006-01-02")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// renderStashPanel renders the stash list over the main view
+func (m Model) renderStashPanel() string {
+	title := ModalTitleStyle.Render("Stashes")
+	width := commitModalWidth + 20
+
+	var lines []string
+	now := time.Now()
+	for i, e := range m.stashEntries {
+		line := fmt.Sprintf("%-10s %-15s %s", e.Ref, relativeTime(e.Date, now), e.Message)
+		line = truncate(line, width)
+		if i == m.stashSelected {
+			line = FileItemSelectedStyle.Width(width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = []string{ModalHelpStyle.MarginTop(0).Render("No stashes")}
+	}
+
+	// Keep the selection in view
+	visible := max(m.height-12, 1)
+	start := max(m.stashSelected-visible+1, 0)
+	end := min(start+visible, len(lines))
+	body := lipgloss.NewStyle().Width(width).Render(strings.Join(lines[start:end], "\n"))
+
+	help := ModalHelpStyle.Render("enter: view | a: apply | p: pop | d: drop | S/Esc: close")
+	if m.op != nil {
+		help = ModalHelpStyle.Render(m.operationStatus())
+	}
+	if m.stashConfirm != "" {
+		entry, _ := m.selectedStash()
+		help = ModalWarningStyle.Render(fmt.Sprintf("%s %s? y/n", strings.ToUpper(m.stashConfirm[:1])+m.stashConfirm[1:], entry.Ref))
+	}
+
+	modal := ModalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, body, help))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"diff-tui/memrepo"
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestModel_StashFileAndPop(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n", "b.txt": "b\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	repo.WriteFile("b.txt", "b\nchanged\n")
+	m := newTestModel(t, repo)
+
+	if node := m.visibleNodes[m.selectedIdx]; node.Path != "a.txt" {
+		t.Fatalf("expected a.txt selected, got %s", node.Path)
+	}
+	m = pressKey(m, "z")
+
+	if len(m.unstaged.Files) != 1 || m.unstaged.Files[0].Name != "b.txt" {
+		t.Fatalf("expected only b.txt left, got %+v", m.unstaged.Files)
+	}
+	if last := m.notifications[len(m.notifications)-1]; last.Text != "Stashed a.txt" {
+		t.Errorf("last notification = %q", last.Text)
+	}
+
+	// The panel lists the entry; enter shows its diff in the file tree
+	m = pressKey(m, "S")
+	if !m.stashActive || len(m.stashEntries) != 1 || !strings.Contains(m.View(), "stash@{0}") {
+		t.Fatalf("expected the stash panel with one entry, got %+v", m.stashEntries)
+	}
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detached == nil || m.sections || len(m.files) != 1 || m.files[0].Name != "a.txt" {
+		t.Fatalf("expected the stash's diff in the file tree, got %+v", m.files)
+	}
+	if !strings.Contains(m.View(), "Stash stash@{0}") {
+		t.Error("expected the file list title to name the stash")
+	}
+
+	// Pop asks first; anything but y cancels
+	m = pressKey(m, "S")
+	m = pressKey(m, "p")
+	m = pressKey(m, "n")
+	if len(m.stashEntries) != 1 {
+		t.Fatal("expected n to cancel the pop")
+	}
+	m = pressKey(m, "p")
+	if !strings.Contains(m.View(), "Pop stash@{0}? y/n") {
+		t.Error("expected a confirmation prompt")
+	}
+	m = pressKey(m, "y")
+
+	if len(m.stashEntries) != 0 {
+		t.Errorf("expected the entry to be popped, got %+v", m.stashEntries)
+	}
+	if m.detached != nil || !m.sections || len(m.unstaged.Files) != 2 {
+		t.Errorf("expected the working tree back with both files, got %d", len(m.unstaged.Files))
+	}
+}
+
+func TestModel_LeaveStashView(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	m := newTestModel(t, repo)
+	m = pressKey(m, "z")
+
+	m = pressKey(m, "S")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detached == nil {
+		t.Fatal("expected the stash view")
+	}
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.detached != nil || !m.sections {
+		t.Error("expected esc to go back to the working tree")
+	}
+}
+
+func TestModel_StashHunk(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	original := strings.Join(lines, "")
+	repo := memrepo.New(map[string]string{"h.txt": original})
+	lines[1], lines[17] = "line two\n", "line eighteen\n"
+	repo.WriteFile("h.txt", strings.Join(lines, ""))
+	m := newTestModel(t, repo)
+
+	// Pick the first line in view of the modified side, in the first hunk
+	m = pressKey(m, "tab")
+	m = pressKey(m, "tab")
+	m = pressKey(m, "a")
+	if m.lineSelect == nil {
+		t.Fatal("expected lines to be picked")
+	}
+	m = pressKey(m, "z")
+
+	if last := m.notifications[len(m.notifications)-1]; last.Text != "Stashed hunks of h.txt" {
+		t.Fatalf("last notification = %q", last.Text)
+	}
+	content, _ := repo.ReadFile(context.Background(), parser.WorktreeRev, "h.txt")
+	if want := strings.Replace(original, "line 18\n", "line eighteen\n", 1); string(content) != want {
+		t.Errorf("expected only the first hunk stashed, worktree is:\n%s", content)
+	}
+	if m.lineSelect != nil || len(m.unstaged.Files) != 1 || m.unstaged.Files[0].AddCount != 1 {
+		t.Errorf("expected the selection closed and one hunk left, got %+v", m.unstaged.Files)
+	}
+}
+
+func TestModel_StashMixedDirectory(t *testing.T) {
+	repo := memrepo.New(map[string]string{"d/a.txt": "one\n"})
+	repo.WriteFile("d/a.txt", "one\ntwo\n")
+	repo.WriteFile("d/new.txt", "new\n")
+	m := newTestModel(t, repo)
+
+	for i, node := range m.visibleNodes {
+		if node.Path == "d" && node.File == nil {
+			m.selectedIdx = i
+		}
+	}
+	if node := m.visibleNodes[m.selectedIdx]; node.Path != "d" || len(nodeFiles(node)) != 2 {
+		t.Fatalf("expected the directory d with both files, got %s", node.Path)
+	}
+	m = pressKey(m, "z")
+
+	if len(m.unstaged.Files) != 2 {
+		t.Fatalf("expected nothing stashed, got %+v", m.unstaged.Files)
+	}
+	if last := m.notifications[len(m.notifications)-1]; !strings.Contains(last.Text, "untracked") {
+		t.Errorf("last notification = %q", last.Text)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-1 * time.Minute), "1 minute ago"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{now.Add(-2 * 24 * time.Hour), "2 days ago"},
+		{now.Add(-90 * 24 * time.Hour), "2024-02-10"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime(tt.t, now); got != tt.want {
+			t.Errorf("relativeTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
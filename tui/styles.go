@@ -10,8 +10,8 @@ var (
 	deleteColor = lipgloss.Color("#E74C3C")
 	contextFg   = lipgloss.Color("#AAAAAA")
 
-	addBg    = lipgloss.Color("#1E3A2F")
-	deleteBg = lipgloss.Color("#3A1E1E")
+	addBg     = lipgloss.Color("#1E3A2F")
+	deleteBg  = lipgloss.Color("#3A1E1E")
 	lineNumFg = lipgloss.Color("#666666")
 
 	// Intense highlight colors for changed portions within a line
@@ -61,6 +61,9 @@ var (
 	EmptyLineStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#333333"))
 
+	BlameStyle = lipgloss.NewStyle().
+			Foreground(lineNumFg)
+
 	PlaceholderStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#444444")).
 				Background(lipgloss.Color("#1a1a1a"))
@@ -88,6 +91,17 @@ var (
 				Foreground(lipgloss.Color("#FFFFFF")).
 				Bold(true)
 
+	// Line numbers of the lines picked for a comment
+	LineNumSelectedStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#3d59a1")).
+				Foreground(lipgloss.Color("#FFFFFF"))
+
+	// Dimmed for files marked as viewed
+	FileItemViewedStyle = lipgloss.NewStyle().
+				PaddingLeft(1).
+				Foreground(lipgloss.Color("#5c6370")).
+				Faint(true)
+
 	AddCountStyle = lipgloss.NewStyle().
 			Foreground(addColor)
 
@@ -132,8 +146,29 @@ var (
 			Foreground(lipgloss.Color("#E74C3C")).
 			MarginTop(1)
 
+	ModalWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#e5c07b")).
+				MarginTop(1)
+
 	// Staged file indicator style
 	StatusStagedStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#2ECC71")). // Green for staged
 				Bold(true)
+
+	// Unmerged (conflicted) file indicator style
+	StatusConflictStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#E74C3C")). // Red for conflicts
+				Bold(true)
+)
+
+// Notification styles; toasts and the status-line indicator take the
+// border/foreground colour of their severity
+var (
+	NotifyInfoColor    = lipgloss.Color("#56b6c2") // Cyan
+	NotifyWarningColor = lipgloss.Color("#e5c07b") // Yellow
+	NotifyErrorColor   = lipgloss.Color("#E74C3C") // Red
+
+	ToastStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			Padding(0, 1)
 )
@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"diff-tui/parser"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// threeWayMsg carries the aligned HEAD, index and worktree versions of a
+// file; seq numbers the load
+type threeWayMsg struct {
+	seq  int
+	path string
+	file *parser.ThreeWayFile
+	err  error
+}
+
+// workingTreeView reports whether the view shows the working tree's changes,
+// the only ones the three-pane view can show
+func (m Model) workingTreeView() bool {
+	return m.detached == nil && (m.sections || parser.IsWorkingTreeDiff(m.diffArgs))
+}
+
+// threePaneView reports whether the diffs are shown as HEAD, index and
+// worktree panes
+func (m Model) threePaneView() bool {
+	return m.threePane && m.workingTreeView()
+}
+
+// threeWayTarget returns the path of the selected file in the three-pane view
+func (m Model) threeWayTarget() (string, bool) {
+	if !m.threePaneView() || m.selectedIdx >= len(m.visibleNodes) || m.visibleNodes[m.selectedIdx].File == nil {
+		return "", false
+	}
+	return m.visibleNodes[m.selectedIdx].File.Name, true
+}
+
+// toggleThreePane switches between the two-pane diff and the HEAD, index and
+// worktree panes, turning blame off
+func (m *Model) toggleThreePane() tea.Cmd {
+	if !m.threePane && !m.workingTreeView() {
+		return m.notify(SeverityInfo, "The three-pane view only shows the working tree's changes")
+	}
+	m.threePane = !m.threePane
+	m.blameOn = false
+	m.resize()
+	return m.loadThreeWay()
+}
+
+// loadThreeWay reads the selected file's three versions in the background,
+// outside the operation slot, unless they are loaded already
+func (m *Model) loadThreeWay() tea.Cmd {
+	path, ok := m.threeWayTarget()
+	if !ok || m.repo == nil {
+		return nil
+	}
+	if _, loaded := m.threeWay[path]; loaded || m.threeWayLoad.loading(path) {
+		return nil
+	}
+	seq := m.threeWayLoad.start(path)
+	repo := m.repo
+	status, _ := m.status.Get(path)
+	return func() tea.Msg {
+		head, index, worktree, err := readVersions(context.Background(), repo, status, path)
+		if err != nil {
+			return threeWayMsg{seq: seq, path: path, err: err}
+		}
+		file := parser.AlignThree(path, head, index, worktree)
+		return threeWayMsg{seq: seq, path: path, file: &file}
+	}
+}
+
+// handleThreeWay stores the versions read; failures are kept so they aren't
+// retried
+func (m *Model) handleThreeWay(msg threeWayMsg) tea.Cmd {
+	if !m.threeWayLoad.finish(msg.seq) {
+		return nil
+	}
+	m.applyThreeWay(msg)
+	if msg.err != nil {
+		return m.notifyf(SeverityError, "Reading %s failed: %v", msg.path, msg.err)
+	}
+	return nil
+}
+
+// readVersions reads the HEAD, index and worktree versions of path, with nil
+// for a version the file does not have according to its status
+func readVersions(ctx context.Context, repo parser.Repository, st parser.FileStatus, path string) (head, index, worktree []byte, err error) {
+	headPath := path
+	if st.Kind == parser.EntryRenamed && st.Index != parser.StatusUnmodified {
+		headPath = st.OrigPath
+	}
+	untracked := st.IsUntracked()
+
+	if !untracked && st.Index != parser.StatusAdded {
+		if head, err = repo.ReadFile(ctx, "HEAD", headPath); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if !untracked && st.Index != parser.StatusDeleted {
+		if index, err = repo.ReadFile(ctx, parser.IndexRev, path); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	worktree, err = repo.ReadFile(ctx, parser.WorktreeRev, path)
+	if errors.Is(err, os.ErrNotExist) {
+		worktree, err = nil, nil
+	}
+	return head, index, worktree, err
+}
+
+// applyThreeWay stores a loaded file and redraws the panes
+func (m *Model) applyThreeWay(msg threeWayMsg) {
+	if m.threeWay == nil {
+		m.threeWay = make(map[string]*parser.ThreeWayFile)
+	}
+	m.threeWay[msg.path] = msg.file
+	m.updateDiffContent()
+}
+
+// setThreeWayContent fills the three panes with the selected file, or leaves
+// them empty until it is loaded
+func (m *Model) setThreeWayContent(width int) {
+	var file *parser.ThreeWayFile
+	if path, ok := m.threeWayTarget(); ok {
+		file = m.threeWay[path]
+	}
+	if file == nil {
+		file = &parser.ThreeWayFile{}
+	}
+	m.leftViewport.SetContent(m.renderDiffLines(file.Head, width, true))
+	m.indexViewport.SetContent(m.renderDiffLines(file.Index, width, false))
+	m.rightViewport.SetContent(m.renderDiffLines(file.Worktree, width, false))
+	m.indexViewport.SetYOffset(m.leftViewport.YOffset)
+}
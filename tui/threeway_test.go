@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"diff-tui/diff"
+	"diff-tui/memrepo"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestModel_ThreePaneView(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\ntwo\n"})
+	repo.WriteFile("a.txt", "ONE\ntwo\n")
+	if err := repo.StageFile(context.Background(), "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	repo.WriteFile("a.txt", "ONE\ntwo\nthree\n")
+
+	m := newTestModel(t, repo)
+
+	// The versions are read without taking the operation slot
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	m = next.(Model)
+	if cmd == nil || m.op != nil {
+		t.Fatal("expected the versions to be read outside the operation slot")
+	}
+	m = runCmd(m, cmd)
+	view := m.View()
+	for _, title := range []string{"HEAD", "Index", "Worktree"} {
+		if !strings.Contains(view, title) {
+			t.Errorf("expected a %s pane", title)
+		}
+	}
+
+	file := m.threeWay["a.txt"]
+	if file == nil || len(file.Head) != 3 {
+		t.Fatalf("three-way file = %+v", file)
+	}
+	// The first line is staged, the last one is not
+	if file.Head[0].Type != diff.Delete || file.Index[0].Type != diff.Add || file.Worktree[0].Type != diff.Context {
+		t.Errorf("first row = %v/%v/%v", file.Head[0].Type, file.Index[0].Type, file.Worktree[0].Type)
+	}
+	if file.Index[2].Type != diff.Placeholder || file.Worktree[2].Type != diff.Add {
+		t.Errorf("last row = %v/%v", file.Index[2].Type, file.Worktree[2].Type)
+	}
+
+	m = pressKey(m, "3")
+	if view := m.View(); strings.Contains(view, "Worktree") || !strings.Contains(view, "Original") {
+		t.Error("expected 3 to go back to two panes")
+	}
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -15,6 +16,15 @@ const (
 	NodeFile
 )
 
+// Section identifies which half of the working tree a node belongs to
+type Section int
+
+const (
+	SectionNone     Section = iota // Single diff, no grouping
+	SectionStaged                  // HEAD vs index
+	SectionUnstaged                // Index vs worktree
+)
+
 // TreeNode represents a node in the file tree
 type TreeNode struct {
 	Name     string // Just the filename/dirname, not full path
@@ -25,6 +35,7 @@ type TreeNode struct {
 	File     *diff.FileDiff // nil for directories
 	Depth    int            // Indentation level
 	Parent   *TreeNode      // For navigation (go to parent)
+	Section  Section        // Staged/unstaged group, SectionNone when ungrouped
 }
 
 // BuildTree creates a tree structure from a flat list of files
@@ -47,17 +58,29 @@ func BuildTree(files []diff.FileDiff, rootName string) []*TreeNode {
 		Depth:    0,
 	}
 
+	addFiles(root, files)
+	return []*TreeNode{root}
+}
+
+// addFiles adds the tree of files below parent, with a submodule's own
+// changes as children of its node
+func addFiles(parent *TreeNode, files []diff.FileDiff) {
+	prefix := ""
+	if parent.File != nil {
+		prefix = parent.File.Name + "/"
+	}
+
 	// Map to track created directories
 	dirNodes := make(map[string]*TreeNode)
 
 	for i := range files {
 		file := &files[i]
-		path := file.Name
+		path := strings.TrimPrefix(file.Name, prefix)
 
 		// Split path into parts
 		parts := strings.Split(path, "/")
 
-		parent := root
+		dir := parent
 		currentPath := ""
 
 		// Create directory nodes as needed
@@ -73,38 +96,76 @@ func BuildTree(files []diff.FileDiff, rootName string) []*TreeNode {
 				if _, exists := dirNodes[currentPath]; !exists {
 					node := &TreeNode{
 						Name:     part,
-						Path:     currentPath,
+						Path:     prefix + currentPath,
 						Type:     NodeDirectory,
 						Expanded: true, // Start expanded by default
-						Parent:   parent,
-						Depth:    j + 1, // +1 because root is at depth 0
+						Parent:   dir,
+						Depth:    dir.Depth + 1,
 					}
 					dirNodes[currentPath] = node
-					parent.Children = append(parent.Children, node)
+					dir.Children = append(dir.Children, node)
 				}
-				parent = dirNodes[currentPath]
+				dir = dirNodes[currentPath]
 			} else {
 				// This is the file
 				node := &TreeNode{
 					Name:   part,
-					Path:   path,
+					Path:   file.Name,
 					Type:   NodeFile,
 					File:   file,
-					Parent: parent,
-					Depth:  j + 1, // +1 because root is at depth 0
+					Parent: dir,
+					Depth:  dir.Depth + 1,
+				}
+				if file.Submodule != nil && len(file.Submodule.Files) > 0 {
+					node.Expanded = true
+					addFiles(node, file.Submodule.Files)
 				}
-				parent.Children = append(parent.Children, node)
+				dir.Children = append(dir.Children, node)
 			}
 		}
 	}
 
 	// Sort all children: directories first, then alphabetically
-	sortChildren(root.Children)
+	sortChildren(parent.Children)
 	for _, dir := range dirNodes {
 		sortChildren(dir.Children)
 	}
+}
 
-	return []*TreeNode{root}
+// BuildSectionTree creates a "Staged" and an "Unstaged" root, each holding the
+// tree of its own files
+func BuildSectionTree(staged, unstaged []diff.FileDiff) []*TreeNode {
+	return []*TreeNode{
+		buildSection(SectionStaged, "Staged", staged),
+		buildSection(SectionUnstaged, "Unstaged", unstaged),
+	}
+}
+
+// buildSection builds the tree for one section, keeping the root even when empty
+func buildSection(section Section, label string, files []diff.FileDiff) *TreeNode {
+	name := fmt.Sprintf("%s (%d)", label, len(files))
+
+	var root *TreeNode
+	if roots := BuildTree(files, name); len(roots) > 0 {
+		root = roots[0]
+	} else {
+		root = &TreeNode{
+			Name:     name,
+			Path:     ".",
+			Type:     NodeDirectory,
+			Expanded: true,
+		}
+	}
+
+	setSection(root, section)
+	return root
+}
+
+func setSection(node *TreeNode, section Section) {
+	node.Section = section
+	for _, child := range node.Children {
+		setSection(child, section)
+	}
 }
 
 // sortChildren sorts nodes: directories first, then files, alphabetically within each group
@@ -131,20 +192,36 @@ func FlattenVisible(roots []*TreeNode) []*TreeNode {
 func flattenNode(node *TreeNode, result *[]*TreeNode) {
 	*result = append(*result, node)
 
-	if node.Type == NodeDirectory && node.Expanded {
+	if node.Expanded {
 		for _, child := range node.Children {
 			flattenNode(child, result)
 		}
 	}
 }
 
-// ToggleExpanded toggles the expanded state of a directory node
+// ToggleExpanded toggles the expanded state of a directory or submodule node
 func (n *TreeNode) ToggleExpanded() {
-	if n.Type == NodeDirectory {
+	if n.IsExpandable() {
 		n.Expanded = !n.Expanded
 	}
 }
 
+// IsExpandable returns true for directories, and for submodules with changes
+// of their own below them
+func (n *TreeNode) IsExpandable() bool {
+	return n.Type == NodeDirectory || len(n.Children) > 0
+}
+
+// Submodule returns the submodule node the node is in, or nil
+func (n *TreeNode) Submodule() *TreeNode {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.File != nil && p.File.Submodule != nil {
+			return p
+		}
+	}
+	return nil
+}
+
 // IsFile returns true if the node is a file
 func (n *TreeNode) IsFile() bool {
 	return n.Type == NodeFile
@@ -155,6 +232,11 @@ func (n *TreeNode) IsDirectory() bool {
 	return n.Type == NodeDirectory
 }
 
+// IsSectionRoot returns true if the node is the root of a staged/unstaged group
+func (n *TreeNode) IsSectionRoot() bool {
+	return n.Parent == nil && n.Section != SectionNone
+}
+
 // FindFirstFile finds the first file node in the tree (for initial selection)
 func FindFirstFile(roots []*TreeNode) *TreeNode {
 	visible := FlattenVisible(roots)
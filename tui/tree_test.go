@@ -249,3 +249,98 @@ func TestBuildTree_CustomRootName(t *testing.T) {
 		t.Errorf("expected root path '.', got '%s'", roots[0].Path)
 	}
 }
+
+func TestBuildSectionTree(t *testing.T) {
+	staged := []diff.FileDiff{
+		{Name: "src/main.go"},
+	}
+	unstaged := []diff.FileDiff{
+		{Name: "src/main.go"},
+		{Name: "README.md"},
+	}
+
+	roots := BuildSectionTree(staged, unstaged)
+
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 section roots, got %d", len(roots))
+	}
+
+	if roots[0].Name != "Staged (1)" || roots[1].Name != "Unstaged (2)" {
+		t.Errorf("unexpected section names '%s', '%s'", roots[0].Name, roots[1].Name)
+	}
+
+	for _, root := range roots {
+		if !root.IsSectionRoot() {
+			t.Errorf("expected '%s' to be a section root", root.Name)
+		}
+	}
+
+	// The partially staged file appears in both groups with its own section
+	stagedMain := roots[0].Children[0].Children[0]
+	unstagedMain := roots[1].Children[0].Children[0]
+	if stagedMain.Path != "src/main.go" || unstagedMain.Path != "src/main.go" {
+		t.Fatalf("expected src/main.go in both sections, got '%s' and '%s'", stagedMain.Path, unstagedMain.Path)
+	}
+	if stagedMain.Section != SectionStaged || unstagedMain.Section != SectionUnstaged {
+		t.Error("expected file nodes to carry their section")
+	}
+	if stagedMain.File == unstagedMain.File {
+		t.Error("expected each section to have its own FileDiff")
+	}
+}
+
+func TestBuildSectionTree_EmptySection(t *testing.T) {
+	roots := BuildSectionTree(nil, []diff.FileDiff{{Name: "main.go"}})
+
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 section roots, got %d", len(roots))
+	}
+
+	if roots[0].Name != "Staged (0)" || len(roots[0].Children) != 0 {
+		t.Errorf("expected empty staged section, got '%s' with %d children", roots[0].Name, len(roots[0].Children))
+	}
+
+	visible := FlattenVisible(roots)
+	// Staged root, Unstaged root, main.go
+	if len(visible) != 3 {
+		t.Fatalf("expected 3 visible nodes, got %d", len(visible))
+	}
+}
+
+func TestBuildTree_Submodule(t *testing.T) {
+	files := []diff.FileDiff{
+		{Name: "README.md"},
+		{Name: "lib/vendor", Submodule: &diff.Submodule{
+			OldCommit: "58a0494",
+			NewCommit: "4cd1bd4",
+			Files: []diff.FileDiff{
+				{Name: "lib/vendor/src/util.go"},
+				{Name: "lib/vendor/go.mod"},
+			},
+		}},
+	}
+
+	roots := BuildTree(files, "")
+	visible := FlattenVisible(roots)
+	// ., lib/, vendor, src/, util.go, go.mod, README.md
+	if len(visible) != 7 {
+		t.Fatalf("expected 7 visible nodes, got %d", len(visible))
+	}
+
+	sub := visible[2]
+	if sub.Name != "vendor" || !sub.IsFile() || !sub.IsExpandable() || !sub.Expanded {
+		t.Fatalf("expected an expanded submodule node, got %+v", sub)
+	}
+	util := visible[4]
+	if util.Path != "lib/vendor/src/util.go" || util.Depth != 4 || util.Submodule() != sub {
+		t.Errorf("expected util.go nested in the submodule, got path %q depth %d", util.Path, util.Depth)
+	}
+	if sub.Submodule() != nil {
+		t.Error("expected the submodule itself not to be inside one")
+	}
+
+	sub.ToggleExpanded()
+	if got := len(FlattenVisible(roots)); got != 4 {
+		t.Errorf("expected 4 visible nodes with the submodule collapsed, got %d", got)
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"diff-tui/parser"
+	"diff-tui/viewed"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ViewedMark follows the name of a file marked as viewed
+const ViewedMark = "✓"
+
+// repoGitDir returns the repository's git directory, or "" if it has none
+// the viewer can keep state in
+func repoGitDir(ctx context.Context, repo parser.Repository) (string, error) {
+	finder, ok := repo.(parser.GitDirFinder)
+	if !ok {
+		return "", nil
+	}
+	return finder.GitDir(ctx)
+}
+
+// loadViewed reads the viewed marks kept in the repository's git directory, or
+// keeps them for the session if there is none
+func loadViewed(ctx context.Context, repo parser.Repository) (*viewed.Store, error) {
+	gitDir, err := repoGitDir(ctx, repo)
+	if gitDir == "" || err != nil {
+		return viewed.NewMemory(), err
+	}
+	store, err := viewed.Load(gitDir)
+	if err != nil {
+		return viewed.NewMemory(), err
+	}
+	return store, nil
+}
+
+// isViewed reports whether the node is a file marked as viewed
+func (m Model) isViewed(node *TreeNode) bool {
+	return node.File != nil && m.viewed != nil && m.viewed.Viewed(node.File)
+}
+
+// allViewed reports whether every file of node is marked as viewed
+func (m Model) allViewed(node *TreeNode) bool {
+	for _, f := range nodeFiles(node) {
+		if !m.viewed.Viewed(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// toggleViewed marks or unmarks the selected file, or every file below a
+// directory
+func (m *Model) toggleViewed() tea.Cmd {
+	if m.viewed == nil || m.selectedIdx >= len(m.visibleNodes) {
+		return nil
+	}
+	node := m.visibleNodes[m.selectedIdx]
+	files := nodeFiles(node)
+	if len(files) == 0 {
+		return nil
+	}
+	mark := !m.allViewed(node)
+	for _, f := range files {
+		m.viewed.Set(f, mark)
+	}
+	if err := m.viewed.Save(); err != nil {
+		return m.notify(SeverityError, "Could not save viewed marks: "+err.Error())
+	}
+
+	if mark && m.collapseViewed {
+		m.collapseViewedDirs(node)
+	}
+	return nil
+}
+
+// collapseViewedDirs collapses the outermost directory or submodule around
+// node whose files are all viewed, short of a section root
+func (m *Model) collapseViewedDirs(node *TreeNode) {
+	var top *TreeNode
+	for n := node; n.Parent != nil; n = n.Parent {
+		if n.IsExpandable() {
+			if !m.allViewed(n) {
+				break
+			}
+			top = n
+		}
+	}
+	if top == nil || !top.Expanded {
+		return
+	}
+	top.Expanded = false
+	m.refreshVisibleNodes()
+	m.selectNode(top)
+}
+
+// viewedProgress is the status line's "12/40 viewed", or "" before anything
+// is marked
+func (m Model) viewedProgress() string {
+	if m.viewed == nil {
+		return ""
+	}
+	total, done := 0, 0
+	for _, root := range m.treeRoots {
+		for _, f := range nodeFiles(root) {
+			total++
+			if m.viewed.Viewed(f) {
+				done++
+			}
+		}
+	}
+	if done == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d viewed", done, total)
+}
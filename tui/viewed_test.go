@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"diff-tui/config"
+	"diff-tui/memrepo"
+)
+
+func TestModel_ToggleViewed(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	repo.WriteFile("a.txt", "a2\n")
+	repo.WriteFile("b.txt", "b2\n")
+	m := newTestModel(t, repo)
+	m.selectedIdx = findNode(t, m, "a.txt")
+
+	m = pressKey(m, "v")
+	if !m.isViewed(m.visibleNodes[m.selectedIdx]) {
+		t.Fatal("expected a.txt to be viewed")
+	}
+	view := m.View()
+	if !strings.Contains(view, "1/2 viewed") || !strings.Contains(view, ViewedMark) {
+		t.Error("expected the progress and the mark on screen")
+	}
+
+	// Changing the file un-marks it
+	repo.WriteFile("a.txt", "a3\n")
+	m = runCmd(m, m.refreshDiff())
+	if m.isViewed(m.visibleNodes[findNode(t, m, "a.txt")]) {
+		t.Error("expected a.txt to be un-marked by the change")
+	}
+	if strings.Contains(m.View(), "viewed") {
+		t.Error("expected no progress once nothing is viewed")
+	}
+}
+
+func TestModel_ToggleViewedDirectory(t *testing.T) {
+	repo := memrepo.New(map[string]string{"src/a.go": "a\n", "src/b.go": "b\n", "main.go": "m\n"})
+	repo.WriteFile("src/a.go", "a2\n")
+	repo.WriteFile("src/b.go", "b2\n")
+	repo.WriteFile("main.go", "m2\n")
+	m := newTestModel(t, repo).WithConfig(&config.Config{Viewed: config.ViewedConfig{Collapse: true}})
+
+	// Marking the last file of a directory collapses it
+	m.selectedIdx = findNode(t, m, "src/a.go")
+	m = pressKey(m, "v")
+	m.selectedIdx = findNode(t, m, "src/b.go")
+	m = pressKey(m, "v")
+	src := m.visibleNodes[m.selectedIdx]
+	if src.Path != "src" || src.Expanded {
+		t.Fatalf("expected src collapsed and selected, got %s", src.Path)
+	}
+
+	// A directory whose files are all viewed is un-marked as a whole
+	m = pressKey(m, "v")
+	if m.allViewed(src) || m.viewedProgress() != "" {
+		t.Error("expected the directory's files to be un-marked")
+	}
+	m = pressKey(m, "v")
+	if got := m.viewedProgress(); got != "2/3 viewed" {
+		t.Errorf("expected 2/3 viewed, got %q", got)
+	}
+}
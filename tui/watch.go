@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"context"
+
+	"diff-tui/parser"
+	"diff-tui/watch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchMsg reports that the worktree, the index or HEAD changed
+type watchMsg struct {
+	err error
+}
+
+// WithWatcher refreshes the view whenever w sees the repository change, until
+// Close is called
+func (m Model) WithWatcher(w *watch.Watcher) Model {
+	m.watcher = w
+	m.watchCtx, m.stopWatch = context.WithCancel(context.Background())
+	return m
+}
+
+// Close stops watching for changes once the program has quit
+func (m Model) Close() {
+	if m.stopWatch != nil {
+		m.stopWatch()
+	}
+}
+
+// waitForChange subscribes to the watcher's next change
+func waitForChange(ctx context.Context, w *watch.Watcher) tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return watchMsg{err: w.Wait(ctx)}
+	}
+}
+
+// handleWatch refreshes after a change and waits for the next one, dropping a
+// failing watcher
+func (m *Model) handleWatch(msg watchMsg) tea.Cmd {
+	if msg.err != nil {
+		m.watcher = nil
+		return m.notify(SeverityError, "Stopped watching for changes: "+msg.err.Error())
+	}
+	m.watchPending = true
+	return tea.Batch(m.refreshWatched(), waitForChange(m.watchCtx, m.watcher))
+}
+
+// refreshWatched runs a pending refresh without optional locks once nothing
+// else runs; a detached diff only has its review notes read again
+func (m *Model) refreshWatched() tea.Cmd {
+	if !m.watchPending || m.op != nil || m.repo == nil {
+		return nil
+	}
+	m.watchPending = false
+	if m.detached != nil {
+		m.staleNotes()
+		return m.loadNotes()
+	}
+	repo, sections, args := m.repo, m.sections, m.diffArgs
+	return m.startOperation("refresh", func(ctx context.Context) tea.Msg {
+		return loadRefresh(parser.WithoutOptionalLocks(ctx), repo, sections, args)
+	})
+}
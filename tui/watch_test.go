@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"diff-tui/memrepo"
+	"diff-tui/watch"
+)
+
+func TestModel_WatchRefresh(t *testing.T) {
+	repo := memrepo.New(map[string]string{"a.txt": "one\n"})
+	repo.WriteFile("a.txt", "one\ntwo\n")
+	m := newTestModel(t, repo)
+
+	// A change made outside the viewer shows up without a key press
+	repo.WriteFile("b.txt", "new\n")
+	m = update(m, watchMsg{})
+	if len(m.unstaged.Files) != 2 {
+		t.Fatalf("expected 2 unstaged files after the change, got %d", len(m.unstaged.Files))
+	}
+
+	// While an operation runs, the refresh waits for it to finish
+	repo.WriteFile("c.txt", "new\n")
+	m.nextOpID++
+	m.op = &operation{id: m.nextOpID, name: "slow", cancel: func() {}}
+	m = update(m, watchMsg{})
+	if !m.watchPending || len(m.unstaged.Files) != 2 {
+		t.Fatal("expected the refresh to wait for the running operation")
+	}
+	m = update(m, opDoneMsg{id: m.nextOpID})
+	if m.watchPending || len(m.unstaged.Files) != 3 {
+		t.Fatalf("expected the refresh once the operation ended, got %d files", len(m.unstaged.Files))
+	}
+}
+
+func TestModel_WatchError(t *testing.T) {
+	m := newTestModel(t, memrepo.New(map[string]string{"a.txt": "one\n"}))
+	m = update(m, watchMsg{err: errors.New("permission denied")})
+	if m.watcher != nil || len(m.toasts) == 0 || m.toasts[len(m.toasts)-1].Severity != SeverityError {
+		t.Error("expected the failing watcher to be dropped with an error")
+	}
+}
+
+func TestModel_CloseStopsWatching(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := watch.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, memrepo.New(map[string]string{"a.txt": "one\n"})).WithWatcher(w)
+
+	wait := waitForChange(m.watchCtx, m.watcher)
+	m.Close()
+	if msg, ok := wait().(watchMsg); !ok || !errors.Is(msg.err, context.Canceled) {
+		t.Errorf("expected the wait to end with the model, got %+v", msg)
+	}
+}
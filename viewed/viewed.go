@@ -0,0 +1,108 @@
+// Package viewed remembers which file diffs have been read, by the blob hashes
+// of both sides, so a mark lapses when either changes
+package viewed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"diff-tui/diff"
+)
+
+// FileName is where the marks are kept, relative to the git directory
+const FileName = "diff-tui/viewed.json"
+
+// Mark records one viewed file diff
+type Mark struct {
+	Path    string `json:"path"`
+	OldHash string `json:"old,omitempty"`
+	NewHash string `json:"new,omitempty"`
+}
+
+// matches reports whether the mark is for file as it is now; hashes may be
+// abbreviated
+func (m Mark) matches(file *diff.FileDiff) bool {
+	return m.Path == file.Name && sameHash(m.OldHash, file.OldHash) && sameHash(m.NewHash, file.NewHash)
+}
+
+func sameHash(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(b, a)
+}
+
+// Store holds the marks of a repository
+type Store struct {
+	path  string // Empty for marks that are not saved
+	marks []Mark
+}
+
+// NewMemory returns a store that keeps its marks for the session only
+func NewMemory() *Store {
+	return &Store{}
+}
+
+// Load reads the marks kept in gitDir; a missing file means no marks
+func Load(gitDir string) (*Store, error) {
+	s := &Store{path: filepath.Join(gitDir, filepath.FromSlash(FileName))}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.marks); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+// Viewed reports whether file has been marked in its current state
+func (s *Store) Viewed(file *diff.FileDiff) bool {
+	for _, m := range s.marks {
+		if m.matches(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set marks file as viewed or not, dropping marks for earlier states of the
+// same diff
+func (s *Store) Set(file *diff.FileDiff, viewed bool) {
+	kept := s.marks[:0]
+	for _, m := range s.marks {
+		earlier := sameHash(m.OldHash, file.OldHash) || sameHash(m.NewHash, file.NewHash)
+		if m.Path != file.Name || !earlier {
+			kept = append(kept, m)
+		}
+	}
+	s.marks = kept
+	if viewed {
+		s.marks = append(s.marks, Mark{Path: file.Name, OldHash: file.OldHash, NewHash: file.NewHash})
+	}
+}
+
+// Save writes the marks back, creating the directory they live in
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.marks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,66 @@
+package viewed
+
+import (
+	"testing"
+
+	"diff-tui/diff"
+)
+
+func TestStore(t *testing.T) {
+	gitDir := t.TempDir()
+	s, err := Load(gitDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	staged := &diff.FileDiff{Name: "a.go", OldHash: "1111111", NewHash: "2222222"}
+	unstaged := &diff.FileDiff{Name: "a.go", OldHash: "2222222", NewHash: "3333333"}
+	s.Set(staged, true)
+	s.Set(unstaged, true)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	s, err = Load(gitDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !s.Viewed(staged) || !s.Viewed(unstaged) {
+		t.Fatal("expected both diffs of a.go to stay viewed")
+	}
+
+	// Full hashes match the abbreviated ones
+	if !s.Viewed(&diff.FileDiff{Name: "a.go", OldHash: "2222222aaaa", NewHash: "3333333bbbb"}) {
+		t.Error("expected a full hash to match its abbreviation")
+	}
+
+	// Editing the file again un-marks it
+	edited := &diff.FileDiff{Name: "a.go", OldHash: "2222222", NewHash: "4444444"}
+	if s.Viewed(edited) {
+		t.Error("expected the edited file not to be viewed")
+	}
+	s.Set(edited, true)
+	if s.Viewed(unstaged) || !s.Viewed(staged) {
+		t.Error("expected the new mark to replace the unstaged one only")
+	}
+
+	s.Set(edited, false)
+	if s.Viewed(edited) {
+		t.Error("expected the mark to be removed")
+	}
+}
+
+func TestStore_NewFile(t *testing.T) {
+	s := NewMemory()
+	added := &diff.FileDiff{Name: "new.go", NewHash: "abcdef0"}
+	s.Set(added, true)
+	if !s.Viewed(added) {
+		t.Fatal("expected the new file to be viewed")
+	}
+	if s.Viewed(&diff.FileDiff{Name: "new.go", OldHash: "abcdef0", NewHash: "1234567"}) {
+		t.Error("expected a later change to the file not to be viewed")
+	}
+	if err := s.Save(); err != nil {
+		t.Errorf("expected saving a memory store to do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package watch polls a worktree, its index, HEAD and the review notes for
+// changes, leaving out ignored files and the rest of .git like git status
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"diff-tui/gitignore"
+	"diff-tui/parser"
+)
+
+// Defaults for a Watcher's timing
+const (
+	DefaultInterval = 500 * time.Millisecond
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+// stamp is what a scan remembers of a file; any difference counts as a change
+type stamp struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// Watcher polls the worktree at root and the index and HEAD in gitDir
+type Watcher struct {
+	Interval time.Duration // Between scans while nothing changes
+	Debounce time.Duration // How long changes must settle before Wait returns
+
+	root   string
+	gitDir string
+	last   map[string]stamp
+}
+
+// New creates a watcher for the worktree at root and scans it, so that Wait
+// reports changes made from now on
+func New(root string) (*Watcher, error) {
+	gitDir, err := findGitDir(root)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		Interval: DefaultInterval,
+		Debounce: DefaultDebounce,
+		root:     root,
+		gitDir:   gitDir,
+	}
+	if w.last, err = w.scan(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Wait blocks until something changed and then settled for the debounce
+// time, or returns the context's error
+func (w *Watcher) Wait(ctx context.Context) error {
+	delay := w.Interval
+	changed := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		current, err := w.scan()
+		if err != nil {
+			return err
+		}
+		if !sameStamps(current, w.last) {
+			w.last = current
+			changed = true
+			delay = w.Debounce
+			continue
+		}
+		if changed {
+			return nil
+		}
+	}
+}
+
+// scan stamps the worktree's files that are not ignored, plus the index, HEAD,
+// its branch and the review notes ref; ignore rules are read on every scan
+func (w *Watcher) scan() (map[string]stamp, error) {
+	stamps := make(map[string]stamp)
+	err := gitignore.New(w.root, commonDir(w.gitDir)).Walk(func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// Removed while walking; the next scan sees it gone
+			return nil
+		}
+		stamps[rel] = stampOf(info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gitFiles := []string{"index", "HEAD", filepath.FromSlash(parser.ReviewNotesRef)}
+	if head, err := os.ReadFile(filepath.Join(w.gitDir, "HEAD")); err == nil {
+		if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: "); ok {
+			gitFiles = append(gitFiles, filepath.FromSlash(ref))
+		}
+	}
+	for _, name := range gitFiles {
+		// The branch ref lives in the common directory of a linked worktree;
+		// packed refs are only rewritten along with the index or HEAD
+		for _, dir := range []string{w.gitDir, commonDir(w.gitDir)} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				stamps[".git/"+filepath.ToSlash(name)] = stampOf(info)
+				break
+			}
+		}
+	}
+	return stamps, nil
+}
+
+func stampOf(info fs.FileInfo) stamp {
+	return stamp{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+}
+
+func sameStamps(a, b map[string]stamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, s := range a {
+		if t, ok := b[path]; !ok || t.size != s.size || t.mode != s.mode || !t.modTime.Equal(s.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// findGitDir returns the git directory of the worktree at root, following the
+// "gitdir:" file of linked worktrees and submodules
+func findGitDir(root string) (string, error) {
+	dotGit := filepath.Join(root, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	target := strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	return filepath.Clean(target), nil
+}
+
+// commonDir returns the directory shared by all worktrees, which is gitDir
+// itself unless it is a linked worktree's
+func commonDir(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
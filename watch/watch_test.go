@@ -0,0 +1,135 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestRepo creates a git repository with one commit and a watcher for it
+// that polls quickly
+func newTestRepo(t *testing.T) (string, *Watcher) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.log\n")
+	writeFile(t, root, "a.txt", "a\n")
+	git(t, root, "init", "-q")
+	git(t, root, "add", ".")
+	git(t, root, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init")
+
+	w, err := New(root)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	w.Interval = 10 * time.Millisecond
+	w.Debounce = 30 * time.Millisecond
+	return root, w
+}
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// wait runs Wait with a timeout, reporting whether a change was seen
+func wait(t *testing.T, w *Watcher) bool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := w.Wait(ctx)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	return err == nil
+}
+
+func TestWait(t *testing.T) {
+	root, w := newTestRepo(t)
+
+	if wait(t, w) {
+		t.Error("expected no change in an untouched repository")
+	}
+
+	writeFile(t, root, "a.txt", "changed\n")
+	if !wait(t, w) {
+		t.Error("expected an edited file to be seen")
+	}
+
+	writeFile(t, root, "debug.log", "noise\n")
+	if wait(t, w) {
+		t.Error("expected an ignored file to be left out")
+	}
+
+	writeFile(t, root, "dir/new.txt", "new\n")
+	if !wait(t, w) {
+		t.Error("expected a new file to be seen")
+	}
+
+	// Staging only touches the index
+	git(t, root, "add", "a.txt")
+	if !wait(t, w) {
+		t.Error("expected staging to be seen")
+	}
+
+	// Switching branches moves HEAD without touching the worktree
+	git(t, root, "checkout", "-q", "-b", "topic")
+	if !wait(t, w) {
+		t.Error("expected a branch switch to be seen")
+	}
+
+	// So does a note, fetched or written
+	git(t, root, "-c", "user.name=test", "-c", "user.email=test@example.com", "notes", "--ref=review", "add", "-m", "note")
+	if !wait(t, w) {
+		t.Error("expected a review note to be seen")
+	}
+}
+
+func TestWait_Debounce(t *testing.T) {
+	root, w := newTestRepo(t)
+	w.Debounce = 100 * time.Millisecond
+
+	// Writes closer together than the debounce time are reported once
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 5 {
+			writeFile(t, root, "a.txt", string(rune('a'+i)))
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	if !wait(t, w) {
+		t.Fatal("expected the writes to be seen")
+	}
+	<-done
+	if wait(t, w) {
+		t.Error("expected the burst to be reported once")
+	}
+}
+
+func TestWait_Cancel(t *testing.T) {
+	_, w := newTestRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}